@@ -195,6 +195,9 @@ func (u *watchedUri) getHostnameForQuery() (*url.URL, error) {
 	return nil, errors.Errorf("Could not find a host for %s", u.name)
 }
 
+// Scheme is the scheme of the d2://serviceName URIs resolved by R2D2Client.ResolveURI
+const Scheme = "d2"
+
 func NewR2D2Client(conn *zk.Conn) *R2D2Client {
 	return &R2D2Client{conn: conn}
 }
@@ -210,3 +213,12 @@ func (c *R2D2Client) ResolveHostnameAndContextForQuery(resourceBaseName string,
 	}
 	return uri.getHostnameForQuery()
 }
+
+// ResolveURI implements protocol.URIResolver, resolving d2://serviceName URIs to one of the hosts of the service. Register
+// it in protocol.RestLiClient.URIResolvers under Scheme
+func (c *R2D2Client) ResolveURI(uri *url.URL) (*url.URL, error) {
+	if uri.Scheme != Scheme {
+		return nil, errors.Errorf("Unsupported scheme in %s", uri)
+	}
+	return c.ResolveHostnameAndContextForQuery(uri.Host, nil)
+}
@@ -18,6 +18,10 @@ func (m *Method) actionFuncParams(def *Group) {
 	}
 }
 
+func (m *Method) actionNameConst() string {
+	return ExportedIdentifier(m.Name + "Action")
+}
+
 func (m *Method) actionStructType() string {
 	return m.actionFuncName() + "Params"
 }
@@ -29,12 +33,11 @@ func (m *Method) actionFuncReturnParams(def *Group) {
 	def.Error()
 }
 
-func (r *Resource) GenerateActionCode(a *Method) *CodeFile {
+func (r *Resource) GenerateActionCode(a *Method) (*CodeFile, error) {
 	actionName := a.Name + "Action"
 	c := r.NewCodeFile(actionName)
 
-	actionNameConst := ExportedIdentifier(actionName)
-	c.Code.Const().Id(actionNameConst).Op("=").Lit(a.Name).Line()
+	c.Code.Const().Id(a.actionNameConst()).Op("=").Lit(a.Name).Line()
 
 	hasParams := len(a.Params) > 0
 	if hasParams {
@@ -48,20 +51,17 @@ func (r *Resource) GenerateActionCode(a *Method) *CodeFile {
 			},
 			Fields: a.Params,
 		}
-		c.Code.Add(record.GenerateCode())
+		code, err := record.generateModelCode()
+		if err != nil {
+			return nil, err
+		}
+		c.Models.Add(code)
 	}
 
-	AddWordWrappedComment(c.Code, a.Doc).Line()
+	a.addDocComment(c.Code).Line()
 	r.addClientFunc(c.Code, a)
 
 	c.Code.BlockFunc(func(def *Group) {
-		var pathFunc string
-		if a.OnEntity {
-			pathFunc = ResourceEntityPath
-		} else {
-			pathFunc = ResourcePath
-		}
-
 		returns := a.Return != nil
 		var errReturnParams []Code
 		if returns {
@@ -70,13 +70,9 @@ func (r *Resource) GenerateActionCode(a *Method) *CodeFile {
 			errReturnParams = []Code{Err()}
 		}
 
-		def.List(Id(PathVar), Err()).Op(":=").Id(pathFunc).Call(a.entityParams()...)
-		IfErrReturn(def, errReturnParams...).Line()
-		def.Id(PathVar).Op("+=").Lit("?action=").Op("+").Id(actionNameConst)
-
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, errReturnParams...).Line()
+		r.formatURL(def, a, errReturnParams...)
 
+		r.withMethodTimeout(def, a, protocol.Method_action)
 		req := def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver)
 		var params *Statement
 		if hasParams {
@@ -84,12 +80,12 @@ func (r *Resource) GenerateActionCode(a *Method) *CodeFile {
 		} else {
 			params = Struct().Block()
 		}
-		req.Dot("JsonPostRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_action), params)
+		req.Dot("JsonPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_action), params)
 		IfErrReturn(def, errReturnParams...).Line()
 
 		if returns {
 			def.Id(DoAndDecodeResult).Op(":=").Struct(Id("Value").Add(a.Return.GoType())).Block()
-			callDoAndDecode(def)
+			callDoAndDecodeEnvelope(def)
 			returnValue := Id(DoAndDecodeResult).Dot("Value")
 			if !a.Return.IsMapOrArray() {
 				returnValue = Op("&").Add(returnValue)
@@ -99,7 +95,9 @@ func (r *Resource) GenerateActionCode(a *Method) *CodeFile {
 			def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot("DoAndIgnore").Call(Id(ReqVar))
 			def.Return(Err())
 		}
-	})
+	}).Line().Line()
+
+	c.Code.Add(r.generateURLFunc(a))
 
-	return c
+	return c, nil
 }
@@ -0,0 +1,51 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/bored-engineer/go-restli/protocol"
+)
+
+const AltKeyParam = "altkey"
+
+// AlternativeKey is a key that can be used to address the entities of a collection instead of its primary key
+// https://linkedin.github.io/rest.li/Alternative-Key-Formats
+type AlternativeKey struct {
+	Name string
+	Doc  string
+	Type RestliType
+}
+
+func (k *AlternativeKey) suffix() string {
+	return "By" + ExportedIdentifier(k.Name)
+}
+
+func (m *Method) supportsAlternativeKeys() bool {
+	if m.MethodType != REST_METHOD || !m.OnEntity {
+		return false
+	}
+	switch m.RestLiMethod() {
+	case protocol.Method_get, protocol.Method_delete:
+		return true
+	default:
+		return false
+	}
+}
+
+// withAlternativeKey returns a copy of this method where the entity key is replaced with the given alternative key.
+// The alternative key is encoded in place of the primary key and the altkey query parameter names which alternative
+// key is being used
+func (m *Method) withAlternativeKey(key *AlternativeKey) *Method {
+	altMethod := *m
+	primaryKey := m.PathKeys[len(m.PathKeys)-1]
+
+	altMethod.PathKeys = append(append([]PathKey(nil), m.PathKeys[:len(m.PathKeys)-1]...), PathKey{
+		Name: key.Name,
+		Type: key.Type,
+	})
+	altMethod.Path = strings.Replace(m.Path, "{"+primaryKey.Name+"}", "{"+key.Name+"}", 1) +
+		"?" + AltKeyParam + "=" + key.Name
+	altMethod.alternativeKey = key
+
+	return &altMethod
+}
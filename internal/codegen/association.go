@@ -0,0 +1,92 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// registerAssociationKeys declares a record for each of the compound keys of association resources, and for the partial
+// keys of their finders. Rest.li sends compound keys like records, e.g. (dest:b,src:a), so once declared they are
+// encoded, decoded and parsed like any other complex key. The same key is given to every method of the association and
+// of its sub-resources, so it is only declared once.
+func (s *GoRestliSpec) registerAssociationKeys() error {
+	var errs ErrorList
+	for _, r := range s.Resources {
+		for _, m := range r.Methods {
+			for _, pk := range m.PathKeys {
+				if len(pk.AssocKeys) == 0 {
+					continue
+				}
+				if pk.Type.Reference == nil {
+					errs.Add(errors.Errorf("go-restli: Association key %s of %s does not reference a record", pk.Name,
+						r.Namespace))
+					continue
+				}
+				errs.Add(registerAssociationKey(&r, pk))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func registerAssociationKey(r *Resource, pk PathKey) error {
+	fields := append([]Field(nil), pk.AssocKeys...)
+	// Rest.li always sends the parts of compound keys sorted by name
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	id := *pk.Type.Reference
+	if t, ok := TypeRegistry[id]; ok {
+		if record, ok := t.Type.(*Record); ok && sameFieldNames(record.Fields, fields) {
+			return nil
+		}
+		return errors.Errorf("go-restli: Association key %s of %s conflicts with %s", pk.Name, r.Namespace, id)
+	}
+
+	return TypeRegistry.Register(&Record{
+		NamedType: NamedType{
+			Identifier: id,
+			SourceFile: r.SourceFile,
+			Doc:        fmt.Sprintf("%s holds the parts of the %s association key", id.Name, pk.Name),
+		},
+		Fields: fields,
+	})
+}
+
+func sameFieldNames(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// finderAssocKey returns the partial association key of finders that declare assocKeys, which is sent in the path
+// rather than in the query, or nil if the finder has none
+func (m *Method) finderAssocKey() *PathKey {
+	if m.MethodType != FINDER || len(m.PathKeys) == 0 {
+		return nil
+	}
+	pk := &m.PathKeys[len(m.PathKeys)-1]
+	// The path keys of finders in sub-resources of associations also end with an association key, which is the
+	// parent's rather than the finder's own
+	if len(pk.AssocKeys) == 0 || !strings.HasSuffix(m.Path, "{"+pk.Name+"}") {
+		return nil
+	}
+	return pk
+}
+
+// finderPathFunc is the name of the function that returns the path of finders that have a finderAssocKey, since their
+// path differs from the ResourcePath of the other methods of the resource
+func (m *Method) finderPathFunc() string {
+	return m.funcName() + "Path"
+}
@@ -0,0 +1,303 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+const (
+	BatchKeysParam = "keys"
+	BatchIdsParam  = "ids"
+)
+
+const BatchKeyFunc = "BatchKey"
+
+func (r *Resource) batchResultsType(m *Method) *Statement {
+	return Map(r.batchMapKeyType()).Add(m.Return.PointerType())
+}
+
+// hasEncodedBatchKeys returns true if the maps returned by the batch methods are keyed by the encoded keys rather than
+// by the keys themselves. Records (including the compound keys of associations) and unions hold pointers, which Go
+// compares by address, so a decoded key would never be equal to the caller's. Time typerefs are not reliably comparable
+// either, see checkMapKeys
+func (r *Resource) hasEncodedBatchKeys() bool {
+	t := r.entityKey().Type
+	if t.Reference == nil {
+		return t.Primitive == nil
+	}
+	switch ref := t.Reference.Resolve().(type) {
+	case *Enum, *Fixed:
+		return false
+	case *Typeref:
+		return !ref.isPrimitive() || ref.isTime
+	default:
+		return true
+	}
+}
+
+// batchMapKeyType is the key type of the maps returned by the batch methods, see hasEncodedBatchKeys
+func (r *Resource) batchMapKeyType() *Statement {
+	if r.hasEncodedBatchKeys() {
+		return String()
+	}
+	return r.entityKey().Type.GoType()
+}
+
+// generateBatchKey generates the BatchKey function of resources whose batch methods return maps keyed by the encoded
+// keys, which returns the key of the given entity key in those maps. The keys are always encoded with
+// protocol.RestLiUrlEncoder, independently of the client's codec and of the key's params
+func (r *Resource) generateBatchKey() *Statement {
+	key := r.entityKey()
+	encoder, hasError, _ := key.Type.RestLiURLEncodeModel(Id("key"))
+
+	def := AddWordWrappedComment(Empty(), fmt.Sprintf("%s returns the key of the given %s in the maps returned by "+
+		"the batch methods. Since %s holds pointers, which are compared by address, the maps are keyed by the "+
+		"encoding of the keys instead of by the keys themselves", BatchKeyFunc, key.Name,
+		key.Type.GoType().GoString())).Line()
+	def.Func().Id(BatchKeyFunc).Params(Id("key").Add(key.Type.GoType())).Params(String(), Error()).BlockFunc(func(def *Group) {
+		if hasError {
+			def.Return(encoder)
+		} else {
+			def.Return(encoder, Nil())
+		}
+	})
+	return def
+}
+
+func addProjection(def *Group) {
+	def.Id(PathVar).Op("=").Qual(ProtocolPackage, "AddProjection").Call(Id(PathVar), Id(FieldsParam)).Line()
+}
+
+// addBatchIds adds the ids query parameter to the path, which lists the encoded keys of the entities to fetch in the
+// format of the client's protocol version (see protocol.RestLiClient.EncodeBatchIds). It must only be called once
+// canGenerateBatchMethod has checked that the keys can be encoded
+func (r *Resource) addBatchIds(def *Group, errReturn ...Code) {
+	encoder, hasError, _ := r.entityKey().Type.RestLiCodecEncodeModel(Id("key"))
+
+	def.Line().Id(BatchIdsParam).Op(":=").Make(Index().String(), Lit(0), Len(Id(BatchKeysParam)))
+	def.For(List(Id("_"), Id("key")).Op(":=").Range().Id(BatchKeysParam)).BlockFunc(func(def *Group) {
+		declareClientCodec(def)
+		if hasError {
+			def.List(Id("encodedKey"), Err()).Op(":=").Add(encoder)
+			IfErrReturn(def, errReturn...)
+		} else {
+			def.Id("encodedKey").Op(":=").Add(encoder)
+		}
+		def.Id(BatchIdsParam).Op("=").Append(Id(BatchIdsParam), Id("encodedKey"))
+	})
+	def.Id(PathVar).Op("+=").Lit("?").Op("+").Id(ClientReceiver).Dot("EncodeBatchIds").Call(Id(BatchIdsParam))
+}
+
+const EncodedIdsLengthFunc = "EncodedIdsLength"
+
+func addEncodedIdsLengthDocComment(def *Statement) *Statement {
+	return AddWordWrappedComment(def, EncodedIdsLengthFunc+" returns the length of the ids query parameter that "+
+		"holds the given keys in the URLs of the batch methods, without building it. It can be used to split batches "+
+		"whose URL would exceed the limit of the server")
+}
+
+func (r *Resource) encodedIdsLengthFunc() *Statement {
+	return Id(EncodedIdsLengthFunc).Params(Id(BatchKeysParam).Index().Add(r.entityKey().Type.GoType())).Params(Int(), Error())
+}
+
+// generateEncodedIdsLength generates the EncodedIdsLength method of resources that have batch methods. It must only be
+// called once canGenerateBatchMethod has checked that the keys can be encoded
+func (r *Resource) generateEncodedIdsLength() *Statement {
+	encoder, hasError, _ := r.entityKey().Type.RestLiCodecEncodeModel(Id("key"))
+
+	def := addEncodedIdsLengthDocComment(Empty()).Line()
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.encodedIdsLengthFunc())
+	def.BlockFunc(func(def *Group) {
+		def.Id("length").Op(":=").Lit(0)
+		def.For(List(Id("_"), Id("key")).Op(":=").Range().Id(BatchKeysParam)).BlockFunc(func(def *Group) {
+			declareClientCodec(def)
+			if hasError {
+				def.List(Id("encodedKey"), Err()).Op(":=").Add(encoder)
+				IfErrReturn(def, Lit(0), Err())
+			} else {
+				def.Id("encodedKey").Op(":=").Add(encoder)
+			}
+			def.Id("length").Op("+=").Len(Id("encodedKey"))
+		})
+		def.Return(Id(ClientReceiver).Dot("EncodedBatchIdsLength").Call(Len(Id(BatchKeysParam)), Id("length")), Nil())
+	})
+	return def
+}
+
+func (m *Method) maxBatchSizeConst() string {
+	return m.funcName() + "MaxBatchSize"
+}
+
+func (m *Method) chunkFuncName() string {
+	return PrivateIdentifier(m.funcName())
+}
+
+// generateChunkedBatchGet generates the exported BATCH_GET, which splits the keys into chunks according to the
+// resource's max batch size and the client's BatchChunkSize (see protocol.RestLiClient.ChunkBatch), then sends one
+// request per chunk
+func (r *Resource) generateChunkedBatchGet(def *Statement, m *Method) {
+	r.addClientFunc(def, m)
+	def.BlockFunc(func(def *Group) {
+		r.chunkBatch(def, m, protocol.Method_batch_get, r.batchResultsType(m), Id(BatchKeysParam), func(def *Group) {
+			def.List(Id("chunk"), Err()).Op(":=").Id(ClientReceiver).Dot(m.chunkFuncName()).CallFunc(func(def *Group) {
+				def.Id(CtxVar)
+				for _, p := range m.entityParams() {
+					def.Add(p)
+				}
+				def.Id(BatchKeysParam).Index(Id("start").Op(":").Id("end"))
+				def.Id(FieldsParam).Op("...")
+			})
+		})
+	}).Line().Line()
+	r.generateMaxBatchSizeConst(def, m)
+}
+
+// chunkBatch generates the body of an exported batch method, which calls callChunk for each chunk of the given keys
+// through protocol.RestLiClient.ChunkBatch. callChunk must declare the results of the chunk as chunk and err, which are
+// merged into a map of the given type
+func (r *Resource) chunkBatch(def *Group, m *Method, method protocol.RestLiMethod, resultsType Code, keys Code, callChunk func(def *Group)) {
+	maxBatchSize, validate := Lit(0), false
+	if m.MaxBatchSize != nil {
+		maxBatchSize, validate = Id(m.maxBatchSizeConst()), m.MaxBatchSize.Validate
+	}
+
+	def.Id("results").Op(":=").Make(resultsType, Len(keys))
+	def.Err().Op(":=").Id(ClientReceiver).Dot("ChunkBatch").Call(
+		RestLiMethod(method), Len(keys), maxBatchSize, Lit(validate),
+		Func().Params(List(Id("start"), Id("end")).Int()).Error().BlockFunc(func(def *Group) {
+			callChunk(def)
+			def.For(List(Id("k"), Id("v")).Op(":=").Range().Id("chunk")).Block(
+				Id("results").Index(Id("k")).Op("=").Id("v"),
+			)
+			def.Return(Err())
+		}),
+	)
+	def.If(
+		List(Id("_"), Id("ok")).Op(":=").Err().Assert(Op("*").Qual(ProtocolPackage, "BatchError")),
+		Err().Op("!=").Nil().Op("&&").Op("!").Id("ok"),
+	).Block(Return(Nil(), Err()))
+	def.Return(Id("results"), Err())
+}
+
+// generateMaxBatchSizeConst declares the max batch size of the given method, if any. It must come after the method's
+// func, so that the func's doc comment (if any) stays attached to it
+func (r *Resource) generateMaxBatchSizeConst(def *Statement, m *Method) {
+	if m.MaxBatchSize != nil {
+		def.Commentf("%s is the max batch size declared by %s for %s", m.maxBatchSizeConst(), r.Namespace, m.funcName()).Line()
+		def.Const().Id(m.maxBatchSizeConst()).Op("=").Lit(m.MaxBatchSize.Value).Line().Line()
+	}
+}
+
+// canGenerateBatchMethod returns true if the keys of the resource can be both encoded into and parsed back from the
+// requests and responses of the given batch method, logging a warning otherwise
+func (r *Resource) canGenerateBatchMethod(m *Method) bool {
+	if err := r.checkBatchKeys(); err != nil {
+		Logger.Printf("Warning: cannot generate %s of %s: %s", m.Name, r.Namespace, err)
+		return false
+	}
+	return true
+}
+
+// checkBatchKeys returns an error if the keys of the resource cannot be both encoded into and parsed back from the
+// requests and responses of batch methods
+func (r *Resource) checkBatchKeys() error {
+	key := r.entityKey()
+	if key == nil {
+		return errors.New("go-restli: Resource has no entity key")
+	}
+	if !key.isParseable() {
+		return errors.New("go-restli: The entity key cannot be parsed")
+	}
+	if _, _, err := key.Type.RestLiURLEncodeModel(Id("key")); err != nil {
+		return errors.Wrap(err, "go-restli: The entity key cannot be encoded")
+	}
+	return nil
+}
+
+// generateBatchGet generates a BATCH_GET, which returns the entities for all the given keys. Keys are sent as
+// ?ids=List(k1,k2) and the results are keyed by their encoded key, which is parsed back using the resource's
+// ParseXxxKey function. Responses that are a top-level array are mapped to the keys by position instead, see
+// protocol.RestLiClient.DoAndDecodeBatchEnvelope
+// https://linkedin.github.io/rest.li/spec/protocol#batch-get
+func (r *Resource) generateBatchGet(m *Method) *Statement {
+	if !r.canGenerateBatchMethod(m) {
+		return nil
+	}
+
+	def := Empty()
+	r.generateChunkedBatchGet(def, m)
+
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.namedClientFunc(m.chunkFuncName(), m))
+	def.BlockFunc(func(def *Group) {
+		r.formatURL(def, m, Nil(), Err())
+
+		r.withMethodTimeout(def, m, protocol.Method_batch_get)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_batch_get))
+		IfErrReturn(def, Nil(), Err()).Line()
+
+		def.Id(DoAndDecodeResult).Op(":=").Struct(
+			Id("Results").Map(String()).Add(m.Return.PointerType()).Tag(JsonFieldTag("results", false)),
+			Id("Errors").Map(String()).Op("*").Qual(ProtocolPackage, "RestLiError").Tag(JsonFieldTag("errors", false)),
+		).Block()
+		callDoAndDecodeBatchEnvelope(def)
+
+		r.decodeBatchResponse(def, r.batchResultsType(m), func(v Code) Code { return v })
+	})
+
+	return def
+}
+
+// decodeBatchResponse generates the code that converts the Results and Errors of a batch response, which are keyed by
+// the encoded keys, into a map of the given type keyed by the decoded keys. value converts each result into the map's
+// value type. The results are returned, alongside a *protocol.BatchError if any of the keys failed
+func (r *Resource) decodeBatchResponse(def *Group, resultsType Code, value func(v Code) Code) {
+	key := r.entityKey()
+
+	// The keys of the results and errors are encoded the same way as in the request, which means they need to be
+	// parsed back into the key type. Complex keys also carry their params, which are dropped
+	parseKey := func(def *Group) {
+		if key.Params != nil {
+			def.List(Id("key"), Id("_"), Err()).Op(":=").Id(codecFuncName(key.parseKeyWithParamsFunc())).Call(clientCodec(), Id("k"))
+		} else {
+			def.List(Id("key"), Err()).Op(":=").Id(codecFuncName(key.parseKeyFunc())).Call(clientCodec(), Id("k"))
+		}
+		IfErrReturn(def, Nil(), Err())
+	}
+	keyValue := Id("key")
+	if key.Type.IsReferencedByPointer() {
+		keyValue = Op("*").Id("key")
+	}
+	if r.hasEncodedBatchKeys() {
+		// The key is encoded again rather than used as is, since the server may encode it differently than BatchKey
+		// (e.g. with its params, or with its fields in another order)
+		decodeKey, decodedKey := parseKey, keyValue
+		parseKey = func(def *Group) {
+			decodeKey(def)
+			def.List(Id("batchKey"), Err()).Op(":=").Id(BatchKeyFunc).Call(decodedKey)
+			IfErrReturn(def, Nil(), Err())
+		}
+		keyValue = Id("batchKey")
+	}
+
+	def.Id("results").Op(":=").Make(resultsType, Len(Id(DoAndDecodeResult).Dot("Results")))
+	def.For(List(Id("k"), Id("v")).Op(":=").Range().Id(DoAndDecodeResult).Dot("Results")).BlockFunc(func(def *Group) {
+		parseKey(def)
+		def.Id("results").Index(keyValue).Op("=").Add(value(Id("v")))
+	}).Line()
+
+	def.If(Len(Id(DoAndDecodeResult).Dot("Errors")).Op(">").Lit(0)).BlockFunc(func(def *Group) {
+		def.Id("keyErrors").Op(":=").Make(Map(Interface()).Op("*").Qual(ProtocolPackage, "RestLiError"), Len(Id(DoAndDecodeResult).Dot("Errors")))
+		def.For(List(Id("k"), Id("v")).Op(":=").Range().Id(DoAndDecodeResult).Dot("Errors")).BlockFunc(func(def *Group) {
+			parseKey(def)
+			def.Id("keyErrors").Index(keyValue).Op("=").Id("v")
+		})
+		def.Return(Id("results"), Op("&").Qual(ProtocolPackage, "BatchError").Values(Dict{
+			Id("Errors"):    Id(DoAndDecodeResult).Dot("Errors"),
+			Id("KeyErrors"): Id("keyErrors"),
+		}))
+	})
+	def.Return(Id("results"), Nil())
+}
@@ -0,0 +1,91 @@
+package codegen
+
+import (
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+)
+
+const BatchPatchesParam = "patches"
+
+func (r *Resource) batchStatusesType() *Statement {
+	return Map(r.batchMapKeyType()).Int()
+}
+
+// generateBatchPartialUpdate generates a BATCH_PARTIAL_UPDATE, which applies a patch to each of the given keys. Keys are
+// sent as ?ids=List(k1,k2) like a BATCH_GET, and the patches under the entities field of the body, keyed by their
+// encoded key. The returned map holds the status the server returned for each key. Like BATCH_GET, the patches are
+// split into chunks according to the resource's max batch size and the client's BatchChunkSize
+// https://linkedin.github.io/rest.li/spec/protocol#batch-partial-update
+func (r *Resource) generateBatchPartialUpdate(m *Method) *Statement {
+	if !r.canGenerateBatchMethod(m) {
+		return nil
+	}
+	key := r.entityKey()
+	encoder, hasError, _ := key.Type.RestLiCodecEncodeModel(Id("key"))
+
+	def := Empty()
+	r.addClientFunc(def, m)
+	def.BlockFunc(func(def *Group) {
+		def.Id(BatchKeysParam).Op(":=").Make(Index().Add(key.Type.GoType()), Lit(0), Len(Id(BatchPatchesParam)))
+		def.For(Id("key").Op(":=").Range().Id(BatchPatchesParam)).Block(
+			Id(BatchKeysParam).Op("=").Append(Id(BatchKeysParam), Id("key")),
+		).Line()
+
+		r.chunkBatch(def, m, protocol.Method_batch_partial_update, r.batchStatusesType(), Id(BatchKeysParam), func(def *Group) {
+			def.Id("chunkPatches").Op(":=").Make(Map(key.Type.GoType()).Add(m.Return.PatchType()), Id("end").Op("-").Id("start"))
+			def.For(List(Id("_"), Id("key")).Op(":=").Range().Id(BatchKeysParam).Index(Id("start").Op(":").Id("end"))).Block(
+				Id("chunkPatches").Index(Id("key")).Op("=").Id(BatchPatchesParam).Index(Id("key")),
+			)
+			def.List(Id("chunk"), Err()).Op(":=").Id(ClientReceiver).Dot(m.chunkFuncName()).CallFunc(func(def *Group) {
+				def.Id(CtxVar)
+				for _, p := range m.entityParams() {
+					def.Add(p)
+				}
+				def.Id("chunkPatches")
+			})
+		})
+	}).Line().Line()
+	r.generateMaxBatchSizeConst(def, m)
+
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.namedClientFunc(m.chunkFuncName(), m))
+	def.BlockFunc(func(def *Group) {
+		def.Id(BatchKeysParam).Op(":=").Make(Index().Add(key.Type.GoType()), Lit(0), Len(Id(BatchPatchesParam)))
+		def.For(Id("key").Op(":=").Range().Id(BatchPatchesParam)).Block(
+			Id(BatchKeysParam).Op("=").Append(Id(BatchKeysParam), Id("key")),
+		).Line()
+		r.formatURL(def, m, Nil(), Err())
+
+		def.Type().Id("entity").Struct(
+			Id("Patch").Add(m.Return.PatchType()).Tag(JsonFieldTag("patch", false)),
+		)
+		def.Id("entities").Op(":=").Make(Map(String()).Id("entity"), Len(Id(BatchPatchesParam)))
+		def.For(List(Id("key"), Id("patch")).Op(":=").Range().Id(BatchPatchesParam)).BlockFunc(func(def *Group) {
+			declareClientCodec(def)
+			if hasError {
+				def.List(Id("encodedKey"), Err()).Op(":=").Add(encoder)
+				IfErrReturn(def, Nil(), Err())
+			} else {
+				def.Id("encodedKey").Op(":=").Add(encoder)
+			}
+			def.Id("entities").Index(Id("encodedKey")).Op("=").Id("entity").Values(Dict{Id("Patch"): Id("patch")})
+		}).Line()
+
+		r.withMethodTimeout(def, m, protocol.Method_batch_partial_update)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_batch_partial_update), Op("&").Struct(
+			Id("Entities").Map(String()).Id("entity").Tag(JsonFieldTag("entities", false)),
+		).Values(Id("entities")))
+		IfErrReturn(def, Nil(), Err()).Line()
+
+		def.Id(DoAndDecodeResult).Op(":=").Struct(
+			Id("Results").Map(String()).Struct(
+				Id("Status").Int().Tag(JsonFieldTag("status", false)),
+			).Tag(JsonFieldTag("results", false)),
+			Id("Errors").Map(String()).Op("*").Qual(ProtocolPackage, "RestLiError").Tag(JsonFieldTag("errors", false)),
+		).Block()
+		callDoAndDecodeBatchEnvelope(def)
+
+		r.decodeBatchResponse(def, r.batchStatusesType(), func(v Code) Code { return Add(v).Dot("Status") })
+	})
+
+	return def
+}
@@ -0,0 +1,138 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+)
+
+const (
+	BatchResultType          = "BatchResult"
+	batchResultReceiver      = "r"
+	batchResultErrorField    = "batchError"
+	batchResultResultsField  = "Results"
+	batchResultStatusesField = "Statuses"
+	batchResultErrorsField   = "Errors"
+)
+
+// generateBatchResult generates the BatchResult type of a resource that has batch methods, which gathers the results,
+// statuses and errors of a batch request in a single value keyed by the resource's key. Batch methods keep returning
+// their results and a *protocol.BatchError, and a NewXxxResult function is generated for each of them that builds a
+// BatchResult from those return values, e.g. NewBatchGetResult(client.BatchGet(ctx, keys))
+func (r *Resource) generateBatchResult(def *Statement, batchMethods []*Method) {
+	if len(batchMethods) == 0 {
+		return
+	}
+
+	keyType := r.batchMapKeyType()
+	entityType := batchMethods[0].Return.PointerType()
+	errorType := Op("*").Qual(ProtocolPackage, "RestLiError")
+	field := func(name string) *Statement {
+		return Id(batchResultReceiver).Dot(name)
+	}
+
+	AddWordWrappedComment(def, fmt.Sprintf("%s holds the outcome of a batch request, keyed by the key of each entity. "+
+		"Build one from the values returned by a batch method with the corresponding NewXxxResult function",
+		BatchResultType)).Line()
+	def.Type().Id(BatchResultType).Struct(
+		AddWordWrappedComment(Empty(), "Results holds the entities returned by BATCH_GET"),
+		Id(batchResultResultsField).Map(keyType.Clone()).Add(entityType.Clone()),
+		AddWordWrappedComment(Empty(), "Statuses holds the status returned for each key by BATCH_PARTIAL_UPDATE"),
+		Id(batchResultStatusesField).Map(keyType.Clone()).Int(),
+		AddWordWrappedComment(Empty(), "Errors holds the errors returned for the keys that could not be processed"),
+		Id(batchResultErrorsField).Map(keyType.Clone()).Add(errorType.Clone()),
+		Line(),
+		Id(batchResultErrorField).Op("*").Qual(ProtocolPackage, "BatchError"),
+	).Line().Line()
+
+	AddWordWrappedComment(def, "Get returns the entity returned for the given key, if any").Line()
+	AddFuncOnReceiver(def, batchResultReceiver, BatchResultType, "Get").
+		Params(Id("key").Add(keyType.Clone())).
+		Add(entityType.Clone()).
+		Block(Return(field(batchResultResultsField).Index(Id("key")))).
+		Line().Line()
+
+	AddWordWrappedComment(def, "Status returns the status returned for the given key, if any").Line()
+	AddFuncOnReceiver(def, batchResultReceiver, BatchResultType, "Status").
+		Params(Id("key").Add(keyType.Clone())).
+		Params(Id("status").Int(), Id("ok").Bool()).
+		BlockFunc(func(def *Group) {
+			def.List(Id("status"), Id("ok")).Op("=").Add(field(batchResultStatusesField)).Index(Id("key"))
+			def.Return(Id("status"), Id("ok"))
+		}).Line().Line()
+
+	AddWordWrappedComment(def, "Error returns the error returned for the given key, or nil if the key was processed "+
+		"successfully").Line()
+	AddFuncOnReceiver(def, batchResultReceiver, BatchResultType, "Error").
+		Params(Id("key").Add(keyType.Clone())).
+		Add(errorType.Clone()).
+		Block(Return(field(batchResultErrorsField).Index(Id("key")))).
+		Line().Line()
+
+	AddWordWrappedComment(def, "Successful returns the keys that have a result or a status, and no error. The keys "+
+		"are not sorted").Line()
+	AddFuncOnReceiver(def, batchResultReceiver, BatchResultType, "Successful").
+		Params().
+		Params(Id("keys").Index().Add(keyType.Clone())).
+		BlockFunc(func(def *Group) {
+			for _, name := range []string{batchResultResultsField, batchResultStatusesField} {
+				def.For(Id("key").Op(":=").Range().Add(field(name))).BlockFunc(func(def *Group) {
+					if name == batchResultStatusesField {
+						// Keys that have both a result and a status were already added
+						def.If(List(Id("_"), Id("ok")).Op(":=").Add(field(batchResultResultsField)).Index(Id("key")), Id("ok")).
+							Block(Continue())
+					}
+					def.If(List(Id("_"), Id("ok")).Op(":=").Add(field(batchResultErrorsField)).Index(Id("key")), Op("!").Id("ok")).
+						Block(Id("keys").Op("=").Append(Id("keys"), Id("key")))
+				})
+			}
+			def.Return(Id("keys"))
+		}).Line().Line()
+
+	AddWordWrappedComment(def, "AnyError returns the *protocol.BatchError holding the errors of the keys that failed, "+
+		"or nil if all the keys were processed successfully").Line()
+	AddFuncOnReceiver(def, batchResultReceiver, BatchResultType, "AnyError").
+		Params().
+		Error().
+		BlockFunc(func(def *Group) {
+			def.If(field(batchResultErrorField).Op("==").Nil()).Block(Return(Nil()))
+			def.Return(field(batchResultErrorField))
+		}).Line().Line()
+
+	for _, m := range batchMethods {
+		funcName := "New" + m.funcName() + "Result"
+		var results Code
+		var resultsField string
+		switch m.RestLiMethod() {
+		case protocol.Method_batch_get:
+			results, resultsField = r.batchResultsType(m), batchResultResultsField
+		case protocol.Method_batch_partial_update:
+			results, resultsField = r.batchStatusesType(), batchResultStatusesField
+		}
+
+		AddWordWrappedComment(def, fmt.Sprintf("%s builds a %s from the values returned by %s. Errors other than a "+
+			"*protocol.BatchError are returned as is", funcName, BatchResultType, m.funcName())).Line()
+		def.Func().Id(funcName).
+			Params(Id("results").Add(results), Err().Error()).
+			Params(Op("*").Id(BatchResultType), Error()).
+			BlockFunc(func(def *Group) {
+				def.List(Id("batchError"), Id("ok")).Op(":=").Err().Assert(Op("*").Qual(ProtocolPackage, "BatchError"))
+				def.If(Err().Op("!=").Nil().Op("&&").Op("!").Id("ok")).Block(Return(Nil(), Err())).Line()
+
+				def.Id(batchResultReceiver).Op(":=").Op("&").Id(BatchResultType).Values(Dict{
+					Id(resultsField): Id("results"),
+				})
+				def.If(Id("batchError").Op("!=").Nil()).BlockFunc(func(def *Group) {
+					def.Add(field(batchResultErrorField)).Op("=").Id("batchError")
+					def.Add(field(batchResultErrorsField)).Op("=").Make(Map(keyType.Clone()).Add(errorType.Clone()), Len(Id("batchError").Dot("KeyErrors")))
+					def.For(List(Id("k"), Id("v")).Op(":=").Range().Id("batchError").Dot("KeyErrors")).Block(
+						If(List(Id("key"), Id("ok")).Op(":=").Id("k").Assert(keyType.Clone()), Id("ok")).Block(
+							field(batchResultErrorsField).Index(Id("key")).Op("=").Id("v"),
+						),
+					)
+				})
+				def.Return(Id(batchResultReceiver), Nil())
+			}).Line().Line()
+	}
+}
@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+func (r *Record) builderType() string {
+	return r.Name + "Builder"
+}
+
+// generateBuilder generates a builder with a fluent setter for every field, which is more convenient than a struct
+// literal for records with many optional fields since the setters take care of taking the address of the values
+func (r *Record) generateBuilder(def *Statement) {
+	builder := r.builderType()
+	receiver := ReceiverName(builder)
+	value := "record"
+	accessor := func(f Field) *Statement {
+		return Id(receiver).Dot(value).Dot(ExportedIdentifier(f.Name))
+	}
+
+	def.Commentf("%s builds a %s using fluent setters, see New%s", builder, r.Name, builder).Line()
+	def.Type().Id(builder).Struct(Id(value).Id(r.Name)).Line().Line()
+
+	def.Commentf("New%s returns an empty %s", builder, builder).Line()
+	def.Func().Id("New" + builder).Params().Op("*").Id(builder).Block(Return(New(Id(builder)))).Line().Line()
+
+	for _, f := range r.Fields {
+		funcName := "With" + ExportedIdentifier(f.Name)
+		var param *Statement
+		var assign *Statement
+		if f.IsPointer() && !f.Type.IsReferencedByPointer() {
+			param = f.Type.GoType()
+			assign = Add(accessor(f)).Op("=").Op("&").Id("value")
+		} else if f.IsPointer() {
+			param = f.Type.PointerType()
+			assign = Add(accessor(f)).Op("=").Id("value")
+		} else {
+			param = f.Type.GoType()
+			assign = Add(accessor(f)).Op("=").Id("value")
+		}
+
+		AddDocComment(def, fmt.Sprintf("%s sets the %s field", funcName, f.Name), f.Deprecated).Line()
+		AddFuncOnReceiver(def, receiver, builder, funcName).
+			Params(Id("value").Add(param)).
+			Op("*").Id(builder).
+			Block(assign, Return(Id(receiver))).
+			Line().Line()
+	}
+
+	hasUnionField := false
+	def.Commentf("Build returns a copy of the %s built so far, or an error if any of its required fields are not set", r.Name).Line()
+	AddFuncOnReceiver(def, receiver, builder, "Build").
+		Params().
+		Params(Op("*").Id(r.Name), Error()).
+		BlockFunc(func(def *Group) {
+			for _, f := range r.Fields {
+				hasUnionField = hasUnionField || f.Type.IsUnion()
+				// Required fields that have a default value are populated when the record is sent
+				if f.IsOptional || f.DefaultValue != nil {
+					continue
+				}
+				def.If(r.isUnset(f, accessor(f))).Block(
+					Return(Nil(), Qual("fmt", "Errorf").Call(Lit("go-restli: Missing required field "+f.Name+" in "+r.Identifier.String()))),
+				)
+			}
+			if hasUnionField {
+				def.If(Err().Op(":=").Id(receiver).Dot(value).Dot(ValidateUnionFields).Call(True()), Err().Op("!=").Nil()).
+					Block(Return(Nil(), Err()))
+			}
+			def.Id(value).Op(":=").Id(receiver).Dot(value)
+			def.Return(Op("&").Id(value), Nil())
+		}).Line().Line()
+}
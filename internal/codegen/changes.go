@@ -0,0 +1,160 @@
+package codegen
+
+import (
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+// ChangeTrackedRecords are the fully-qualified names of the records that track which of their fields were set through
+// their SetXxx functions since they were created or decoded, so that a patch with only those fields can be built
+// without diffing the record against a copy of itself
+var ChangeTrackedRecords []string
+
+const (
+	changesField = "changes"
+	ToPatch      = "ToPatch"
+)
+
+// changesWords returns the number of uint64s needed to hold one bit per field
+func (r *Record) changesWords() int {
+	return (len(r.Fields) + 63) / 64
+}
+
+func (r *Record) changesType() *Statement {
+	return Index(Lit(r.changesWords())).Uint64()
+}
+
+// changeBit returns the word and mask of the bit that tracks the i-th field
+func changeBit(i int) (word int, mask *Statement) {
+	return i / 64, Parens(Lit(1).Op("<<").Lit(i % 64))
+}
+
+// resetChanges generates the code that clears the changes of a decoded record, since its fields now hold what was
+// received rather than changes made by the caller
+func (r *Record) resetChanges(def *Group) {
+	if r.tracksChanges {
+		def.Id(r.Receiver()).Dot(changesField).Op("=").Add(r.changesType()).Values()
+	}
+}
+
+func (r *Record) generateChangeTracking(def *Statement) {
+	if !r.tracksChanges {
+		return
+	}
+
+	fieldNames := make(map[string]bool)
+	for _, f := range r.Fields {
+		fieldNames[ExportedIdentifier(f.Name)] = true
+	}
+	conflicts := func(funcName string) bool {
+		if fieldNames[funcName] {
+			Logger.Printf("Warning: cannot generate %s on %s since it conflicts with a field", funcName, r.Identifier)
+			return true
+		}
+		return false
+	}
+	changes := Id(r.Receiver()).Dot(changesField)
+
+	for i, f := range r.Fields {
+		funcName := "Set" + ExportedIdentifier(f.Name)
+		if conflicts(funcName) {
+			continue
+		}
+
+		word, mask := changeBit(i)
+		def.Commentf("%s sets the %s field and marks it as changed, see %s", funcName, f.Name, ToPatch).Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, funcName).
+			Params(Id("value").Add(r.fieldType(f))).
+			Block(
+				r.field(f).Op("=").Id("value"),
+				Add(changes).Index(Lit(word)).Op("|=").Add(mask),
+			).
+			Line().Line()
+	}
+
+	if !conflicts("ChangedFields") {
+		r.generateChangedFields(def)
+	}
+	if !conflicts("ResetChanges") {
+		def.Comment("ResetChanges marks all the fields as unchanged, e.g. once the patch returned by " + ToPatch +
+			" was sent").Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, "ResetChanges").Params().Block(
+			changes.Clone().Op("=").Add(r.changesType()).Values(),
+		).Line().Line()
+	}
+	if !conflicts(ToPatch) {
+		r.generateToPatch(def)
+	}
+}
+
+func (r *Record) generateChangedFields(def *Statement) {
+	changes := Id(r.Receiver()).Dot(changesField)
+	def.Commentf("ChangedFields returns the names of the fields that were set through the SetXxx functions since this %s "+
+		"was created, decoded or had its changes reset", r.Name).Line()
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, "ChangedFields").Params().Index().String().BlockFunc(func(def *Group) {
+		def.Var().Id("fields").Index().String()
+		for i, f := range r.Fields {
+			word, mask := changeBit(i)
+			def.If(Add(changes).Index(Lit(word)).Op("&").Add(mask).Op("!=").Lit(0)).Block(
+				Id("fields").Op("=").Append(Id("fields"), Lit(f.Name)),
+			)
+		}
+		def.Return(Id("fields"))
+	}).Line().Line()
+
+}
+
+func (r *Record) generateToPatch(def *Statement) {
+	changes := Id(r.Receiver()).Dot(changesField)
+	def.Commentf("%s returns a %s holding the changed fields: those that were set to nil are deleted, the others are "+
+		"copied into %s, such that later changes to this %s do not affect the patch. A nil patch is returned if no fields "+
+		"were changed", ToPatch, r.PatchType(), PatchSet, r.Name).Line()
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, ToPatch).
+		Params().
+		Params(Id(PartialUpdateParam).Op("*").Id(r.PatchType())).
+		BlockFunc(func(def *Group) {
+			def.Id(PartialUpdateParam).Op("=").New(Id(r.PatchType())).Line()
+
+			for i, f := range r.Fields {
+				word, mask := changeBit(i)
+				field := r.field(f)
+				def.If(Add(changes).Index(Lit(word)).Op("&").Add(mask).Op("!=").Lit(0)).BlockFunc(func(def *Group) {
+					def.If(r.isUnset(f, field)).Block(
+						Id(PartialUpdateParam).Dot(PatchDelete).Op("=").Append(Id(PartialUpdateParam).Dot(PatchDelete), Lit(f.Name)),
+					).Else().BlockFunc(func(def *Group) {
+						def.If(Id(PartialUpdateParam).Dot(PatchSet).Op("==").Nil()).Block(
+							Id(PartialUpdateParam).Dot(PatchSet).Op("=").New(Id(r.Name)),
+						)
+						def.Id(PartialUpdateParam).Dot(PatchSet).Dot(ExportedIdentifier(f.Name)).Op("=").
+							Qual(ProtocolPackage, "DeepCopy").Call(field).Assert(r.fieldType(f))
+					})
+				}).Line()
+			}
+
+			def.If(Id(PartialUpdateParam).Dot(PatchSet).Op("==").Nil().Op("&&").Len(Id(PartialUpdateParam).Dot(PatchDelete)).Op("==").Lit(0)).
+				Block(Return(Nil()))
+			def.Return(Id(PartialUpdateParam))
+		}).Line().Line()
+}
+
+// checkChangeTrackedRecords flags the ChangeTrackedRecords, and fails if any of them is not a known record
+func (reg typeRegistry) checkChangeTrackedRecords() error {
+	for _, fqn := range ChangeTrackedRecords {
+		idx := strings.LastIndex(fqn, ".")
+		if idx <= 0 {
+			return errors.Errorf("go-restli: Change tracked record %s is not a fully-qualified name", fqn)
+		}
+		t, ok := reg[Identifier{Namespace: fqn[:idx], Name: fqn[idx+1:]}]
+		if !ok {
+			return errors.Errorf("go-restli: Unknown change tracked record %s", fqn)
+		}
+		record, ok := t.Type.(*Record)
+		if !ok {
+			return errors.Errorf("go-restli: Change tracked type %s is not a record", fqn)
+		}
+		record.tracksChanges = true
+	}
+	return nil
+}
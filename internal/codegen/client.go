@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bored-engineer/go-restli/protocol"
 	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
 type Resource struct {
@@ -13,37 +15,108 @@ type Resource struct {
 	SourceFile       string
 	RootResourceName string
 	ResourceSchema   *RestliType
-	Methods          []*Method
+	// IsUnstructuredData is true for resources that serve raw bytes rather than records, see
+	// generateUnstructuredDataMethod
+	IsUnstructuredData bool
+	Methods            []*Method
+	AlternativeKeys    []*AlternativeKey
 }
 
 func (r *Resource) PackagePath() string {
 	return FqcpToPackagePath(r.Namespace)
 }
 
-func (r *Resource) GenerateCode() []*CodeFile {
-	c := &CodeFile{
-		SourceFile:  r.SourceFile,
-		PackagePath: r.PackagePath(),
-		Filename:    "client",
-		Code:        Empty(),
+func (r *Resource) InnerTypes() IdentifierSet {
+	innerTypes := make(IdentifierSet)
+	if r.ResourceSchema != nil {
+		innerTypes.AddAll(r.ResourceSchema.InnerTypes())
 	}
+	for _, m := range r.Methods {
+		for _, pk := range m.PathKeys {
+			innerTypes.AddAll(pk.Type.InnerTypes())
+			if pk.Params != nil {
+				innerTypes.AddAll(pk.Params.InnerTypes())
+			}
+		}
+		for _, p := range m.Params {
+			innerTypes.AddAll(p.Type.InnerTypes())
+		}
+		if m.Return != nil {
+			innerTypes.AddAll(m.Return.InnerTypes())
+		}
+		if m.Metadata != nil {
+			innerTypes.AddAll(m.Metadata.InnerTypes())
+		}
+	}
+	for _, k := range r.AlternativeKeys {
+		innerTypes.AddAll(k.Type.InnerTypes())
+	}
+	return innerTypes
+}
+
+func (r *Resource) GenerateCode() ([]*CodeFile, error) {
+	c := r.NewCodeFile("client")
 
 	var generatedRestMethods []Code
+	var batchMethods []*Method
 
 	AddWordWrappedComment(c.Code, r.Doc).Line()
 	c.Code.Type().Id(ClientInterfaceType).InterfaceFunc(func(def *Group) {
 		for _, m := range r.Methods {
-			if m.MethodType != REST_METHOD {
-				AddWordWrappedComment(def.Empty(), m.Doc)
-			} else {
+			if m.MethodType == REST_METHOD {
 				if code := r.GenerateRestMethodCode(m); code != nil {
 					generatedRestMethods = append(generatedRestMethods, code.Line().Line())
+					switch m.RestLiMethod() {
+					case protocol.Method_batch_get, protocol.Method_batch_partial_update:
+						batchMethods = append(batchMethods, m)
+					}
 				} else {
 					// this method is not currently supported, don't add it to the interface
 					continue
 				}
 			}
+			m.addDocComment(def.Empty())
 			def.Add(r.clientFunc(m))
+			def.Add(r.urlFunc(m))
+			if m.MethodType == REST_METHOD {
+				generatedRestMethods = append(generatedRestMethods, r.generateURLFunc(m).Line().Line())
+			}
+
+			if m.supportsAlternativeKeys() {
+				for _, k := range r.AlternativeKeys {
+					altMethod := m.withAlternativeKey(k)
+					generatedRestMethods = append(generatedRestMethods, r.GenerateRestMethodCode(altMethod).Line().Line())
+					if k.Doc != "" {
+						AddWordWrappedComment(def.Empty(), k.Doc)
+					}
+					def.Add(r.clientFunc(altMethod))
+					def.Add(r.urlFunc(altMethod))
+					generatedRestMethods = append(generatedRestMethods, r.generateURLFunc(altMethod).Line().Line())
+				}
+			}
+
+			// Unstructured data cannot be projected, so checking whether it exists would download it entirely
+			if m.RestLiMethod() == protocol.Method_get && !r.IsUnstructuredData {
+				generatedRestMethods = append(generatedRestMethods, r.generateExists(m).Line().Line())
+				addExistsDocComment(def.Empty())
+				def.Add(r.existsFunc(m))
+			}
+			if RawGet && m.RestLiMethod() == protocol.Method_get && !r.IsUnstructuredData {
+				generatedRestMethods = append(generatedRestMethods, r.generateRawGet(m).Line().Line())
+				addRawGetDocComment(def.Empty(), m)
+				def.Add(r.rawGetFunc(m))
+			}
+			if CreateStream && m.RestLiMethod() == protocol.Method_create && !r.IsUnstructuredData {
+				generatedRestMethods = append(generatedRestMethods, r.generateCreateStream(m).Line().Line())
+				addCreateStreamDocComment(def.Empty(), m)
+				def.Add(r.createStreamFunc(m))
+			}
+		}
+
+		if len(batchMethods) > 0 {
+			generatedRestMethods = append(generatedRestMethods, r.generateEncodedIdsLength().Line().Line())
+			addEncodedIdsLengthDocComment(def.Empty())
+			def.Add(r.encodedIdsLengthFunc())
 		}
 	}).Line().Line()
 	c.Code.Type().Id(ClientType).Struct(Op("*").Qual(ProtocolPackage, RestLiClient)).Line().Line()
@@ -51,59 +124,149 @@ func (r *Resource) GenerateCode() []*CodeFile {
 		Block(Return(Op("&").Id(ClientType).Values(Id("c")))).
 		Line().Line()
 
+	r.addNameConsts(c.Code)
+
 	for _, m := range r.Methods {
-		if !m.OnEntity {
-			r.addResourcePathFunc(c.Code, ResourcePath, m)
+		if !m.OnEntity && m.finderAssocKey() == nil {
+			if err := r.addResourcePathFunc(c.Code, ResourcePath, m); err != nil {
+				return nil, err
+			}
 			break
 		}
 	}
 
 	for _, m := range r.Methods {
 		if m.OnEntity {
-			r.addResourcePathFunc(c.Code, ResourceEntityPath, m)
+			if err := r.addResourcePathFunc(c.Code, ResourceEntityPath, m); err != nil {
+				return nil, err
+			}
+			r.addParseKeyFuncs(c.Code, m.PathKeys[len(m.PathKeys)-1])
+			for _, k := range r.AlternativeKeys {
+				if err := r.addResourcePathFunc(c.Code, ResourceEntityPath+k.suffix(), m.withAlternativeKey(k)); err != nil {
+					return nil, err
+				}
+			}
 			break
 		}
 	}
 
 	c.Code.Add(generatedRestMethods...)
+	r.generateBatchResult(c.Code, batchMethods)
+	if len(batchMethods) > 0 && r.hasEncodedBatchKeys() {
+		c.Code.Add(r.generateBatchKey()).Line().Line()
+	}
 
 	codeFiles := []*CodeFile{c}
+	if ServerStubs {
+		if server := r.GenerateServerCode(); server != nil {
+			codeFiles = append(codeFiles, server)
+		}
+	}
 
 	for _, m := range r.Methods {
+		var code *CodeFile
 		switch m.MethodType {
 		case REST_METHOD:
 			// This is generated during the interface definition
+			continue
 		case ACTION:
-			codeFiles = append(codeFiles, r.GenerateActionCode(m))
+			var err error
+			code, err = r.GenerateActionCode(m)
+			if err != nil {
+				return nil, err
+			}
 		case FINDER:
-			codeFiles = append(codeFiles, r.GenerateFinderCode(m))
+			var err error
+			code, err = r.GenerateFinderCode(m)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if r.isSimple() {
+			c.Models.Add(code.Models)
+			c.Code.Line().Line().Add(code.Code)
+		} else {
+			codeFiles = append(codeFiles, code)
 		}
 	}
 
-	return codeFiles
+	var splitFiles []*CodeFile
+	for _, f := range codeFiles {
+		splitFiles = append(splitFiles, f.SplitModels()...)
+	}
+	return splitFiles, nil
 }
 
-func (r *Resource) addResourcePathFunc(def *Statement, funcName string, m *Method) {
+// addNameConsts declares the name of the resource and the rest.li name of each of its methods, i.e. the method's name
+// for REST methods, or the name of the action or finder
+func (r *Resource) addNameConsts(def *Statement) {
+	def.Const().DefsFunc(func(def *Group) {
+		def.Comment("ResourceName is the name of this resource, as declared in its restspec")
+		def.Id("ResourceName").Op("=").Lit(r.Namespace[strings.LastIndex(r.Namespace, ".")+1:])
+		for i, m := range r.Methods {
+			if i == 0 {
+				def.Line().Comment("The rest.li names of this resource's methods")
+			}
+			def.Id("Method" + m.funcName()).Op("=").Lit(m.Name)
+		}
+	}).Line().Line()
+}
+
+// isSimple returns true if this resource has no entity key, i.e. it is a simple resource or an action set. Such
+// resources usually only have a handful of methods, so their actions are generated in the same file as the client
+// rather than in a file each
+func (r *Resource) isSimple() bool {
+	return r.entityKey() == nil
+}
+
+func (r *Resource) addResourcePathFunc(def *Statement, funcName string, m *Method) (err error) {
+	def.Commentf("%sTemplate is the path returned by %s, with a {placeholder} for each key", funcName, funcName).Line()
+	def.Const().Id(funcName + "Template").Op("=").Lit(m.Path).Line().Line()
+
+	def.Commentf("%s returns %sTemplate with its keys encoded by protocol.%s. The client encodes them with its own "+
+		"codec instead, see protocol.RestLiClient.Codec", funcName, funcName, RestLiUrlEncoder).Line()
 	def.Func().Id(funcName).
 		ParamsFunc(func(def *Group) { m.addEntityTypes(def) }).
+		Params(String(), Error()).
+		Block(Return(Id(codecFuncName(funcName)).Call(append([]Code{Qual(ProtocolPackage, RestLiUrlEncoder)}, m.entityParams()...)...))).
+		Line().Line()
+
+	def.Func().Id(codecFuncName(funcName)).
+		ParamsFunc(func(def *Group) {
+			def.Id(Codec).Qual(ProtocolPackage, RestLiCodec)
+			m.addEntityTypes(def)
+		}).
 		Params(String(), Error()).BlockFunc(func(def *Group) {
 
 		def.Var().Id(PathVar).String()
 		path := m.Path
 		for _, pk := range m.PathKeys {
 			encodedVariableName := pk.Name + "Str"
-			assignment, hasError := pk.Type.RestLiURLEncodeModel(Id(pk.Name))
+			var assignment *Statement
+			var hasError bool
+			assignment, hasError, err = pk.Type.RestLiCodecEncodeModel(Id(pk.Name))
+			if err != nil {
+				return
+			}
 			if hasError {
 				def.List(Id(encodedVariableName), Err()).Op(":=").Add(assignment)
 				IfErrReturn(def, Lit(""), Err())
 			} else {
 				def.Id(encodedVariableName).Op(":=").Add(assignment)
 			}
+			if pk.Params != nil {
+				err = addComplexKeyParams(def, pk, encodedVariableName)
+				if err != nil {
+					return
+				}
+			}
 
 			pattern := fmt.Sprintf("{%s}", pk.Name)
 			idx := strings.Index(path, pattern)
 			if idx < 0 {
-				Logger.Panicf("%s does not appear in %s", pattern, path)
+				err = errors.Errorf("go-restli: %s does not appear in %s", pattern, path)
+				return
 			}
 			def.Id(PathVar).Op("+=").Lit(path[:idx]).Op("+").Id(encodedVariableName)
 			path = path[idx+len(pattern):]
@@ -116,4 +279,141 @@ func (r *Resource) addResourcePathFunc(def *Statement, funcName string, m *Metho
 
 		def.Return(Id(PathVar), Nil())
 	}).Line().Line()
+	return err
+}
+
+// addComplexKeyParams adds the key's params, if they were given, to its encoded value under $params
+func addComplexKeyParams(def *Group, pk PathKey, encodedVariableName string) error {
+	assignment, hasError, err := pk.Params.RestLiCodecEncodeModel(Id(pk.paramsName()))
+	if err != nil {
+		return err
+	}
+
+	def.If(Id(pk.paramsName()).Op("!=").Nil()).BlockFunc(func(def *Group) {
+		if hasError {
+			def.List(Id("params"), Err()).Op(":=").Add(assignment)
+			IfErrReturn(def, Lit(""), Err())
+		} else {
+			def.Id("params").Op(":=").Add(assignment)
+		}
+		def.Id(encodedVariableName).Op("=").Qual(ProtocolPackage, "EncodeComplexKey").Call(Id(encodedVariableName), Id("params"))
+	})
+	return nil
+}
+
+// addParseKeyFuncs generates ParseXxxKey and MustParseXxxKey for the key of this resource's entities. Keys that cannot
+// be url decoded are skipped
+func (pk *PathKey) isParseable() bool {
+	_, err := pk.Type.RestLiURLDecodeModel(Id(pk.Name), Id("s"))
+	return err == nil
+}
+
+func (r *Resource) addParseKeyFuncs(def *Statement, pk PathKey) {
+	decoder, err := pk.Type.RestLiCodecDecodeModel(Id(pk.Name), Id("s"))
+	if err != nil {
+		Logger.Printf("Warning: cannot generate key parsing functions for %s in %s: %s", pk.Name, r.Namespace, err)
+		return
+	}
+
+	parseFunc := pk.parseKeyFunc()
+	def.Commentf("%s parses the given string as a %s, as it would appear in this resource's path", parseFunc, pk.Name).Line()
+	def.Func().Id(parseFunc).
+		Params(Id("s").String()).
+		Params(Id(pk.Name).Add(pk.Type.ReferencedType()), Err().Error()).
+		Block(Return(Id(codecFuncName(parseFunc)).Call(Qual(ProtocolPackage, RestLiUrlEncoder), Id("s")))).
+		Line().Line()
+
+	def.Func().Id(codecFuncName(parseFunc)).
+		Params(Id(Codec).Qual(ProtocolPackage, RestLiCodec), Id("s").String()).
+		Params(Id(pk.Name).Add(pk.Type.ReferencedType()), Err().Error()).
+		BlockFunc(func(def *Group) {
+			// Enums and fixed types are passed around as pointers, so they need to be allocated first
+			if pk.Type.IsReferencedByPointer() {
+				def.Id(pk.Name).Op("=").New(pk.Type.GoType())
+			}
+			def.Err().Op("=").Add(decoder)
+			def.Return(Id(pk.Name), Err())
+		}).Line().Line()
+
+	mustParseFunc := "Must" + parseFunc
+	def.Commentf("%s is like %s but panics if the key cannot be parsed", mustParseFunc, parseFunc).Line()
+	def.Func().Id(mustParseFunc).
+		Params(Id("s").String()).
+		Add(pk.Type.ReferencedType()).
+		BlockFunc(func(def *Group) {
+			def.List(Id(pk.Name), Err()).Op(":=").Id(parseFunc).Call(Id("s"))
+			def.If(Err().Op("!=").Nil()).Block(Panic(Err()))
+			def.Return(Id(pk.Name))
+		}).Line().Line()
+
+	if pk.Params != nil {
+		r.addParseKeyWithParamsFunc(def, pk)
+	}
+}
+
+// addParseKeyWithParamsFunc generates a function that parses a complex key along with the params sent under $params
+func (r *Resource) addParseKeyWithParamsFunc(def *Statement, pk PathKey) {
+	decoder, err := pk.Params.RestLiCodecDecodeModel(Id(pk.paramsName()), Id("params"))
+	if err != nil {
+		Logger.Printf("Warning: cannot generate %s for %s in %s: %s", pk.parseKeyWithParamsFunc(), pk.Name, r.Namespace, err)
+		return
+	}
+
+	parseFunc := pk.parseKeyWithParamsFunc()
+	def.Commentf("%s is like %s but also parses the key's params, if any", parseFunc, pk.parseKeyFunc()).Line()
+	def.Func().Id(parseFunc).
+		Params(Id("s").String()).
+		Params(Id(pk.Name).Add(pk.Type.ReferencedType()), Id(pk.paramsName()).Add(pk.Params.PointerType()), Err().Error()).
+		Block(Return(Id(codecFuncName(parseFunc)).Call(Qual(ProtocolPackage, RestLiUrlEncoder), Id("s")))).
+		Line().Line()
+
+	def.Func().Id(codecFuncName(parseFunc)).
+		Params(Id(Codec).Qual(ProtocolPackage, RestLiCodec), Id("s").String()).
+		Params(Id(pk.Name).Add(pk.Type.ReferencedType()), Id(pk.paramsName()).Add(pk.Params.PointerType()), Err().Error()).
+		BlockFunc(func(def *Group) {
+			def.List(Id("key"), Id("params"), Err()).Op(":=").Qual(ProtocolPackage, "SplitComplexKey").Call(Id(Codec), Id("s"))
+			IfErrReturn(def)
+			def.List(Id(pk.Name), Err()).Op("=").Id(codecFuncName(pk.parseKeyFunc())).Call(Id(Codec), Id("key"))
+			def.If(Err().Op("!=").Nil().Op("||").Id("params").Op("==").Lit("")).Block(Return())
+			def.Line()
+			def.Id(pk.paramsName()).Op("=").New(pk.Params.GoType())
+			def.Err().Op("=").Add(decoder)
+			def.Return()
+		}).Line().Line()
+}
+
+// codecFuncName is the name of the unexported variant of the given generated function, which takes the codec to encode
+// or decode keys with instead of always using protocol.RestLiUrlEncoder. The client calls it with its own codec, see
+// protocol.RestLiClient.UrlCodec
+func codecFuncName(funcName string) string {
+	return PrivateIdentifier(funcName)
+}
+
+// clientCodec is the codec the client encodes keys with, which it passes to the functions named by codecFuncName
+func clientCodec() *Statement {
+	return Id(ClientReceiver).Dot("UrlCodec").Call()
+}
+
+// declareClientCodec declares the codec variable used by RestLiCodecEncodeModel as the client's codec. The methods of
+// protocol.RestLiCodec have pointer receivers, so they cannot be called on clientCodec directly
+func declareClientCodec(def *Group) {
+	def.Id(Codec).Op(":=").Add(clientCodec())
+}
+
+func (pk *PathKey) parseKeyWithParamsFunc() string {
+	return "Parse" + ExportedIdentifier(pk.Name) + "KeyWithParams"
+}
+
+func (pk *PathKey) parseKeyFunc() string {
+	return "Parse" + ExportedIdentifier(pk.Name) + "Key"
+}
+
+// entityKey returns the key of this resource's entities, if this resource is a collection
+func (r *Resource) entityKey() *PathKey {
+	for _, m := range r.Methods {
+		if m.OnEntity {
+			return &m.PathKeys[len(m.PathKeys)-1]
+		}
+	}
+	return nil
 }
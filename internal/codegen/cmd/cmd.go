@@ -5,9 +5,9 @@ import (
 	"compress/gzip"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/bored-engineer/go-restli/internal/codegen"
 	"github.com/pkg/errors"
@@ -19,7 +19,8 @@ var Version string
 func CodeGenerator() *cobra.Command {
 	var specBytes []byte
 	var outputDir string
-	var schemaDir string
+	var schemaDirs []string
+	var validateOnly bool
 
 	cmd := &cobra.Command{
 		Use:          "go-restli",
@@ -31,10 +32,13 @@ func CodeGenerator() *cobra.Command {
 					return errors.New("go-restli: Must specify at least one restspec file")
 				}
 
-				if schemaDir == "" {
+				if len(schemaDirs) == 0 {
 					return errors.New("go-restli: Must specify a schema dir")
-				} else if _, err := os.Stat(schemaDir); err != nil {
-					return errors.Wrap(err, "go-restli: Must specify a valid schema dir: %w")
+				}
+				for _, schemaDir := range schemaDirs {
+					if _, err := os.Stat(schemaDir); err != nil {
+						return errors.Wrapf(err, "go-restli: Must specify a valid schema dir (%s)", schemaDir)
+					}
 				}
 			} else {
 				switch len(args) {
@@ -47,6 +51,9 @@ func CodeGenerator() *cobra.Command {
 						return errors.New("go-restli: No stdin and no spec file given")
 					}
 				case 1:
+					if args[0] == StdinSpecFile {
+						break
+					}
 					if _, err := os.Stat(args[0]); err != nil {
 						return errors.Wrap(err, "go-restli: Must specify a valid spec file")
 					}
@@ -58,36 +65,116 @@ func CodeGenerator() *cobra.Command {
 			return nil
 		},
 		PreRunE: func(_ *cobra.Command, args []string) (err error) {
+			if codegen.FlatPackage && codegen.PackagePrefix == "" {
+				return errors.New("go-restli: --flat requires a --package-prefix to name the generated package")
+			}
+			for _, key := range codegen.PropertyTags {
+				// Struct tag keys are separated from their value by a colon, and from other tags by spaces
+				if key == "" || key == "json" || strings.ContainsAny(key, " :\"`") {
+					return errors.Errorf("go-restli: %q cannot be used as a struct tag", key)
+				}
+			}
+
+			if err = codegen.ValidateReceiverNaming(); err != nil {
+				return err
+			}
+
 			if len(Jar) > 0 {
-				specBytes, err = ExecuteJar(schemaDir, args)
+				specBytes, err = ExecuteJar(schemaDirs, args)
 			} else {
 				specBytes, err = ReadSpec(args)
 			}
 			return err
 		},
 		RunE: func(*cobra.Command, []string) error {
+			if validateOnly {
+				return codegen.ValidateSpec(specBytes)
+			}
 			return codegen.GenerateCode(specBytes, outputDir)
 		},
 	}
 
 	if len(Jar) > 0 {
 		cmd.Use += " REST_SPEC [REST_SPEC...]"
-		cmd.Flags().StringVarP(&schemaDir, "schema-dir", "s", "", "The directory that contains all the .pdsc/.pdl "+
-			"files that may be needed")
+		cmd.Flags().StringSliceVarP(&schemaDirs, "schema-dir", "s", nil, "The directories that contain all the "+
+			".pdsc/.pdl files that may be needed. Can be repeated, in which case referenced schemas are searched for in "+
+			"each directory in the given order")
 	} else {
-		cmd.Use += " [SPEC_FILE]"
+		cmd.Use += " [SPEC_FILE|-]"
 	}
 
 	cmd.Flags().StringVarP(&codegen.PackagePrefix, "package-prefix", "p", "", "The namespace to prefix all generated "+
 		"packages with (e.g. github.com/bored-engineer/go-restli/generated)")
+	cmd.Flags().BoolVar(&codegen.FlatPackage, "flat", false, "Generate all the data types into the single package "+
+		"given by --package-prefix, prefixing the names of colliding types with their namespace")
+	cmd.Flags().BoolVar(&validateOnly, "validate-only", false, "Only check that code can be generated for the given "+
+		"specs, reporting all errors, without writing any files")
+	cmd.Flags().StringVar(&codegen.ProtocolPackage, "protocol-package", codegen.DefaultProtocolPackage, "The import "+
+		"path of the go-restli protocol package used by the generated code, e.g. when using a fork or a /vN module path")
+	cmd.Flags().StringVar(&codegen.JsonPackage, "json-package", codegen.EncodingJson, "The import path of the "+
+		"package whose Marshal and Unmarshal functions the generated code calls, e.g. github.com/goccy/go-json. It must "+
+		"be a drop-in replacement for encoding/json")
+	cmd.Flags().StringSliceVar(&codegen.PropertyTags, "property-tags", nil, "The keys of the field properties to "+
+		"copy into struct tags of the same name (e.g. db), next to the json tag")
+	cmd.Flags().StringSliceVar(&codegen.Validators, "validators", codegen.Validators, "The schema validators to "+
+		"generate Validate functions for, any other validator is ignored")
+	cmd.Flags().BoolVar(&codegen.PreserveUnknownUnionMembers, "preserve-unknown-union-members", false, "Keep the "+
+		"members of JSON unions that are not part of the schema in an Unknown field, and re-emit them when marshaling")
+	cmd.Flags().BoolVar(&codegen.GenerateFuzzTests, "fuzz-tests", false, "Generate a fuzz test for every record, "+
+		"checking that decoding arbitrary data never panics and that decoded values can be encoded again")
+	cmd.Flags().StringToStringVar(&codegen.PolymorphicRecords, "polymorphic-record", nil, "Base records (e.g. "+
+		"com.example.Animal=kind) whose fields are decoded into the record named by the given discriminator field")
+	cmd.Flags().StringSliceVar(&codegen.LazyRecords, "lazy-record", nil, "Records (e.g. com.example.Profile) whose "+
+		"record fields are kept as raw JSON until they are first accessed")
+	cmd.Flags().StringSliceVar(&codegen.ChangeTrackedRecords, "track-changes", nil, "Records (e.g. "+
+		"com.example.Profile) that track which fields were set through their SetXxx functions, and can turn those "+
+		"changes into a patch with ToPatch")
+	cmd.Flags().StringSliceVar(&codegen.RecordConversions, "convert-record", nil, "Pairs of records (e.g. "+
+		"com.example.ProfileV1=com.example.ProfileV2) for which a ConvertXxxToYyy function is generated, which copies "+
+		"the fields that have the same name and type in both records")
+	cmd.Flags().StringToStringVar(&codegen.TimeTyperefs, "time-typeref", nil, "Typerefs to long (e.g. "+
+		"com.example.Timestamp="+codegen.TimeTarget+") that hold the number of milliseconds since the epoch and are "+
+		"generated as a "+codegen.TimeTarget+", or as an "+codegen.Int64Target+" to opt out of the well-known ones (e.g. "+
+		strings.Join(codegen.WellKnownTimeTyperefs, ", ")+")")
+	cmd.Flags().BoolVar(&codegen.FinderPaging, "finder-paging", false, "Make finders also return the paging "+
+		"information of their response as a *protocol.CollectionPaging, which holds the links to the previous and next "+
+		"pages")
+	cmd.Flags().BoolVar(&codegen.RawGet, "raw-get", false, "Generate a GetRaw method alongside each GET method, "+
+		"which also returns the raw body of the response")
+	cmd.Flags().BoolVar(&codegen.CreateStream, "create-stream", false, "Generate a CreateStream method alongside "+
+		"each CREATE method, which streams the entity from an io.Reader with chunked transfer encoding")
+	cmd.Flags().StringVar(&codegen.FeatureFlagAnnotation, "feature-flag-annotation", "", "The restspec annotation "+
+		"(e.g. featureFlag) whose value names the feature flag a method is behind, which is checked by "+
+		"RestLiClient.FeatureFlags before each request")
+	cmd.Flags().StringVar(&codegen.ReceiverNaming, "receiver-naming", codegen.ReceiverNaming, "How the receivers "+
+		"of the generated methods are named, either "+codegen.FirstLetterReceivers+" (e.g. f for FooBar) or "+
+		codegen.AbbreviatedReceivers+" (e.g. fb for FooBar)")
+	cmd.Flags().BoolVar(&codegen.ServerStubs, "server-stubs", false, "Generate a Server alongside each client, an "+
+		"http.Handler that routes rest.li requests to handler funcs, for contract tests against an in-process server")
+	cmd.Flags().StringSliceVar(&codegen.LiteMethods, "lite", nil, "Only generate the given methods (e.g. "+
+		"com.example.profiles.get) or resources (e.g. com.example.profiles), along with the types they reference")
+	cmd.Flags().BoolVar(&codegen.AllTypes, "all-types", false, "Generate every type in the spec, including the "+
+		"ones that are not referenced by any resource")
+	cmd.Flags().BoolVar(&codegen.SplitModelFiles, "split-model-files", false, "Generate the models of each "+
+		"resource file (e.g. the parameters of finders and actions) in a separate <file>_models.go file, next to a "+
+		"<file>_client.go file")
+	cmd.Flags().StringVar(&codegen.AllImportsFile, "all-imports-file", codegen.AllImportsFile, "The path, relative "+
+		"to the package prefix, of the test file that imports every generated package (set to an empty string to skip "+
+		"it)")
+	cmd.Flags().StringVar(&codegen.AllImportsPackage, "all-imports-package", "", "The package of the all imports "+
+		"file (defaults to main, or to the external test package of the flat package with --flat)")
+	cmd.Flags().StringVar(&codegen.AllImportsFunc, "all-imports-func", codegen.AllImportsFunc, "The name of the "+
+		"empty test declared in the all imports file (set to an empty string to leave it out)")
+	cmd.Flags().StringVar(&codegen.BuildTag, "build-tag", "", "A build constraint (e.g. restli) written at the top "+
+		"of every generated file, so that the generated code is only compiled when building with that tag")
 	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "The directory in which to output the generated files")
 
 	return cmd
 }
 
-func ExecuteJar(schemaDir string, restSpecs []string) ([]byte, error) {
+func ExecuteJar(schemaDirs []string, restSpecs []string) ([]byte, error) {
 	if len(Jar) == 0 {
-		log.Panicln("No jar!")
+		return nil, errors.New("go-restli: No jar!")
 	}
 
 	r, err := gzip.NewReader(bytes.NewBuffer(Jar))
@@ -110,7 +197,8 @@ func ExecuteJar(schemaDir string, restSpecs []string) ([]byte, error) {
 		return nil, err
 	}
 
-	c := exec.Command("java", append([]string{"-jar", f.Name(), schemaDir}, restSpecs...)...)
+	resolverPath := strings.Join(schemaDirs, string(os.PathListSeparator))
+	c := exec.Command("java", append([]string{"-jar", f.Name(), resolverPath}, restSpecs...)...)
 	c.Stderr = os.Stderr
 	stdout, err := c.Output()
 	if err != nil {
@@ -125,18 +213,33 @@ func ExecuteJar(schemaDir string, restSpecs []string) ([]byte, error) {
 	return stdout, nil
 }
 
+// StdinSpecFile can be given instead of a spec file to explicitly read the spec from stdin
+const StdinSpecFile = "-"
+
+// ReadSpec reads the spec from the file given in args, or from stdin if there is none
 func ReadSpec(args []string) ([]byte, error) {
-	if len(args) == 0 {
-		specBytes, err := ioutil.ReadAll(os.Stdin)
-		if err != nil {
-			return nil, errors.Wrap(err, "go-restli: Could not read spec from stdin")
-		}
-		return specBytes, nil
-	} else {
-		specByes, err := ioutil.ReadFile(args[0])
-		if err != nil {
-			return nil, errors.Wrap(err, "go-restli: Could not open spec file")
-		}
-		return specByes, nil
+	if len(args) == 0 || args[0] == StdinSpecFile {
+		return ReadSpecFrom(os.Stdin, "stdin")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "go-restli: Could not open spec file")
+	}
+	defer f.Close()
+	return ReadSpecFrom(f, args[0])
+}
+
+// utf8BOM is the byte order mark some Windows tools prepend to UTF-8 files. encoding/json rejects it as an invalid
+// character, so it is stripped before the spec is parsed
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ReadSpecFrom reads a spec from the given reader, which lets build tooling feed specs from memory or a pipe. The name
+// only identifies the spec in error messages. A leading UTF-8 BOM is removed.
+func ReadSpecFrom(r io.Reader, name string) ([]byte, error) {
+	specBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "go-restli: Could not read spec from %s", name)
 	}
+	return bytes.TrimPrefix(specBytes, utf8BOM), nil
 }
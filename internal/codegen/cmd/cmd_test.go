@@ -0,0 +1,621 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSpec_BOM(t *testing.T) {
+	specBytes, err := ReadSpec([]string{"testdata/bom.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec map[string]json.RawMessage
+	if err = json.Unmarshal(specBytes, &spec); err != nil {
+		t.Fatalf("Could not parse BOM-prefixed spec: %+v", err)
+	}
+	if _, ok := spec["resources"]; !ok {
+		t.Errorf("Missing resources in %s", specBytes)
+	}
+}
+
+func TestCodeGenerator_Association(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "go-restli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	cmd := CodeGenerator()
+	cmd.SetArgs([]string{"-o", outputDir, "-p", "example.com/gen", "testdata/association.json"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"testsuite/follows/FollowsId.go": {
+			"type FollowsId struct {\n\tFollowee *int64",
+			"Follower *string",
+		},
+		"testsuite/follows/ByFollowerAssocKeys.go": {
+			"type ByFollowerAssocKeys struct {\n\tFollower *string",
+		},
+		"testsuite/follows/client.go": {
+			"func ResourceEntityPath(followsId *FollowsId) (string, error)",
+			"func ResourcePath() (string, error)",
+			"func ParseFollowsIdKey(s string) (followsId *FollowsId, err error)",
+		},
+		// Finders that bind to parts of the association key only take those parts, in the path
+		"testsuite/follows/findByByFollower.go": {
+			"func FindByByFollowerPath(followsId *ByFollowerAssocKeys) (string, error)",
+			"func FindByByFollowerPath(followsId *ByFollowerAssocKeys) (string, error) {\n\treturn findByByFollowerPath(protocol.RestLiUrlEncoder, followsId)",
+			"path, err := findByByFollowerPath(c.UrlCodec(), followsId)",
+		},
+		// Sub-resources are given the association's whole compound key
+		"testsuite/follows/notes/client.go": {
+			"func ResourceEntityPath(followsId *follows.FollowsId, notesId int64) (string, error)",
+			`path += "/follows/" + followsIdStr`,
+		},
+		"testsuite/follows/notes/findByRecent.go": {
+			"path, err := resourcePath(c.UrlCodec(), followsId)",
+		},
+	}
+	for file, snippets := range expected {
+		code, err := ioutil.ReadFile(filepath.Join(outputDir, "example.com/gen", file))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, snippet := range snippets {
+			if !strings.Contains(string(code), snippet) {
+				t.Errorf("%s does not contain %q", file, snippet)
+			}
+		}
+	}
+}
+
+func TestCodeGenerator_MapKeys(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "go-restli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	cmd := CodeGenerator()
+	cmd.SetArgs([]string{"-o", outputDir, "-p", "example.com/gen", "--all-types", "testdata/map_keys.json"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := ioutil.ReadFile(filepath.Join(outputDir, "example.com/gen/testsuite/mapkeys/Directory.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snippet := range []string{
+		"Owners map[Handle]string",
+		"Labels map[string]string",
+		// Typeref'd keys are encoded and decoded by the typeref, plain keys are encoded as strings
+		"encodedKey, err = key.RestLiEncode(codec)",
+		"RestLiDecode(codec, ",
+		"codec.EncodeString(key)",
+		// Records cannot be encoded by the codecs of rest.li 1.0 clients
+		"err = codec.CheckComplexValue()",
+	} {
+		if !strings.Contains(string(code), snippet) {
+			t.Errorf("Directory.go does not contain %q", snippet)
+		}
+	}
+}
+
+func TestCodeGenerator_IllegalMapKeys(t *testing.T) {
+	tests := map[string]string{
+		"enum": `{"enum": {"name": "Color", "namespace": "testsuite.mapkeys.enum", "sourceFile": "/x/Color.pdsc", ` +
+			`"symbols": ["RED"]}}`,
+		"long": `{"typeref": {"name": "Count", "namespace": "testsuite.mapkeys.long", "sourceFile": "/x/Count.pdsc", ` +
+			`"ref": {"primitive": "int64"}}}`,
+		"time": `{"typeref": {"name": "Time", "namespace": "com.linkedin.common", "sourceFile": "/x/Time.pdsc", ` +
+			`"ref": {"primitive": "int64"}}}`,
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			var keyType struct {
+				Enum, Typeref *struct {
+					Name, Namespace string
+				}
+			}
+			if err := json.Unmarshal([]byte(key), &keyType); err != nil {
+				t.Fatal(err)
+			}
+			id := keyType.Typeref
+			if id == nil {
+				id = keyType.Enum
+			}
+
+			dir, err := ioutil.TempDir("", "go-restli")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			spec := `{"dataTypes": [` + key + `, {"record": {"name": "Holder", "namespace": "` + id.Namespace + `", ` +
+				`"sourceFile": "/x/Holder.pdsc", "fields": [{"name": "values", "type": {"map": {"primitive": "string"}, ` +
+				`"mapKey": {"reference": {"name": "` + id.Name + `", "namespace": "` + id.Namespace + `"}}}}]}}]}`
+			specFile := filepath.Join(dir, "spec.json")
+			if err = ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := CodeGenerator()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetArgs([]string{"-o", dir, "-p", "example.com/gen", "--all-types", specFile})
+			err = cmd.Execute()
+			expected := id.Namespace + "." + id.Name + " cannot be used as the key of a map"
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+// TestCodeGenerator_BatchKeys checks that batch results can be looked up with the keys that were passed in, even when
+// the server encodes them differently
+func TestCodeGenerator_BatchKeys(t *testing.T) {
+	dir, packagePrefix := generateInModule(t, "testdata/batch_keys.json")
+	defer os.RemoveAll(dir)
+
+	code, err := ioutil.ReadFile(filepath.Join(dir, "gen/testsuite/batchkeys/profiles/client.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snippet := range []string{
+		// Record keys are not comparable, so the results are keyed by their encoding instead
+		"func BatchKey(key batchkeys.ProfileKey) (string, error)",
+		"(map[string]*batchkeys.Profile, error)",
+		"(map[string]int, error)",
+		"func (r *BatchResult) Get(key string) *batchkeys.Profile",
+		// BATCH_PARTIAL_UPDATE is chunked like BATCH_GET
+		"const BatchPartialUpdateMaxBatchSize = 2",
+		"c.ChunkBatch(protocol.Method_batch_partial_update, len(keys), BatchPartialUpdateMaxBatchSize, true, ",
+	} {
+		if !strings.Contains(string(code), snippet) {
+			t.Errorf("client.go does not contain %q", snippet)
+		}
+	}
+
+	runInModule(t, dir, packagePrefix, batchKeysMain)
+}
+
+// TestCodeGenerator_Server checks that the generated clients can call the generated servers
+func TestCodeGenerator_Server(t *testing.T) {
+	dir, packagePrefix := generateInModule(t, "testdata/server.json", "--server-stubs")
+	defer os.RemoveAll(dir)
+
+	code, err := ioutil.ReadFile(filepath.Join(dir, "gen/testsuite/greeter/greetings/server.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snippet := range []string{
+		"FindBySearch func(ctx context.Context, params *FindBySearchParams) ([]*greeter.Greeting, error)",
+		"BatchGet func(ctx context.Context, keys []int64, fields ...protocol.PathSpec) (map[int64]*greeter.Greeting, error)",
+		"case protocol.Method_finder:",
+		"codec := protocol.ServerUrlCodec(se.Codec, req)",
+	} {
+		if !strings.Contains(string(code), snippet) {
+			t.Errorf("server.go does not contain %q", snippet)
+		}
+	}
+
+	runInModule(t, dir, packagePrefix, serverMain)
+}
+
+// generateInModule generates the given spec into a temporary directory of this module, such that the generated code
+// can be compiled against the protocol package. The leading underscore of the directory hides it from ./... patterns.
+// The generated packages are under packagePrefix, in the gen directory
+func generateInModule(t *testing.T, spec string, args ...string) (dir, packagePrefix string) {
+	dir, err := ioutil.TempDir(".", "_gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packagePrefix = "github.com/bored-engineer/go-restli/internal/codegen/cmd/" + filepath.Base(dir) + "/gen"
+	cmd := CodeGenerator()
+	cmd.SetArgs(append(args, "-o", filepath.Join(dir, "out"), "-p", packagePrefix, spec))
+	if err = cmd.Execute(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	if err = os.Rename(filepath.Join(dir, "out", packagePrefix), filepath.Join(dir, "gen")); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return dir, packagePrefix
+}
+
+// runInModule runs the given main package against the code generated by generateInModule, in which PACKAGE_PREFIX is
+// replaced by the prefix of the generated packages. The test is skipped if the code cannot be compiled
+func runInModule(t *testing.T, dir, packagePrefix, main string) {
+	goBin, err := exec.LookPath("go")
+	if err != nil || testing.Short() || !inModule(t) {
+		t.Skip("Cannot compile the generated code")
+	}
+	if err = os.Mkdir(filepath.Join(dir, "main"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	main = strings.Replace(main, "PACKAGE_PREFIX", packagePrefix, -1)
+	if err = ioutil.WriteFile(filepath.Join(dir, "main", "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(goBin, "run", "./"+filepath.Join(dir, "main")).CombinedOutput(); err != nil {
+		t.Fatalf("%+v: %s", err, out)
+	}
+}
+
+func inModule(t *testing.T) bool {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ; filepath.Dir(dir) != dir; dir = filepath.Dir(dir) {
+		if _, err = os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+const batchKeysMain = `package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+
+	"github.com/bored-engineer/go-restli/protocol"
+
+	"PACKAGE_PREFIX/testsuite/batchkeys"
+	"PACKAGE_PREFIX/testsuite/batchkeys/profiles"
+)
+
+func main() {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(protocol.RestLiHeader_ProtocolVersion, protocol.RestLiProtocolVersion)
+		// The keys are sent back with their fields in a different order than the client encoded them in
+		switch r.Header.Get(protocol.RestLiHeader_Method) {
+		case "batch_get":
+			fmt.Fprint(w, ` + "`" + `{"results": {"(id:1,org:a)": {"name": "one"}}, "errors": {"(region:eu,id:2,org:b)": {"status": 404}}}` + "`" + `)
+		case "batch_partial_update":
+			fmt.Fprint(w, ` + "`" + `{"results": {"(id:1,org:a)": {"status": 204}}}` + "`" + `)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := profiles.NewClient(&protocol.RestLiClient{
+		Client:           s.Client(),
+		HostnameResolver: &protocol.SimpleHostnameSupplier{Hostname: u},
+	})
+
+	org, id := "a", int64(1)
+	one := batchkeys.ProfileKey{Org: &org, Id: &id}
+	otherOrg, otherId, region := "b", int64(2), "eu"
+	two := batchkeys.ProfileKey{Org: &otherOrg, Id: &otherId, Region: &region}
+	oneKey, err := profiles.BatchKey(one)
+	check(err)
+	twoKey, err := profiles.BatchKey(two)
+	check(err)
+
+	result, err := profiles.NewBatchGetResult(c.BatchGet(context.Background(), []batchkeys.ProfileKey{one, two}))
+	check(err)
+	if profile := result.Get(oneKey); profile == nil || profile.Name == nil || *profile.Name != "one" {
+		fail("Could not look up %s in %+v", oneKey, result.Results)
+	}
+	if e := result.Error(twoKey); e == nil || e.Status != http.StatusNotFound {
+		fail("Could not look up the error of %s in %+v", twoKey, result.Errors)
+	}
+
+	statuses, err := c.BatchPartialUpdate(context.Background(), map[batchkeys.ProfileKey]*batchkeys.ProfilePatch{
+		one: {},
+	})
+	check(err)
+	if status, ok := statuses[oneKey]; !ok || status != http.StatusNoContent {
+		fail("Could not look up %s in %+v", oneKey, statuses)
+	}
+
+	_, err = c.BatchPartialUpdate(context.Background(), map[batchkeys.ProfileKey]*batchkeys.ProfilePatch{
+		one: {}, two: {}, {}: {},
+	})
+	if _, ok := err.(*protocol.BatchSizeError); !ok {
+		fail("Expected a BatchSizeError, got %+v", err)
+	}
+}
+
+func check(err error) {
+	if err != nil {
+		fail("%+v", err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+`
+
+const serverMain = `package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bored-engineer/go-restli/protocol"
+
+	"PACKAGE_PREFIX/testsuite/greeter"
+	"PACKAGE_PREFIX/testsuite/greeter/greetings"
+)
+
+func main() {
+	server := &greetings.Server{
+		FindBySearch: func(ctx context.Context, params *greetings.FindBySearchParams) ([]*greeter.Greeting, error) {
+			if params.Tone == nil || *params.Tone != greeter.Tone_COLD || params.Prefix == nil || params.Limit != nil {
+				return nil, fmt.Errorf("unexpected params: %+v", params)
+			}
+			return []*greeter.Greeting{{Message: str(*params.Prefix + " there"), Tone: params.Tone}}, nil
+		},
+		BatchGet: func(ctx context.Context, keys []int64, fields ...protocol.PathSpec) (map[int64]*greeter.Greeting, error) {
+			results := make(map[int64]*greeter.Greeting)
+			batchError := &protocol.BatchError{Errors: make(map[string]*protocol.RestLiError)}
+			for _, k := range keys {
+				if k < 0 {
+					batchError.Errors[fmt.Sprint(k)] = &protocol.RestLiError{Status: http.StatusNotFound}
+				} else {
+					results[k] = &greeter.Greeting{Message: str(fmt.Sprint("hello ", k))}
+				}
+			}
+			if len(batchError.Errors) > 0 {
+				return results, batchError
+			}
+			return results, nil
+		},
+		BatchPartialUpdate: func(ctx context.Context, patches map[int64]*greeter.GreetingPatch) (map[int64]int, error) {
+			statuses := make(map[int64]int)
+			for k, patch := range patches {
+				if patch.Set == nil || patch.Set.Message == nil || *patch.Set.Message != fmt.Sprint("bye ", k) {
+					return nil, fmt.Errorf("unexpected patch for %d: %+v", k, patch)
+				}
+				statuses[k] = http.StatusNoContent
+			}
+			return statuses, nil
+		},
+	}
+	s := httptest.NewServer(server)
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	for _, version := range []string{protocol.RestLiProtocolVersion, protocol.RestLiProtocolVersion1} {
+		c := greetings.NewClient(&protocol.RestLiClient{
+			Client:           s.Client(),
+			HostnameResolver: &protocol.SimpleHostnameSupplier{Hostname: u},
+			ProtocolVersion:  version,
+		})
+		ctx := context.Background()
+
+		// The prefix holds characters that are part of the rest.li syntax, to check that it is decoded back as is
+		tone, prefix := greeter.Tone_COLD, "hi, (you)"
+		found, err := c.FindBySearch(ctx, &greetings.FindBySearchParams{Tone: &tone, Prefix: &prefix})
+		check(err)
+		if len(found) != 1 || *found[0].Message != "hi, (you) there" {
+			fail("%s: unexpected finder results: %+v", version, found)
+		}
+
+		results, err := c.BatchGet(ctx, []int64{1, 2, -3})
+		batchError, ok := err.(*protocol.BatchError)
+		if !ok || batchError.KeyErrors[int64(-3)] == nil || batchError.KeyErrors[int64(-3)].Status != http.StatusNotFound {
+			fail("%s: expected an error for -3, got %+v", version, err)
+		}
+		if len(results) != 2 || results[2] == nil || *results[2].Message != "hello 2" {
+			fail("%s: unexpected batch results: %+v", version, results)
+		}
+
+		statuses, err := c.BatchPartialUpdate(ctx, map[int64]*greeter.GreetingPatch{
+			1: {Set: &greeter.Greeting{Message: str("bye 1")}},
+			2: {Set: &greeter.Greeting{Message: str("bye 2")}},
+		})
+		check(err)
+		if len(statuses) != 2 || statuses[1] != http.StatusNoContent {
+			fail("%s: unexpected statuses: %+v", version, statuses)
+		}
+
+		// Handlers that were not set are not implemented
+		_, err = c.Get(ctx, 1)
+		if e, ok := err.(*protocol.RestLiError); !ok || e.Status != http.StatusNotImplemented ||
+			!strings.Contains(e.Message, "Get") {
+			fail("%s: expected Get to be not implemented, got %+v", version, err)
+		}
+	}
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func check(err error) {
+	if err != nil {
+		fail("%+v", err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+`
+
+func TestCodeGenerator_PatchFieldConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-restli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spec := `{"dataTypes": [{"record": {"name": "Post", "namespace": "testsuite.patchconflicts", "sourceFile": "/x/Post.pdsc", ` +
+		`"fields": [{"name": "set", "type": {"array": {"primitive": "string"}}}, ` +
+		`{"name": "delete", "type": {"array": {"primitive": "string"}}}, ` +
+		`{"name": "tags", "type": {"array": {"primitive": "string"}}}]}}]}`
+	specFile := filepath.Join(dir, "spec.json")
+	if err = ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := CodeGenerator()
+	cmd.SetArgs([]string{"-o", dir, "-p", "example.com/gen", "--all-types", specFile})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := ioutil.ReadFile(filepath.Join(dir, "example.com/gen/testsuite/patchconflicts/Post.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Array fields named set or delete do not get an ArrayPatch since it would conflict with the Set and Delete fields
+	for snippet, expected := range map[string]bool{
+		"Set    *Post    `json:\"$set,omitempty\"`": true,
+		"Set *protocol.ArrayPatch":                  false,
+		"Delete *protocol.ArrayPatch":               false,
+		"Tags *protocol.ArrayPatch":                 true,
+		"patch.Set.Apply":                           false,
+		"patch.Tags.Apply(base.Tags)":               true,
+	} {
+		if strings.Contains(string(code), snippet) != expected {
+			t.Errorf("Post.go contains %q: %v", snippet, !expected)
+		}
+	}
+}
+
+func TestCodeGenerator_PropertyTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-restli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fields := map[string]string{
+		"plain":     `"required"`,
+		"number":    `5`,
+		"quote":     `"a\"b"`,
+		"backtick":  "\"a`b\"",
+		"newline":   `"a\nb"`,
+		"backslash": `"a\\b"`,
+	}
+	var fieldsJson []string
+	for name, value := range fields {
+		fieldsJson = append(fieldsJson, `{"name": "`+name+`", "type": {"primitive": "string"}, "properties": {"validate": `+value+`}}`)
+	}
+	spec := `{"dataTypes": [{"record": {"name": "Tagged", "namespace": "testsuite.propertytags", ` +
+		`"sourceFile": "/x/Tagged.pdsc", "fields": [` + strings.Join(fieldsJson, ", ") + `]}}]}`
+	specFile := filepath.Join(dir, "spec.json")
+	if err = ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := CodeGenerator()
+	cmd.SetArgs([]string{"-o", dir, "-p", "example.com/gen", "--all-types", "--property-tags", "validate", specFile})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := ioutil.ReadFile(filepath.Join(dir, "example.com/gen/testsuite/propertytags/Tagged.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Values that would break out of the struct tag are skipped
+	for _, snippet := range []string{
+		"`json:\"plain,omitempty\" validate:\"required\"`",
+		"`json:\"number,omitempty\" validate:\"5\"`",
+		"`json:\"quote,omitempty\"`",
+		"`json:\"backtick,omitempty\"`",
+		"`json:\"newline,omitempty\"`",
+		"`json:\"backslash,omitempty\"`",
+	} {
+		if !strings.Contains(string(code), snippet) {
+			t.Errorf("Tagged.go does not contain %q", snippet)
+		}
+	}
+}
+
+func TestCodeGenerator_TrackChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-restli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	record := func(name string, fields ...string) string {
+		var fieldsJson []string
+		for _, f := range fields {
+			fieldsJson = append(fieldsJson, `{"name": "`+f+`", "type": {"primitive": "string"}, "isOptional": true}`)
+		}
+		return `{"record": {"name": "` + name + `", "namespace": "testsuite.trackchanges", "sourceFile": "/x/` + name +
+			`.pdsc", "fields": [` + strings.Join(fieldsJson, ", ") + `]}}`
+	}
+	spec := `{"dataTypes": [` + record("Tracked", "name") + `, ` +
+		record("Conflicting", "name", "changedFields", "resetChanges", "toPatch") + `]}`
+	specFile := filepath.Join(dir, "spec.json")
+	if err = ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := CodeGenerator()
+	cmd.SetArgs([]string{"-o", dir, "-p", "example.com/gen", "--all-types",
+		"--track-changes", "testsuite.trackchanges.Tracked,testsuite.trackchanges.Conflicting", specFile})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[string]bool{
+		"Tracked.go": {
+			"*Tracked) SetName(value *string)":          true,
+			"*Tracked) ChangedFields() []string":        true,
+			"*Tracked) ResetChanges()":                  true,
+			"*Tracked) ToPatch() (patch *TrackedPatch)": true,
+			// The patch must not share memory with the record
+			"patch.Set.Name = protocol.DeepCopy(tr.Name).(*string)": true,
+		},
+		// Funcs that conflict with a field are skipped
+		"Conflicting.go": {
+			"*Conflicting) SetName(value *string)":    true,
+			"*Conflicting) ChangedFields() []string":  false,
+			"*Conflicting) ResetChanges()":            false,
+			"*Conflicting) ToPatch() (patch *":        false,
+			"*Conflicting) SetToPatch(value *string)": true,
+		},
+	}
+	for file, snippets := range expected {
+		code, err := ioutil.ReadFile(filepath.Join(dir, "example.com/gen/testsuite/trackchanges", file))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for snippet, expected := range snippets {
+			if strings.Contains(string(code), snippet) != expected {
+				t.Errorf("%s contains %q: %v", file, snippet, !expected)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"bytes"
+	"go/token"
 	"html/template"
 	"io/ioutil"
 	"os"
@@ -20,6 +21,7 @@ const (
 	UnmarshalJSON = "UnmarshalJSON"
 	Marshal       = "Marshal"
 	MarshalJSON   = "MarshalJSON"
+	CanonicalJSON = "CanonicalJSON"
 
 	Codec                = "codec"
 	RestLiEncode         = "RestLiEncode"
@@ -28,17 +30,36 @@ const (
 	RestLiUrlEncoder     = "RestLiUrlEncoder"
 	RestLiReducedEncoder = "RestLiReducedEncoder"
 
-	PopulateDefaultValues = "populateDefaultValues"
-	ValidateUnionFields   = "validateUnionFields"
+	PopulateDefaultValues    = "populateDefaultValues"
+	PopulateRequiredDefaults = "populateRequiredDefaults"
+	ValidateUnionFields      = "validateUnionFields"
+	RequireSet               = "requireSet"
 
 	NetHttp = "net/http"
 
-	ProtocolPackage = "github.com/bored-engineer/go-restli/protocol"
+	DefaultProtocolPackage = "github.com/bored-engineer/go-restli/protocol"
 )
 
 var (
 	PackagePrefix string
 
+	// ProtocolPackage is the import path of the runtime package that the generated code depends on. It only needs to be
+	// changed when the runtime is imported from a different module, e.g. a fork, a vendored copy or a /vN major version
+	ProtocolPackage = DefaultProtocolPackage
+
+	// JsonPackage is the import path of the package whose Marshal and Unmarshal functions are called by the generated
+	// code, e.g. github.com/json-iterator/go or github.com/goccy/go-json. It must be a drop-in replacement for
+	// encoding/json, whose Marshaler, Unmarshaler and RawMessage types are still used
+	JsonPackage = EncodingJson
+
+	// SplitModelFiles generates the models of each resource file (e.g. the parameters of finders and actions) in a
+	// separate <file>_models.go file, next to a <file>_client.go file that holds the rest of its code
+	SplitModelFiles bool
+
+	// BuildTag is an optional build constraint expression (e.g. "restli") written at the top of every generated file,
+	// so that the generated code is only compiled when the tag is given to the go tool
+	BuildTag string
+
 	CommentWrapWidth = 120
 
 	HeaderTemplate = template.Must(template.New("header").Parse(`DO NOT EDIT
@@ -51,7 +72,10 @@ type CodeFile struct {
 	SourceFile  string
 	PackagePath string
 	Filename    string
-	Code        *Statement
+	// Models holds the data types declared by the file, if any, which precede the rest of the code unless the file is
+	// split by SplitModels
+	Models *Statement
+	Code   *Statement
 }
 
 func (r *Resource) NewCodeFile(filename string) *CodeFile {
@@ -59,10 +83,31 @@ func (r *Resource) NewCodeFile(filename string) *CodeFile {
 		PackagePath: r.PackagePath(),
 		SourceFile:  r.SourceFile,
 		Filename:    filename,
+		Models:      Empty(),
 		Code:        Empty(),
 	}
 }
 
+// SplitModels returns this file as is, unless SplitModelFiles is set and the file declares models, in which case they
+// are moved to a <Filename>_models file while the rest of the code goes to a <Filename>_client file. The models of a
+// resource's client file go to a models file instead
+func (f *CodeFile) SplitModels() []*CodeFile {
+	if !SplitModelFiles || f.Models == nil || strings.TrimSpace(f.Models.GoString()) == "" {
+		return []*CodeFile{f}
+	}
+
+	models, client := *f, *f
+	models.Models, models.Code = nil, f.Models
+	client.Models = nil
+	// The main file of resources is already named client, which only needs a models counterpart
+	if f.Filename == "client" {
+		models.Filename = "models"
+	} else {
+		models.Filename, client.Filename = f.Filename+"_models", f.Filename+"_client"
+	}
+	return []*CodeFile{&models, &client}
+}
+
 func (f *CodeFile) Write(outputDir string) (filename string, err error) {
 	defer func() {
 		e := recover()
@@ -70,21 +115,50 @@ func (f *CodeFile) Write(outputDir string) (filename string, err error) {
 			err = errors.Errorf("Could not generate model: %+v", e)
 		}
 	}()
-	file := NewFilePath(f.PackagePath)
-
-	header := bytes.NewBuffer(nil)
-	err = HeaderTemplate.Execute(header, f)
+	file, err := f.jenFile()
 	if err != nil {
 		return "", err
 	}
-	file.HeaderComment(header.String())
 
-	file.Add(f.Code)
 	filename = filepath.Join(outputDir, f.PackagePath, f.Filename+".go")
 	err = WriteJenFile(filename, file)
 	return filename, err
 }
 
+// addBuildTag adds the BuildTag constraint, if any, to the given file. Every generated file must have it, otherwise
+// untagged files would reference the packages the constraint excludes from the build
+func addBuildTag(file *File) {
+	if BuildTag != "" {
+		// The constraint must precede the package clause, which jennifer guarantees by separating header comments from it
+		// with a blank line
+		file.HeaderComment("//go:build " + BuildTag)
+	}
+}
+
+func (f *CodeFile) jenFile() (*File, error) {
+	var file *File
+	if FlatPackage && f.PackagePath == PackagePrefix {
+		file = NewFilePathName(f.PackagePath, flatPackageName())
+	} else {
+		file = NewFilePath(f.PackagePath)
+	}
+
+	addBuildTag(file)
+
+	header := bytes.NewBuffer(nil)
+	err := HeaderTemplate.Execute(header, f)
+	if err != nil {
+		return nil, err
+	}
+	file.HeaderComment(header.String())
+
+	if f.Models != nil {
+		file.Add(f.Models)
+	}
+	file.Add(f.Code)
+	return file, nil
+}
+
 func (f *CodeFile) Identifier() string {
 	return f.PackagePath + "." + f.Filename
 }
@@ -118,32 +192,95 @@ func WriteJenFile(filename string, file *File) error {
 	return nil
 }
 
+// AddWordWrappedComment adds the given comment as a series of line comments, wrapping lines longer than
+// CommentWrapWidth at the last space that fits. Docs coming from .pdl files tend to be indented, so the indentation
+// common to all the lines is removed, and runs of blank lines are collapsed into a single empty comment line. Wrapped
+// lines keep the indentation of the line they come from, or align with the text of list items.
 func AddWordWrappedComment(code *Statement, comment string) *Statement {
-	if comment != "" {
-		code.Comment(comment)
-		return code
-	} else {
+	rawLines := strings.Split(strings.Trim(comment, "\n"), "\n")
+
+	indent := -1
+	for _, line := range rawLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if i := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace)); indent == -1 || i < indent {
+			indent = i
+		}
+	}
+	if indent == -1 {
 		return code
 	}
 
-	// WIP: RestLi comments are not behaving quite as expected, so comments get added as is, without being wrapped
-	for len(comment) > CommentWrapWidth {
-		if newline := strings.Index(comment[:CommentWrapWidth], "\n"); newline != -1 {
-			code.Comment(comment[:newline]).Line()
-			comment = comment[newline+1:]
+	var lines []string
+	for _, line := range rawLines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
 			continue
 		}
+		line = line[indent:]
+
+		text := strings.TrimLeftFunc(line, unicode.IsSpace)
+		prefix := line[:len(line)-len(text)]
+		continuation := prefix
+		if strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "* ") {
+			continuation += "  "
+		}
 
-		if index := strings.LastIndexFunc(comment[:CommentWrapWidth], unicode.IsSpace); index > 0 {
-			code.Comment(comment[:index]).Line()
-			comment = comment[index+1:]
-		} else {
-			break
+		for len(line) > CommentWrapWidth {
+			index := strings.LastIndexFunc(line[:CommentWrapWidth+1], unicode.IsSpace)
+			if index <= len(continuation) {
+				break
+			}
+			lines = append(lines, strings.TrimRightFunc(line[:index], unicode.IsSpace))
+			line = continuation + strings.TrimLeftFunc(line[index:], unicode.IsSpace)
 		}
+		lines = append(lines, line)
 	}
 
-	code.Comment(comment)
+	for i, line := range lines {
+		if i != 0 {
+			code.Line()
+		}
+		code.Comment(line)
+	}
+	return code
+}
 
+// AddDocComment adds the given doc followed by a "Deprecated:" paragraph if the element was marked as deprecated in its
+// schema, which is the convention recognized by tools like gopls and staticcheck
+func AddDocComment(code *Statement, doc string, deprecated *string) *Statement {
+	return addCommentParagraphs(code, doc, deprecationParagraph(deprecated))
+}
+
+func deprecationParagraph(deprecated *string) string {
+	if deprecated == nil {
+		return ""
+	}
+	message := *deprecated
+	if message == "" {
+		message = "This is marked as deprecated in its schema."
+	}
+	return "Deprecated: " + message
+}
+
+// addCommentParagraphs adds each non-empty paragraph with AddWordWrappedComment, separated by empty comment lines.
+// Paragraphs are wrapped independently so that the indentation of one does not affect the others
+func addCommentParagraphs(code *Statement, paragraphs ...string) *Statement {
+	first := true
+	for _, p := range paragraphs {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		if !first {
+			code.Line().Comment("").Line()
+		}
+		first = false
+		AddWordWrappedComment(code, p)
+	}
 	return code
 }
 
@@ -155,8 +292,66 @@ func PrivateIdentifier(identifier string) string {
 	return strings.ToLower(identifier[:1]) + identifier[1:]
 }
 
+const (
+	// FirstLetterReceivers names receivers after the first letter of their type, e.g. f for FooBar
+	FirstLetterReceivers = "first-letter"
+	// AbbreviatedReceivers names receivers after the capital letters of their type, e.g. fb for FooBar
+	AbbreviatedReceivers = "abbreviated"
+)
+
+// ReceiverNaming is the scheme used by ReceiverName, either FirstLetterReceivers or AbbreviatedReceivers
+var ReceiverNaming = FirstLetterReceivers
+
+// reservedIdentifiers are the identifiers that receivers must not shadow: the parameters and local variables declared
+// by the generated methods, as well as the builtins and packages they reference
+var reservedIdentifiers = map[string]bool{
+	// parameters and local variables
+	"base": true, "body": true, "buf": true, "c": true, "codec": true, "data": true, "end": true, "err": true,
+	"errs": true, "f": true, "from": true, "id": true, "idx": true, "init": true, "k": true, "key": true, "keys": true,
+	"obj": true, "ok": true, "path": true, "raw": true, "s": true, "set": true, "str": true, "t": true, "tmp": true,
+	"to": true, "v": true, "val": true,
+	// builtins
+	"append": true, "bool": true, "byte": true, "cap": true, "copy": true, "delete": true, "error": true,
+	"false": true, "int": true, "len": true, "make": true, "new": true, "nil": true, "string": true, "true": true,
+	// packages
+	"bytes": true, "errors": true, "fmt": true, "io": true, "json": true, "math": true, "protocol": true,
+	"reflect": true, "sort": true, "strconv": true, "strings": true, "time": true, "url": true,
+}
+
+// ValidateReceiverNaming returns an error if ReceiverNaming is not one of the supported schemes
+func ValidateReceiverNaming() error {
+	switch ReceiverNaming {
+	case FirstLetterReceivers, AbbreviatedReceivers:
+		return nil
+	default:
+		return errors.Errorf("go-restli: Unknown receiver naming %q, must be %q or %q", ReceiverNaming,
+			FirstLetterReceivers, AbbreviatedReceivers)
+	}
+}
+
+// ReceiverName returns the name of the receiver of the methods of the given type, following ReceiverNaming. Names that
+// would shadow one of the reservedIdentifiers or are keywords are lengthened with the following letters of the type name
 func ReceiverName(typeName string) string {
-	return PrivateIdentifier(typeName[:1])
+	name := PrivateIdentifier(typeName[:1])
+	if ReceiverNaming == AbbreviatedReceivers {
+		var capitals strings.Builder
+		for _, c := range typeName {
+			if unicode.IsUpper(c) {
+				capitals.WriteRune(unicode.ToLower(c))
+			}
+		}
+		if capitals.Len() > 0 {
+			name = capitals.String()
+		}
+	}
+
+	for n := 2; reservedIdentifiers[name] || token.IsKeyword(name); n++ {
+		if n > len(typeName) {
+			return PrivateIdentifier(typeName) + "Receiver"
+		}
+		name = strings.ToLower(typeName[:n])
+	}
+	return name
 }
 
 func AddFuncOnReceiver(def *Statement, receiver, typeName, funcName string) *Statement {
@@ -0,0 +1,112 @@
+package codegen
+
+import (
+	"reflect"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+// RecordConversions are pairs of fully-qualified record names separated by a "=", e.g.
+// com.example.ProfileV1=com.example.ProfileV2. A ConvertXxxToYyy function is generated for each pair, which copies the
+// fields that have the same name and type in both records. It is meant as a starting point for migrating from one
+// version of a record to the next
+var RecordConversions []string
+
+// checkRecordConversions resolves the RecordConversions and records them on the records being converted from
+func (reg typeRegistry) checkRecordConversions() error {
+	for _, conversion := range RecordConversions {
+		idx := strings.Index(conversion, "=")
+		if idx < 0 {
+			return errors.Errorf("go-restli: Record conversion %q must be of the form <from>=<to>", conversion)
+		}
+
+		var records [2]*Record
+		for i, fqn := range []string{conversion[:idx], conversion[idx+1:]} {
+			dot := strings.LastIndex(fqn, ".")
+			if dot <= 0 {
+				return errors.Errorf("go-restli: Converted record %s is not a fully-qualified name", fqn)
+			}
+			t, ok := reg[Identifier{Namespace: fqn[:dot], Name: fqn[dot+1:]}]
+			if !ok {
+				return errors.Errorf("go-restli: Unknown converted record %s", fqn)
+			}
+			record, ok := t.Type.(*Record)
+			if !ok {
+				return errors.Errorf("go-restli: Converted type %s is not a record", fqn)
+			}
+			records[i] = record
+		}
+
+		from, to := records[0], records[1]
+		if from.Identifier == to.Identifier {
+			return errors.Errorf("go-restli: Cannot convert %s to itself", from.Identifier)
+		}
+		from.conversions = append(from.conversions, to)
+	}
+	return nil
+}
+
+// conversionFuncName returns the name of the function that converts from into to. The last segment of each record's
+// namespace is added when both records have the same name, e.g. ConvertV1ProfileToV2Profile
+func conversionFuncName(from, to *Record) string {
+	fromName, toName := from.Name, to.Name
+	if fromName == to.Name {
+		fromName = ExportedIdentifier(from.Namespace[strings.LastIndex(from.Namespace, ".")+1:]) + fromName
+		toName = ExportedIdentifier(to.Namespace[strings.LastIndex(to.Namespace, ".")+1:]) + toName
+	}
+	return "Convert" + fromName + "To" + toName
+}
+
+// generateConversions generates the conversion functions from this record to each of the records it was paired with in
+// RecordConversions. Fields that cannot be copied are left for the caller to handle, and are flagged with TODO comments
+// in the generated code
+func (r *Record) generateConversions(def *Statement) {
+	for _, to := range r.conversions {
+		funcName := conversionFuncName(r, to)
+		toType := Qual(to.PackagePath(), to.Name)
+
+		fromFields := make(map[string]Field)
+		for _, f := range r.Fields {
+			fromFields[f.Name] = f
+		}
+		toFields := make(map[string]bool)
+		for _, f := range to.Fields {
+			toFields[f.Name] = true
+		}
+
+		def.Commentf("%s returns a %s holding the fields of from that have the same name and type in %s. Fields "+
+			"that only exist in %s are left unset. This function was generated as a starting point for migrating "+
+			"between the two records, see the TODOs for the fields that need to be converted by hand", funcName,
+			to.Name, to.Name, to.Name).Line()
+		def.Func().Id(funcName).
+			Params(Id("from").Op("*").Id(r.Name)).
+			Params(Id("to").Op("*").Add(toType)).
+			BlockFunc(func(def *Group) {
+				def.If(Id("from").Op("==").Nil()).Block(Return(Nil()))
+				def.Id("to").Op("=").New(toType.Clone()).Line()
+
+				for _, f := range to.Fields {
+					field := ExportedIdentifier(f.Name)
+					fromField, ok := fromFields[f.Name]
+					switch {
+					case !ok:
+						def.Commentf("TODO: %s is new in %s", f.Name, to.Name)
+					case !reflect.DeepEqual(fromField.Type, f.Type):
+						def.Commentf("TODO: %s is a %#v in %s but a %#v in %s", f.Name, fromField.Type.GoType(),
+							r.Name, f.Type.GoType(), to.Name)
+					default:
+						def.Id("to").Dot(field).Op("=").Id("from").Dot(field)
+					}
+				}
+				for _, f := range r.Fields {
+					if !toFields[f.Name] {
+						def.Commentf("TODO: %s is not in %s", f.Name, to.Name)
+					}
+				}
+
+				def.Line().Return(Id("to"))
+			}).Line().Line()
+	}
+}
@@ -2,27 +2,68 @@ package codegen
 
 import (
 	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
-func (t *RestliType) RestLiURLEncodeModel(accessor *Statement) (def *Statement, hasError bool) {
-	return t.RestLiEncodeModel(RestLiUrlEncoder, accessor)
+func (t *RestliType) RestLiURLEncodeModel(accessor *Statement) (def *Statement, hasError bool, err error) {
+	return t.RestLiEncodeModel(Qual(ProtocolPackage, RestLiUrlEncoder), accessor)
 }
 
-func (t *RestliType) RestLiReducedEncodeModel(accessor *Statement) (def *Statement, hasError bool) {
-	return t.RestLiEncodeModel(RestLiReducedEncoder, accessor)
+func (t *RestliType) RestLiReducedEncodeModel(accessor *Statement) (def *Statement, hasError bool, err error) {
+	return t.RestLiEncodeModel(Qual(ProtocolPackage, RestLiReducedEncoder), accessor)
 }
 
-func (t *RestliType) RestLiEncodeModel(encoder string, accessor *Statement) (*Statement, bool) {
-	encoderRef := Qual(ProtocolPackage, encoder)
+// RestLiCodecEncodeModel encodes the accessor with the codec variable, e.g. the codec given to the functions that the
+// client calls with its own codec
+func (t *RestliType) RestLiCodecEncodeModel(accessor *Statement) (def *Statement, hasError bool, err error) {
+	return t.RestLiEncodeModel(Id(Codec), accessor)
+}
+
+func (t *RestliType) RestLiEncodeModel(encoderRef *Statement, accessor *Statement) (*Statement, bool, error) {
+	if t.Reference != nil {
+		return Add(accessor).Dot(RestLiEncode).Call(encoderRef), true, nil
+	}
+
+	if t.Primitive != nil {
+		return Add(encoderRef).Dot("Encode" + ExportedIdentifier(t.Primitive.Type)).Call(accessor), false, nil
+	}
+
+	return nil, false, errors.Errorf("go-restli: %+v cannot be url encoded", t)
+}
+
+// checkComplexValue makes the generated code fail before encoding a record, union, array or map with a codec that
+// cannot encode them, see protocol.RestLiCodec.CheckComplexValue
+func checkComplexValue(def *Group, results ...Code) {
+	def.Err().Op("=").Id(Codec).Dot("CheckComplexValue").Call()
+	IfErrReturn(def, results...).Line()
+}
+
+func (t *RestliType) RestLiURLDecodeModel(accessor, data *Statement) (def *Statement, err error) {
+	return t.RestLiDecodeModel(Qual(ProtocolPackage, RestLiUrlEncoder), accessor, data)
+}
+
+// RestLiCodecDecodeModel is like RestLiCodecEncodeModel, but decodes the data into the accessor
+func (t *RestliType) RestLiCodecDecodeModel(accessor, data *Statement) (def *Statement, err error) {
+	return t.RestLiDecodeModel(Id(Codec), accessor, data)
+}
 
+func (t *RestliType) RestLiDecodeModel(encoderRef *Statement, accessor, data *Statement) (*Statement, error) {
 	if t.Reference != nil {
-		return Add(accessor).Dot(RestLiEncode).Call(encoderRef), true
+		switch ref := t.Reference.Resolve().(type) {
+		case *Enum, *Fixed, *Record:
+		case *Typeref:
+			if ref.Ref.Primitive == nil && ref.Ref.Union == nil {
+				return nil, errors.Errorf("go-restli: %+v cannot be url decoded", t)
+			}
+		default:
+			return nil, errors.Errorf("go-restli: %+v cannot be url decoded", t)
+		}
+		return Add(accessor).Dot(RestLiDecode).Call(encoderRef, data), nil
 	}
 
 	if t.Primitive != nil {
-		return Add(encoderRef).Dot("Encode" + ExportedIdentifier(t.Primitive.Type)).Call(accessor), false
+		return Add(encoderRef).Dot("Decode"+ExportedIdentifier(t.Primitive.Type)).Call(data, Op("&").Add(accessor)), nil
 	}
 
-	Logger.Panicf("%+v cannot be url encoded", t)
-	return nil, false
+	return nil, errors.Errorf("go-restli: %+v cannot be url decoded", t)
 }
@@ -16,9 +16,9 @@ func (e *Enum) InnerTypes() IdentifierSet {
 	return nil
 }
 
-func (e *Enum) GenerateCode() (def *Statement) {
+func (e *Enum) GenerateCode() (def *Statement, err error) {
 	def = Empty()
-	AddWordWrappedComment(def, e.Doc).Line()
+	AddDocComment(def, e.Doc, e.Deprecated).Line()
 	def.Type().Id(e.Name).Int().Line()
 
 	def.Const().DefsFunc(func(def *Group) {
@@ -54,6 +54,33 @@ func (e *Enum) GenerateCode() (def *Statement) {
 		}))
 	}).Line().Line()
 
+	// Ordinals follow the order in which the symbols are declared, starting from 0, unlike the values of the consts
+	// which start from 1 so that the zero value is never a valid symbol
+	ordinals := "_" + e.Name + "_ordinals"
+	def.Var().Id(ordinals).Op("=").Map(Id(e.Name)).Int().Values(DictFunc(func(dict Dict) {
+		for i, s := range e.Symbols {
+			dict[Id(e.SymbolIdentifier(s))] = Lit(i)
+		}
+	})).Line().Line()
+
+	ordinalFunc := e.Name + "Ordinal"
+	def.Commentf("%s returns the ordinal of the given %s, i.e. the position of its symbol in the enum's declaration "+
+		"starting from 0, or -1 if it is not a known symbol", ordinalFunc, e.Name).Line()
+	def.Func().Id(ordinalFunc).Params(Id("v").Id(e.Name)).Int().BlockFunc(func(def *Group) {
+		def.If(List(Id("ordinal"), Id("ok")).Op(":=").Id(ordinals).Index(Id("v")), Id("ok")).Block(Return(Id("ordinal")))
+		def.Return(Lit(-1))
+	}).Line().Line()
+
+	fromOrdinalFunc := e.Name + "FromOrdinal"
+	def.Commentf("%s returns the %s with the given ordinal (see %s), and false if there is none", fromOrdinalFunc,
+		e.Name, ordinalFunc).Line()
+	def.Func().Id(fromOrdinalFunc).Params(Id("ordinal").Int()).Params(Id(e.Name), Bool()).BlockFunc(func(def *Group) {
+		def.If(Id("ordinal").Op("<").Lit(0).Op("||").Id("ordinal").Op(">=").Lit(len(e.Symbols))).Block(
+			Return(Id("_"+e.SymbolIdentifier("unknown")), False()),
+		)
+		def.Return(Id(e.Name).Call(Id("ordinal").Op("+").Lit(1)), True())
+	}).Line().Line()
+
 	def.Func().Id(getter).Params(Id("val").String()).Params(Id(receiver).Id(e.Name), Err().Error())
 	def.BlockFunc(func(def *Group) {
 		def.List(Id(receiver), Id("ok")).Op(":=").Id(values).Index(Id("val"))
@@ -77,7 +104,7 @@ func (e *Enum) GenerateCode() (def *Statement) {
 
 	AddUnmarshalJSON(def, receiver, e.Name, func(def *Group) {
 		def.Var().Id("str").String()
-		def.Err().Op("=").Qual(EncodingJson, Unmarshal).Call(Id("data"), Op("&").Id("str"))
+		def.Err().Op("=").Qual(JsonPackage, Unmarshal).Call(Id("data"), Op("&").Id("str"))
 		IfErrReturn(def)
 		def.Line()
 
@@ -87,8 +114,13 @@ func (e *Enum) GenerateCode() (def *Statement) {
 		def.Return()
 	}).Line().Line()
 
+	// Like MarshalJSON, unknown values cannot be encoded, otherwise they would silently be sent as empty strings (e.g.
+	// in finder parameters)
 	AddRestLiEncode(def, receiver, e.Name, func(def *Group) {
 		def.Id("data").Op("=").Id(receiver).Dot("String").Call()
+		def.If(Id("data").Op("==").Lit("")).BlockFunc(func(def *Group) {
+			def.Err().Op("=").Qual("fmt", "Errorf").Call(Lit(fmt.Sprintf("illegal %s: %%d", e.Name)), Op("*").Id(receiver))
+		})
 		def.Return()
 	}).Line().Line()
 	AddRestLiDecode(def, receiver, e.Name, func(def *Group) {
@@ -96,9 +128,18 @@ func (e *Enum) GenerateCode() (def *Statement) {
 		def.Return()
 	}).Line().Line()
 
-	return def
+	return def, nil
 }
 
 func (e *Enum) SymbolIdentifier(symbol string) string {
 	return ExportedIdentifier(e.Name + "_" + symbol)
 }
+
+func (e *Enum) hasSymbol(symbol string) bool {
+	for _, s := range e.Symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package codegen
+
+import (
+	"github.com/pkg/errors"
+)
+
+// FeatureFlagAnnotation is the name of the restspec annotation that puts a method behind a feature flag, whose name is
+// the annotation's value, e.g. "annotations": {"featureFlag": {"value": "new-search"}}. The flag is checked by
+// protocol.RestLiClient.FeatureFlags before each request of the method is sent. Annotations are ignored when empty
+var FeatureFlagAnnotation string
+
+type featureFlagAnnotation struct {
+	Value string `json:"value"`
+}
+
+// checkFeatureFlags reads the feature flag of every method annotated with the FeatureFlagAnnotation
+func (s *GoRestliSpec) checkFeatureFlags() error {
+	if FeatureFlagAnnotation == "" {
+		return nil
+	}
+
+	var errs ErrorList
+	for _, r := range s.Resources {
+		for _, m := range r.Methods {
+			var annotation featureFlagAnnotation
+			ok, err := m.Annotation(FeatureFlagAnnotation, &annotation)
+			if err != nil {
+				errs.Add(errors.Wrapf(err, "go-restli: Invalid feature flag in %s", r.SourceFile))
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if annotation.Value == "" {
+				errs.Add(errors.Errorf("go-restli: The @%s annotation of %s in %s does not name a feature flag",
+					FeatureFlagAnnotation, m.Name, r.SourceFile))
+				continue
+			}
+			m.featureFlag = annotation.Value
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
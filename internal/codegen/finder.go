@@ -8,6 +8,11 @@ import (
 )
 
 const EncodeFinderParams = "EncodeFinderParams"
+const ReservedParamsField = "ReservedParams"
+
+// FinderPaging makes finders also return the paging information of their response, i.e. its start, count, total and
+// the links to the previous and next pages, as a *protocol.CollectionPaging
+var FinderPaging bool
 
 type FinderParams Record
 
@@ -30,14 +35,26 @@ func (m *Method) finderReturnType() Code {
 
 func (m *Method) finderFuncReturnParams(def *Group) {
 	def.Add(m.finderReturnType())
+	if m.Metadata != nil {
+		def.Add(m.Metadata.PointerType())
+	}
+	if FinderPaging {
+		def.Op("*").Qual(ProtocolPackage, "CollectionPaging")
+	}
 	def.Error()
 }
 
-func (r *Resource) GenerateFinderCode(f *Method) *CodeFile {
+func (r *Resource) GenerateFinderCode(f *Method) (*CodeFile, error) {
 	c := r.NewCodeFile("findBy" + ExportedIdentifier(f.Name))
 
 	c.Code.Const().Id(ExportedIdentifier(FindBy + ExportedIdentifier(f.Name))).Op("=").Lit(f.Name).Line()
 
+	if f.finderAssocKey() != nil {
+		if err := r.addResourcePathFunc(c.Code, f.finderPathFunc(), f); err != nil {
+			return nil, err
+		}
+	}
+
 	params := &FinderParams{
 		NamedType: NamedType{
 			Identifier: Identifier{
@@ -48,50 +65,86 @@ func (r *Resource) GenerateFinderCode(f *Method) *CodeFile {
 		},
 		Fields: f.Params,
 	}
-	c.Code.Add(params.GenerateCode(f)).Line().Line()
+	c.Models.Add(params.GenerateCode(f)).Line().Line()
 
-	AddWordWrappedComment(c.Code, f.Doc).Line()
+	f.addDocComment(c.Code).Line()
 	r.addClientFunc(c.Code, f)
 
-	c.Code.BlockFunc(func(def *Group) {
-		def.List(Id(PathVar), Err()).Op(":=").Id(ResourcePath).Call(f.entityParams()...)
-		IfErrReturn(def, Nil(), Err()).Line()
-
-		def.List(Id("query"), Err()).Op(":=").Id("params").Dot(EncodeFinderParams).Call()
-		IfErrReturn(def, Nil(), Err()).Line()
+	fields := []Code{Id("Elements").Add(f.finderReturnType())}
+	results := []Code{Id(DoAndDecodeResult).Dot("Elements")}
+	errReturn := []Code{Nil()}
+	if f.Metadata != nil {
+		fields = append(fields, Id("Metadata").Add(f.Metadata.PointerType()).Tag(JsonFieldTag("metadata", true)))
+		results = append(results, Id(DoAndDecodeResult).Dot("Metadata"))
+		errReturn = append(errReturn, Nil())
+	}
+	if FinderPaging {
+		fields = append(fields, Id("Paging").Op("*").Qual(ProtocolPackage, "CollectionPaging").Tag(JsonFieldTag("paging", true)))
+		results = append(results, Id(DoAndDecodeResult).Dot("Paging"))
+		errReturn = append(errReturn, Nil())
+	}
+	errReturn = append(errReturn, Err())
 
-		def.Id(PathVar).Op("+=").Lit("?").Op("+").Id("query").Dot("Encode").Call()
+	c.Code.BlockFunc(func(def *Group) {
+		r.formatURL(def, f, errReturn...)
 
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Nil(), Err()).Line()
+		r.withMethodTimeout(def, f, protocol.Method_finder)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_finder))
+		IfErrReturn(def, errReturn...).Line()
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_finder))
-		IfErrReturn(def, Nil(), Err()).Line()
+		def.Id(DoAndDecodeResult).Op(":=").Struct(fields...).Block()
+		def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot(DoAndDecodeEnvelope).Call(Id(ReqVar), Op("&").Id(DoAndDecodeResult))
+		IfErrReturn(def, errReturn...).Line()
+		def.Return(append(results, Nil())...)
+	}).Line().Line()
 
-		def.Id(DoAndDecodeResult).Op(":=").Struct(Id("Elements").Add(f.finderReturnType())).Block()
-		callDoAndDecode(def)
-		def.Return(Id(DoAndDecodeResult).Dot("Elements"), Nil())
-	})
+	c.Code.Add(r.generateURLFunc(f))
 
-	return c
+	return c, nil
 }
 
 func (p *FinderParams) GenerateCode(f *Method) *Statement {
 	def := Empty()
-	def.Add((*Record)(p).generateStruct()).Line().Line()
+	var extraFields []Code
+	hasReservedParams := p.hasReservedParams()
+	if hasReservedParams {
+		extraFields = append(extraFields, Line().Comment(ReservedParamsField+" are sent alongside the finder's own "+
+			"parameters, see protocol.ReservedParams").Line().
+			Id(ReservedParamsField).Qual(ProtocolPackage, "ReservedParams").Tag(map[string]string{"json": "-"}))
+	}
+	def.Add((*Record)(p).generateStruct(extraFields...)).Line().Line()
 
 	receiver := (*Record)(p).Receiver()
-	return AddFuncOnReceiver(def, receiver, p.Name, EncodeFinderParams).
+	def.Commentf("%s encodes the parameters into the query of the %s finder. Optional parameters can be left unset, "+
+		"but an error is returned if a required parameter is missing", EncodeFinderParams, f.Name).Line()
+	// The values are URL encoded by query.Encode, so they only need the reduced encoding here, otherwise they would get
+	// escaped twice. The client passes its own reduced codec instead, see protocol.RestLiClient.ReducedCodec
+	AddFuncOnReceiver(def, receiver, p.Name, EncodeFinderParams).
 		Params().
 		Params(Id("query").Qual("net/url", "Values"), Err().Error()).
-		BlockFunc(func(def *Group) {
-			def.Id(Codec).Op(":=").Qual(ProtocolPackage, RestLiUrlEncoder).Line()
+		Block(Return(Id(receiver).Dot(codecFuncName(EncodeFinderParams)).Call(Qual(ProtocolPackage, RestLiReducedEncoder)))).
+		Line().Line()
 
+	return AddFuncOnReceiver(def, receiver, p.Name, codecFuncName(EncodeFinderParams)).
+		Params(Id(Codec).Qual(ProtocolPackage, RestLiCodec)).
+		Params(Id("query").Qual("net/url", "Values"), Err().Error()).
+		BlockFunc(func(def *Group) {
 			def.Id("query").Op("=").Make(Qual("net/url", "Values"))
 			def.Id("query").Dot("Set").Call(Lit("q"), Lit(f.Name))
 			def.Line()
 
-			def.Var().Id("buf").Qual("strings", "Builder")
+			for _, field := range f.Params {
+				if !field.IsOptional {
+					def.If((*Record)(p).isUnset(field, Id(receiver).Dot(ExportedIdentifier(field.Name)))).Block(
+						Return(Nil(), Qual("fmt", "Errorf").Call(Lit("go-restli: Missing required parameter "+field.Name+" of the "+f.Name+" finder"))),
+					)
+				}
+			}
+			def.Line()
+
+			if len(f.Params) > 0 {
+				def.Var().Id("buf").Qual("strings", "Builder")
+			}
 
 			for _, field := range f.Params {
 				accessor := Id(receiver).Dot(ExportedIdentifier(field.Name))
@@ -106,13 +159,41 @@ func (p *FinderParams) GenerateCode(f *Method) *Statement {
 				}
 
 				setBlock.BlockFunc(func(def *Group) {
+					// Records and typeref'd unions check the codec themselves in RestLiEncode
+					if field.Type.Array != nil || field.Type.Map != nil || field.Type.Union != nil {
+						checkComplexValue(def, Nil(), Err())
+					}
 					field.Type.WriteToBuf(def, accessor)
 					def.Id("query").Dot("Set").Call(Lit(field.Name), Id("buf").Dot("String").Call())
-					Id("buf").Dot("Reset").Call()
+					def.Id("buf").Dot("Reset").Call()
 				})
 				def.Line()
 			}
 
+			if hasReservedParams {
+				def.Err().Op("=").Id(receiver).Dot(ReservedParamsField).Dot(EncodeFinderParams).CallFunc(func(def *Group) {
+					def.Id(Codec)
+					def.Id("query")
+					for _, field := range f.Params {
+						def.Lit(field.Name)
+					}
+				})
+				IfErrReturn(def, Nil(), Err()).Line()
+			}
+
 			def.Return(Id("query"), Err())
 		})
 }
+
+// hasReservedParams returns true if the ReservedParams field can be added to the finder's params, i.e. if none of its
+// own parameters has the same name
+func (p *FinderParams) hasReservedParams() bool {
+	for _, f := range p.Fields {
+		if ExportedIdentifier(f.Name) == ReservedParamsField {
+			Logger.Printf("Warning: the %s parameter of %s conflicts with the %s field, reserved params cannot be sent",
+				f.Name, p.Name, ReservedParamsField)
+			return false
+		}
+	}
+	return true
+}
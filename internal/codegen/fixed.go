@@ -15,9 +15,9 @@ func (f *Fixed) InnerTypes() IdentifierSet {
 	return nil
 }
 
-func (f *Fixed) GenerateCode() (def *Statement) {
+func (f *Fixed) GenerateCode() (def *Statement, err error) {
 	def = Empty()
-	AddWordWrappedComment(def, f.Doc).Line()
+	AddDocComment(def, f.Doc, f.Deprecated).Line()
 	def.Type().Id(f.Name).Index(Lit(f.Size)).Byte().Line().Line()
 
 	receiver := ReceiverName(f.Name)
@@ -54,5 +54,5 @@ func (f *Fixed) GenerateCode() (def *Statement) {
 		def.Return()
 	}).Line().Line()
 
-	return def
+	return def, nil
 }
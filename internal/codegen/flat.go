@@ -0,0 +1,90 @@
+package codegen
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// FlatPackage generates all the data types into the single package named by PackagePrefix instead of one package per
+// namespace. Resources are still generated in their own packages since each one declares its own Client
+var FlatPackage bool
+
+// typeReferences holds every reference to a complex type found in the spec, so that references can be renamed along
+// with the types they point to when generating a flat package
+var typeReferences []*Identifier
+
+type renamable interface {
+	rename(name string)
+}
+
+func (t *NamedType) rename(name string) {
+	t.Name = name
+}
+
+// flatPackageName is the name of the flat package, derived from the last element of PackagePrefix
+func flatPackageName() string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(PackagePrefix))
+}
+
+// flatName prefixes the identifier's name with its namespace, e.g. com.linkedin.Foo becomes ComLinkedinFoo
+func flatName(id Identifier) string {
+	var name strings.Builder
+	for _, s := range strings.Split(id.Namespace, ".") {
+		if s != "" {
+			name.WriteString(ExportedIdentifier(s))
+		}
+	}
+	name.WriteString(ExportedIdentifier(id.Name))
+	return name.String()
+}
+
+// Flatten renames the types whose names collide once all the namespaces are merged into a single package by prefixing
+// them with their namespace. All references to the renamed types are updated accordingly
+func (reg typeRegistry) Flatten() error {
+	byName := make(map[string][]Identifier)
+	for id := range reg {
+		byName[id.Name] = append(byName[id.Name], id)
+	}
+
+	renamed := make(map[Identifier]Identifier)
+	for _, ids := range byName {
+		if len(ids) < 2 {
+			continue
+		}
+		for _, id := range ids {
+			renamed[id] = Identifier{Name: flatName(id), Namespace: id.Namespace}
+		}
+	}
+
+	for oldId, newId := range renamed {
+		Logger.Printf("Renaming %s to %s to avoid a name collision in the flat package", oldId, newId.Name)
+		t := reg[oldId]
+		delete(reg, oldId)
+		t.Type.(renamable).rename(newId.Name)
+		reg[newId] = t
+	}
+
+	for _, ref := range typeReferences {
+		if newId, ok := renamed[*ref]; ok {
+			*ref = newId
+		}
+	}
+
+	names := make(map[string]Identifier)
+	for id := range reg {
+		if other, ok := names[id.Name]; ok {
+			return errors.Errorf("go-restli: %s and %s cannot both be named %s in the flat package", other, id, id.Name)
+		}
+		names[id.Name] = id
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package codegen
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+// generateFromMap generates NewXxxFromMap, which builds a record from a map[string]interface{} such as the ones decoded
+// from YAML, converting each value to the type of its field (see protocol.ConvertMapValue)
+func (r *Record) generateFromMap(def *Statement) {
+	fromMap := "New" + r.Name + "FromMap"
+	AddWordWrappedComment(def, fromMap+" returns a new "+r.Name+" whose fields are set from the values of the given "+
+		"map, e.g. one decoded from YAML or assembled from a template, converted to the type of each field. The values "+
+		"that cannot be converted and the keys that are not fields are all reported at once, as a "+
+		"protocol.ValidationErrors. Required fields are not checked, see Validate").Line()
+	def.Func().Id(fromMap).
+		Params(Id("values").Map(String()).Interface()).
+		Params(Op("*").Id(r.Name), Error()).
+		BlockFunc(func(def *Group) {
+			def.Id("record").Op(":=").New(Id(r.Name))
+			def.Var().Id("errs").Qual(ProtocolPackage, "ValidationErrors")
+			for _, f := range r.Fields {
+				def.If(List(Id("value"), Id("ok")).Op(":=").Id("values").Index(Lit(f.Name)), Id("ok")).Block(
+					If(Err().Op(":=").Qual(ProtocolPackage, "ConvertMapValue").Call(Id("value"), Op("&").Id("record").Dot(ExportedIdentifier(f.Name))), Err().Op("!=").Nil()).Block(
+						Id("errs").Dot("Add").Call(Lit(f.Name), Qual(ProtocolPackage, "TypeValidator"), Err().Dot("Error").Call()),
+					),
+				)
+			}
+			def.Id("errs").Dot("AddUnknownKeys").CallFunc(func(def *Group) {
+				def.Id("values")
+				for _, f := range r.Fields {
+					def.Lit(f.Name)
+				}
+			})
+			def.If(Err().Op(":=").Id("errs").Dot("Err").Call(), Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+			def.Return(Id("record"), Nil())
+		}).Line().Line()
+}
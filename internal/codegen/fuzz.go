@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+// GenerateFuzzTests adds a Go native fuzz test next to every record, see generateFuzzTest
+var GenerateFuzzTests bool
+
+func (r *Record) fuzzTestFilename() string {
+	return r.Name + "_fuzz_test"
+}
+
+// generateFuzzTest generates a FuzzXxx function that feeds arbitrary bytes to the record's UnmarshalJSON and
+// RestLiDecode, checking that they never panic and that whatever they successfully decode can be encoded again. Values
+// that are missing a required union member are not re-encoded, since decoding accepts them but encoding does not.
+func (r *Record) generateFuzzTest() *Statement {
+	def := Empty()
+
+	hasUnionField := false
+	for _, f := range r.Fields {
+		hasUnionField = hasUnionField || f.Type.IsUnion()
+	}
+
+	reencode := func(def *Group, decode, encode *Statement, format string) {
+		def.Var().Id("v").Id(r.Name)
+		cond := Add(decode).Op("!=").Nil()
+		if hasUnionField {
+			cond = cond.Op("||").Id("v").Dot(ValidateUnionFields).Call(True()).Op("!=").Nil()
+		}
+		def.If(cond).Block(Return())
+		def.If(List(Id("_"), Err()).Op(":=").Add(encode), Err().Op("!=").Nil()).Block(
+			Id("t").Dot("Errorf").Call(Lit("Could not re-encode decoded "+format+" %q: %+v"), Id("data"), Err()),
+		)
+	}
+
+	def.Commentf("Fuzz%s checks that decoding arbitrary data into a %s never panics, and that anything that is successfully "+
+		"decoded can be encoded again", r.Name, r.Name).Line()
+	def.Func().Id("Fuzz" + r.Name).Params(Id("f").Op("*").Qual("testing", "F")).BlockFunc(func(def *Group) {
+		def.Id("f").Dot("Add").Call(Index().Byte().Call(Lit("{}")))
+		def.Id("f").Dot("Add").Call(Index().Byte().Call(Lit("()")))
+		def.Id("f").Dot("Fuzz").Call(Func().Params(Id("t").Op("*").Qual("testing", "T"), Id("data").Index().Byte()).BlockFunc(func(def *Group) {
+			def.Func().Call().BlockFunc(func(def *Group) {
+				reencode(def,
+					Qual(JsonPackage, Unmarshal).Call(Id("data"), Op("&").Id("v")),
+					Qual(JsonPackage, Marshal).Call(Op("&").Id("v")),
+					"JSON")
+			}).Call()
+			def.Func().Call().BlockFunc(func(def *Group) {
+				reencode(def,
+					Id("v").Dot(RestLiDecode).Call(Qual(ProtocolPackage, RestLiUrlEncoder), String().Call(Id("data"))),
+					Id("v").Dot(RestLiEncode).Call(Qual(ProtocolPackage, RestLiUrlEncoder)),
+					"rest.li data")
+			}).Call()
+		}))
+	})
+
+	return def
+}
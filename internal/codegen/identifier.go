@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 var namespaceEscape = regexp.MustCompile("([/.])_?internal([/.]?)")
@@ -26,13 +28,23 @@ func (i Identifier) GetQualifiedClasspath() string {
 	return i.Namespace + "." + i.Name
 }
 
-func (i Identifier) PackagePath() string {
+// Validate checks that the identifier can be turned into a package path. It is called when types are registered, which
+// guarantees that PackagePath will never be called on an invalid Identifier
+func (i Identifier) Validate() error {
 	if i.Namespace == "" {
-		Logger.Panicf("%+v has no namespace!", i)
+		return errors.Errorf("go-restli: %+v has no namespace!", i)
 	}
 	if i.Name == "" {
-		Logger.Panicf("%+v has no name!", i)
+		return errors.Errorf("go-restli: %+v has no name!", i)
 	}
+	return nil
+}
+
+func (i Identifier) PackagePath() string {
+	if FlatPackage {
+		return PackagePrefix
+	}
+
 	var p string
 	if TypeRegistry.IsCyclic(i) {
 		p = "conflictResolution"
@@ -0,0 +1,225 @@
+package codegen
+
+import (
+	"sort"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+// LazyRecords are the fully-qualified names of the records whose record fields are decoded lazily. Such fields hold a
+// Lazy<Record> that keeps the field's raw JSON until it is first accessed, which saves decoding the fields that are never
+// read
+var LazyRecords []string
+
+// lazyRecords are the records listed in LazyRecords
+var lazyRecords = make(map[Identifier]bool)
+
+// Lazy is the type generated for each record that is referenced by a field of one of the LazyRecords. It holds the
+// record's raw JSON, and only decodes it the first time it is accessed
+type Lazy struct {
+	NamedType
+	Record *Record
+}
+
+func (l *Lazy) InnerTypes() IdentifierSet {
+	return IdentifierSet{l.Record.Identifier: true}
+}
+
+func (l *Lazy) GenerateCode() (def *Statement, err error) {
+	def = Empty()
+	receiver := ReceiverName(l.Name)
+	recordType := Qual(l.Record.PackagePath(), l.Record.Name)
+	raw := Id(receiver).Dot("raw")
+	value := Id(receiver).Dot("value")
+
+	def.Commentf("%s holds a %s that is only decoded from JSON the first time Get is called. It is not safe for "+
+		"concurrent use", l.Name, l.Record.Name).Line()
+	def.Type().Id(l.Name).Struct(
+		Id("raw").Qual(EncodingJson, "RawMessage"),
+		Id("value").Op("*").Add(recordType),
+	).Line().Line()
+
+	def.Commentf("New%s returns a %s that holds the given value", l.Name, l.Name).Line()
+	def.Func().Id("New" + l.Name).Params(Id("value").Op("*").Add(recordType)).Op("*").Id(l.Name).
+		Block(Return(Op("&").Id(l.Name).Values(Dict{Id("value"): Id("value")}))).
+		Line().Line()
+
+	def.Comment("Get decodes the value on the first call, and returns the cached value afterwards").Line()
+	AddFuncOnReceiver(def, receiver, l.Name, "Get").Params().Params(Op("*").Add(recordType), Error()).BlockFunc(func(def *Group) {
+		def.If(Add(value).Op("==").Nil().Op("&&").Add(raw).Op("!=").Nil()).BlockFunc(func(def *Group) {
+			def.Id("v").Op(":=").New(recordType)
+			def.Err().Op(":=").Qual(JsonPackage, Unmarshal).Call(raw, Id("v"))
+			IfErrReturn(def, Nil(), Err())
+			def.List(value, raw).Op("=").List(Id("v"), Nil())
+		})
+		def.Return(value, Nil())
+	}).Line().Line()
+
+	def.Comment("Set replaces the value").Line()
+	AddFuncOnReceiver(def, receiver, l.Name, "Set").Params(Id("v").Op("*").Add(recordType)).BlockFunc(func(def *Group) {
+		def.List(value, raw).Op("=").List(Id("v"), Nil())
+	}).Line().Line()
+
+	def.Comment("decoded returns the value, decoding it if needed without caching it").Line()
+	AddFuncOnReceiver(def, receiver, l.Name, "decoded").Params().Params(Op("*").Add(recordType), Error()).BlockFunc(func(def *Group) {
+		def.If(Add(value).Op("!=").Nil().Op("||").Add(raw).Op("==").Nil()).Block(Return(value, Nil()))
+		def.Id("v").Op(":=").New(recordType)
+		def.Err().Op(":=").Qual(JsonPackage, Unmarshal).Call(raw, Id("v"))
+		IfErrReturn(def, Nil(), Err())
+		def.Return(Id("v"), Nil())
+	}).Line().Line()
+
+	def.Commentf("DeepCopy returns a copy of this %s that shares no memory with it, see protocol.DeepCopier", l.Name).Line()
+	AddFuncOnReceiver(def, receiver, l.Name, "DeepCopy").Params().Interface().BlockFunc(func(def *Group) {
+		def.Id("c").Op(":=").New(Id(l.Name))
+		def.If(Add(raw).Op("!=").Nil()).Block(
+			Id("c").Dot("raw").Op("=").Append(Qual(EncodingJson, "RawMessage").Call(Nil()), Add(raw).Op("...")),
+		)
+		def.If(Add(value).Op("!=").Nil()).Block(
+			Id("c").Dot("value").Op("=").Qual(ProtocolPackage, "DeepCopy").Call(value).Assert(Op("*").Add(recordType)),
+		)
+		def.Return(Id("c"))
+	}).Line().Line()
+
+	def.Commentf("DeepEqual compares the decoded values of both %ss, regardless of whether they were accessed yet. "+
+		"Values that cannot be decoded are only equal to identical JSON, see protocol.DeepEqualer", l.Name).Line()
+	AddFuncOnReceiver(def, receiver, l.Name, "DeepEqual").Params(Id("other").Interface()).Bool().BlockFunc(func(def *Group) {
+		def.List(Id("o"), Id("ok")).Op(":=").Id("other").Assert(Op("*").Id(l.Name))
+		def.If(Op("!").Id("ok")).Block(Return(False()))
+		def.List(Id("v"), Err()).Op(":=").Id(receiver).Dot("decoded").Call()
+		def.List(Id("ov"), Id("otherErr")).Op(":=").Id("o").Dot("decoded").Call()
+		def.If(Err().Op("!=").Nil().Op("||").Id("otherErr").Op("!=").Nil()).Block(
+			Return(Err().Op("!=").Nil().Op("&&").Id("otherErr").Op("!=").Nil().Op("&&").
+				Qual("bytes", "Equal").Call(raw, Id("o").Dot("raw"))),
+		)
+		def.Return(Qual(ProtocolPackage, "DeepEqual").Call(Id("v"), Id("ov")))
+	}).Line().Line()
+
+	AddMarshalJSON(def, receiver, l.Name, func(def *Group) {
+		// Values that were never accessed are written back as they were received
+		def.If(Add(value).Op("==").Nil().Op("&&").Add(raw).Op("!=").Nil()).Block(Return(raw, Nil()))
+		def.Return(Qual(JsonPackage, Marshal).Call(value))
+	}).Line().Line()
+	AddUnmarshalJSON(def, receiver, l.Name, func(def *Group) {
+		def.List(value, raw).Op("=").List(Nil(), Append(Qual(EncodingJson, "RawMessage").Call(Nil()), Id("data").Op("...")))
+		def.Return(Nil())
+	}).Line().Line()
+
+	AddRestLiEncode(def, receiver, l.Name, func(def *Group) {
+		def.List(Id("v"), Err()).Op(":=").Id(receiver).Dot("Get").Call()
+		IfErrReturn(def, Lit(""), Err())
+		def.If(Id("v").Op("==").Nil()).Block(
+			Return(Lit(""), Qual("fmt", "Errorf").Call(Lit("go-restli: Cannot encode an empty "+l.Name))),
+		)
+		def.Return(Id("v").Dot(RestLiEncode).Call(Id(Codec)))
+	}).Line().Line()
+	AddRestLiDecode(def, receiver, l.Name, func(def *Group) {
+		def.Id("v").Op(":=").New(recordType)
+		def.Err().Op("=").Id("v").Dot(RestLiDecode).Call(Id(Codec), Id("data"))
+		IfErrReturn(def)
+		def.List(value, raw).Op("=").List(Id("v"), Nil())
+		def.Return()
+	}).Line().Line()
+
+	return def, nil
+}
+
+// generateLazyGetters generates a GetXxx function for every lazily decoded field, which decodes the field on first
+// access. Functions that would conflict with the name of another field are skipped
+func (r *Record) generateLazyGetters(def *Statement) {
+	fieldNames := make(map[string]bool)
+	for _, f := range r.Fields {
+		fieldNames[ExportedIdentifier(f.Name)] = true
+	}
+
+	for _, f := range r.Fields {
+		if f.Type.Reference == nil {
+			continue
+		}
+		lazy, ok := f.Type.Reference.Resolve().(*Lazy)
+		if !ok {
+			continue
+		}
+
+		funcName := "Get" + ExportedIdentifier(f.Name)
+		if fieldNames[funcName] {
+			Logger.Printf("Warning: cannot generate %s on %s since it conflicts with a field", funcName, r.Identifier)
+			continue
+		}
+
+		def.Commentf("%s decodes the %s field the first time it is called, and returns nil if the field is absent",
+			funcName, f.Name).Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, funcName).
+			Params().
+			Params(Op("*").Qual(lazy.Record.PackagePath(), lazy.Record.Name), Error()).
+			BlockFunc(func(def *Group) {
+				def.If(r.field(f).Op("==").Nil()).Block(Return(Nil(), Nil()))
+				def.Return(r.field(f).Dot("Get").Call())
+			}).
+			Line().Line()
+	}
+}
+
+// registerLazyTypes registers a Lazy type for every record referenced by a field of the LazyRecords, and makes those
+// fields reference it instead
+func (reg typeRegistry) registerLazyTypes() error {
+	lazyRecords = make(map[Identifier]bool)
+	if len(LazyRecords) == 0 {
+		return nil
+	}
+
+	var records []*Record
+	for _, fqn := range LazyRecords {
+		idx := strings.LastIndex(fqn, ".")
+		if idx <= 0 {
+			return errors.Errorf("go-restli: Lazy record %s is not a fully-qualified name", fqn)
+		}
+		id := Identifier{Namespace: fqn[:idx], Name: fqn[idx+1:]}
+		t, ok := reg[id]
+		if !ok {
+			return errors.Errorf("go-restli: Unknown lazy record %s", fqn)
+		}
+		record, ok := t.Type.(*Record)
+		if !ok {
+			return errors.Errorf("go-restli: Lazy type %s is not a record", fqn)
+		}
+		lazyRecords[id] = true
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Identifier.String() < records[j].Identifier.String()
+	})
+
+	wrappers := make(map[Identifier]*Identifier)
+	for _, r := range records {
+		for i, f := range r.Fields {
+			if f.Type.Reference == nil {
+				continue
+			}
+			ref, ok := f.Type.Reference.Resolve().(*Record)
+			if !ok {
+				continue
+			}
+
+			wrapper, ok := wrappers[ref.Identifier]
+			if !ok {
+				l := &Lazy{
+					NamedType: NamedType{
+						Identifier: Identifier{Namespace: ref.Namespace, Name: "Lazy" + ref.Name},
+						SourceFile: ref.SourceFile,
+					},
+					Record: ref,
+				}
+				if err := reg.Register(l); err != nil {
+					return err
+				}
+				wrapper = &l.Identifier
+				wrappers[ref.Identifier] = wrapper
+			}
+			r.Fields[i].Type.Reference = wrapper
+		}
+	}
+	return nil
+}
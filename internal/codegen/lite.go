@@ -0,0 +1,84 @@
+package codegen
+
+import (
+	"github.com/pkg/errors"
+)
+
+// LiteMethods, when set, restricts the generated clients to the given methods, each named by the namespace of its
+// resource followed by its name (e.g. com.example.profiles.get), or to all the methods of a resource when only its
+// namespace is given (e.g. com.example.profiles). Only the types referenced by these methods are generated
+var LiteMethods []string
+
+// AllTypes disables the removal of the types that are not referenced by any resource, such that every type in the
+// spec is generated. It has no effect on specs without resources, whose types are always all generated, nor in lite
+// mode, see LiteMethods
+var AllTypes bool
+
+// selectLiteMethods drops the methods that are not part of the LiteMethods, as well as the resources that have none
+// left
+func (s *GoRestliSpec) selectLiteMethods() error {
+	if len(LiteMethods) == 0 {
+		return nil
+	}
+
+	matched := make(map[string]bool, len(LiteMethods))
+	selected := func(names ...string) bool {
+		for _, name := range names {
+			for _, pattern := range LiteMethods {
+				if pattern == name {
+					matched[pattern] = true
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var resources []Resource
+	methodCount := 0
+	for _, r := range s.Resources {
+		var methods []*Method
+		for _, m := range r.Methods {
+			if selected(r.Namespace, r.Namespace+"."+m.Name) {
+				methods = append(methods, m)
+			}
+		}
+		if len(methods) > 0 {
+			r.Methods = methods
+			methodCount += len(methods)
+			resources = append(resources, r)
+		}
+	}
+
+	var errs ErrorList
+	for _, pattern := range LiteMethods {
+		if !matched[pattern] {
+			errs.Add(errors.Errorf("go-restli: Unknown lite method or resource %s", pattern))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	s.Resources = resources
+
+	Logger.Printf("Lite mode: generating %d methods of %d resources", methodCount, len(s.Resources))
+	return nil
+}
+
+// pruneUnreachableTypes drops the types that the resources do not reference, directly or through other types, unless
+// AllTypes is set
+func (s *GoRestliSpec) pruneUnreachableTypes() {
+	if len(s.Resources) == 0 || (AllTypes && len(LiteMethods) == 0) {
+		return
+	}
+
+	roots := make(IdentifierSet)
+	for _, r := range s.Resources {
+		roots.AddAll(r.InnerTypes())
+	}
+	removed := TypeRegistry.retainReachableTypes(roots)
+	if removed > 0 {
+		Logger.Printf("Skipped %d types that are not referenced by any resource (use --all-types to generate them)",
+			removed)
+	}
+}
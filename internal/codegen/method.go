@@ -1,7 +1,12 @@
 package codegen
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
 type MethodType string
@@ -16,16 +21,77 @@ type Method struct {
 	MethodType MethodType
 	Name       string
 	Doc        string
+	Deprecated *string
 	Path       string
 	OnEntity   bool
 	PathKeys   []PathKey
 	Params     []Field
 	Return     *RestliType
+	// MaxBatchSize is only set on batch methods that declare a max batch size
+	MaxBatchSize *MaxBatchSize
+	// Metadata is the type of the metadata returned alongside the elements of finders, if they declare one
+	Metadata *RestliType
+	// Annotations holds the raw contents of the custom annotations declared on the method in its restspec, keyed by
+	// annotation name. They can be used to drive the generation of a method from the schema itself.
+	Annotations map[string]json.RawMessage
+
+	alternativeKey *AlternativeKey
+	// featureFlag is the name of the feature flag the method is behind, see FeatureFlagAnnotation
+	featureFlag string
+}
+
+type MaxBatchSize struct {
+	Value int
+	// Validate is true when the server rejects batches larger than Value
+	Validate bool
 }
 
 type PathKey struct {
 	Name string
 	Type RestliType
+	// Params is only set for complex keys that declare params, which are sent alongside the key under $params
+	Params *RestliType
+	// AssocKeys is only set for the keys of association resources, and holds the parts of the compound key. Type then
+	// references the record that is declared to hold them, see registerAssociationKeys
+	AssocKeys []Field
+}
+
+func (pk *PathKey) paramsName() string {
+	return pk.Name + "Params"
+}
+
+// HasAnnotation returns true if the method was annotated with the given annotation in its restspec
+func (m *Method) HasAnnotation(name string) bool {
+	_, ok := m.Annotations[name]
+	return ok
+}
+
+// Annotation unmarshals the contents of the given annotation into v. It returns false if the method does not have the
+// annotation, in which case v is left untouched.
+func (m *Method) Annotation(name string, v interface{}) (bool, error) {
+	raw, ok := m.Annotations[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, errors.Wrapf(err, "go-restli: Could not unmarshal @%s annotation of %s", name, m.Name)
+	}
+	return true, nil
+}
+
+// addDocComment adds the method's doc to the given code, followed by a list of the docs of its parameters
+func (m *Method) addDocComment(code *Statement) *Statement {
+	var params []string
+	for _, p := range m.Params {
+		if p.Doc != "" {
+			params = append(params, fmt.Sprintf("  - %s: %s", p.Name, strings.Join(strings.Fields(p.Doc), " ")))
+		}
+	}
+	var paramsDoc string
+	if len(params) > 0 {
+		paramsDoc = "Parameters:\n" + strings.Join(params, "\n")
+	}
+	return addCommentParagraphs(code, m.Doc, paramsDoc, deprecationParagraph(m.Deprecated))
 }
 
 func (m *Method) addEntityTypes(def *Group) {
@@ -35,12 +101,18 @@ func (m *Method) addEntityTypes(def *Group) {
 func addEntityTypes(def *Group, pathKeys []PathKey) {
 	for _, pk := range pathKeys {
 		def.Id(pk.Name).Add(pk.Type.ReferencedType())
+		if pk.Params != nil {
+			def.Id(pk.paramsName()).Add(pk.Params.ReferencedType())
+		}
 	}
 }
 
 func (m *Method) entityParams() (params []Code) {
 	for _, p := range m.PathKeys {
 		params = append(params, Id(p.Name))
+		if p.Params != nil {
+			params = append(params, Id(p.paramsName()))
+		}
 	}
 	return params
 }
@@ -0,0 +1,29 @@
+package codegen
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+// generateOverlay generates OverlayXxx, which composes a record from a base (typically holding defaults) and overrides.
+// Unlike MergeXxx, which applies a patch, fields can only be overridden and never deleted
+func (r *Record) generateOverlay(def *Statement) {
+	overlay := "Overlay" + r.Name
+	def.Commentf("%s returns a shallow copy of base in which every field that is set in overrides is replaced by its "+
+		"value in overrides. Neither base nor overrides are modified, and either can be nil", overlay).Line()
+	def.Func().Id(overlay).
+		Params(Id("base"), Id("overrides").Op("*").Id(r.Name)).
+		Op("*").Id(r.Name).
+		BlockFunc(func(def *Group) {
+			def.Id("result").Op(":=").New(Id(r.Name))
+			def.If(Id("base").Op("!=").Nil()).Block(Op("*").Id("result").Op("=").Op("*").Id("base"))
+			def.If(Id("overrides").Op("==").Nil()).Block(Return(Id("result"))).Line()
+
+			for _, f := range r.Fields {
+				field := ExportedIdentifier(f.Name)
+				def.If(r.isSet(f, Id("overrides").Dot(field))).Block(
+					Id("result").Dot(field).Op("=").Id("overrides").Dot(field),
+				)
+			}
+			def.Return(Id("result"))
+		}).Line().Line()
+}
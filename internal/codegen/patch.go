@@ -0,0 +1,178 @@
+package codegen
+
+import (
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+const (
+	PatchSet    = "Set"
+	PatchDelete = "Delete"
+	ArrayPatch  = "ArrayPatch"
+)
+
+func (r *Record) PatchType() string {
+	return r.Name + "Patch"
+}
+
+// generatePatch generates the struct used to send PARTIAL_UPDATE requests for this record. Array fields get an
+// additional *protocol.ArrayPatch field which can be used to reorder their elements
+// https://linkedin.github.io/rest.li/spec/protocol#partial-update
+func (r *Record) generatePatch(def *Statement) {
+	AddWordWrappedComment(def, fmt.Sprintf("%s describes a partial update to a %s. Fields in %s are set, fields named "+
+		"in %s are removed and array fields can be reordered", r.PatchType(), r.Name, PatchSet, PatchDelete)).Line()
+	def.Type().Id(r.PatchType()).StructFunc(func(def *Group) {
+		def.Id(PatchSet).Op("*").Id(r.Name).Tag(JsonFieldTag("$set", true))
+		def.Id(PatchDelete).Index().String().Tag(JsonFieldTag("$delete", true))
+
+		for _, f := range r.Fields {
+			if f.Type.Array == nil {
+				continue
+			}
+			if !isArrayPatchField(f) {
+				Logger.Printf("Warning: cannot generate the %s of %s on %s since it conflicts with the %s and %s fields",
+					ArrayPatch, f.Name, r.PatchType(), PatchSet, PatchDelete)
+				continue
+			}
+			def.Line().Comment(fmt.Sprintf("%s reorders the elements of the %s field",
+				ExportedIdentifier(f.Name), ExportedIdentifier(f.Name)))
+			def.Id(ExportedIdentifier(f.Name)).Op("*").Qual(ProtocolPackage, ArrayPatch).Tag(JsonFieldTag(f.Name, true))
+		}
+	}).Line().Line()
+
+	r.generateDiff(def)
+	r.generateMerge(def)
+}
+
+// isArrayPatchField returns whether the patch of a record gets an ArrayPatch field for the given field, which is only
+// the case for array fields whose name does not conflict with the Set and Delete fields
+func isArrayPatchField(f Field) bool {
+	name := ExportedIdentifier(f.Name)
+	return f.Type.Array != nil && name != PatchSet && name != PatchDelete
+}
+
+// fieldType returns the type of the given field in the generated struct
+func (r *Record) fieldType(f Field) *Statement {
+	if f.IsPointer() {
+		return f.Type.PointerType()
+	}
+	return f.Type.GoType()
+}
+
+func (r *Record) isUnset(f Field, accessor *Statement) *Statement {
+	if f.Type.IsUnion() {
+		return Qual("reflect", "ValueOf").Call(accessor).Dot("IsZero").Call()
+	}
+	return Add(accessor).Op("==").Nil()
+}
+
+func (r *Record) isSet(f Field, accessor *Statement) *Statement {
+	if f.Type.IsUnion() {
+		return Op("!").Qual("reflect", "ValueOf").Call(accessor).Dot("IsZero").Call()
+	}
+	return Add(accessor).Op("!=").Nil()
+}
+
+func (r *Record) generateDiff(def *Statement) {
+	diff := "Diff" + r.Name
+	AddWordWrappedComment(def, fmt.Sprintf("%s returns the minimal %s that turns from into to. Fields that are set in to "+
+		"and differ from their value in from are copied into %s, such that later changes to to do not affect the patch. "+
+		"Optional fields that are only set in from are put in %s. Required fields are never deleted since servers reject "+
+		"such patches, they are left as is instead. A nil patch is returned if from and to are identical, and an error "+
+		"if either of them is nil", diff, r.PatchType(), PatchSet, PatchDelete)).Line()
+	def.Func().Id(diff).
+		Params(Id("from"), Id("to").Op("*").Id(r.Name)).
+		Params(Id(PartialUpdateParam).Op("*").Id(r.PatchType()), Err().Error()).
+		BlockFunc(func(def *Group) {
+			def.If(Id("from").Op("==").Nil().Op("||").Id("to").Op("==").Nil()).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit("go-restli: Cannot diff a nil "+r.Name))),
+			).Line()
+
+			def.Id(PartialUpdateParam).Op("=").New(Id(r.PatchType())).Line()
+
+			for _, f := range r.Fields {
+				from := Id("from").Dot(ExportedIdentifier(f.Name))
+				to := Id("to").Dot(ExportedIdentifier(f.Name))
+
+				set := Block(
+					If(Id(PartialUpdateParam).Dot(PatchSet).Op("==").Nil()).Block(
+						Id(PartialUpdateParam).Dot(PatchSet).Op("=").New(Id(r.Name)),
+					),
+					Id(PartialUpdateParam).Dot(PatchSet).Dot(ExportedIdentifier(f.Name)).Op("=").
+						Qual(ProtocolPackage, "DeepCopy").Call(to).Assert(r.fieldType(f)),
+				)
+
+				def.If(Op("!").Qual(ProtocolPackage, "DeepEqual").Call(from, to)).BlockFunc(func(def *Group) {
+					if f.IsOptional {
+						def.If(r.isUnset(f, to)).Block(
+							Id(PartialUpdateParam).Dot(PatchDelete).Op("=").Append(Id(PartialUpdateParam).Dot(PatchDelete), Lit(f.Name)),
+						).Else().Add(set)
+					} else {
+						def.If(r.isSet(f, to)).Add(set)
+					}
+				}).Line()
+			}
+
+			def.If(Id(PartialUpdateParam).Dot(PatchSet).Op("==").Nil().Op("&&").Len(Id(PartialUpdateParam).Dot(PatchDelete)).Op("==").Lit(0)).
+				Block(Return(Nil(), Nil()))
+			def.Return(Id(PartialUpdateParam), Nil())
+		}).Line().Line()
+}
+
+func (r *Record) generateMerge(def *Statement) {
+	merge := "Merge" + r.Name
+	AddWordWrappedComment(def, fmt.Sprintf("%s applies the given patch to base. Fields are deleted first, then set, then "+
+		"array fields are reordered. A nil patch leaves base untouched, but an error is returned if base is nil",
+		merge)).Line()
+	def.Func().Id(merge).
+		Params(Id("base").Op("*").Id(r.Name), Id(PartialUpdateParam).Op("*").Id(r.PatchType())).
+		Params(Err().Error()).
+		BlockFunc(func(def *Group) {
+			def.If(Id("base").Op("==").Nil()).Block(
+				Return(Qual("fmt", "Errorf").Call(Lit("go-restli: Cannot merge a patch into a nil " + r.Name))),
+			)
+			def.If(Id(PartialUpdateParam).Op("==").Nil()).Block(Return(Nil())).Line()
+
+			def.For(List(Id("_"), Id("field")).Op(":=").Range().Id(PartialUpdateParam).Dot(PatchDelete)).Block(
+				Switch(Id("field")).BlockFunc(func(def *Group) {
+					for _, f := range r.Fields {
+						field := Id("base").Dot(ExportedIdentifier(f.Name))
+						def.Case(Lit(f.Name))
+						if f.Type.IsUnion() {
+							def.Add(field).Op("=").Add(f.Type.GoType()).Values()
+						} else {
+							def.Add(field).Op("=").Nil()
+						}
+					}
+					def.Default().Return(Qual("fmt", "Errorf").Call(Lit("go-restli: Unknown field %q in "+r.Name), Id("field")))
+				}),
+			).Line()
+
+			def.If(Id("set").Op(":=").Id(PartialUpdateParam).Dot(PatchSet), Id("set").Op("!=").Nil()).BlockFunc(func(def *Group) {
+				for _, f := range r.Fields {
+					field := ExportedIdentifier(f.Name)
+					def.If(r.isSet(f, Id("set").Dot(field))).Block(
+						Id("base").Dot(field).Op("=").Id("set").Dot(field),
+					)
+				}
+			}).Line()
+
+			for _, f := range r.Fields {
+				if !isArrayPatchField(f) {
+					continue
+				}
+				field := ExportedIdentifier(f.Name)
+				def.If(Id(PartialUpdateParam).Dot(field).Op("!=").Nil()).BlockFunc(func(def *Group) {
+					def.Err().Op("=").Id(PartialUpdateParam).Dot(field).Dot("Apply").Call(Id("base").Dot(field))
+					IfErrReturn(def, Err())
+				}).Line()
+			}
+
+			def.Return(Nil())
+		}).Line().Line()
+}
+
+func (t *RestliType) PatchType() *Statement {
+	return Op("*").Qual(t.Reference.PackagePath(), t.Reference.Name+"Patch")
+}
@@ -0,0 +1,64 @@
+package codegen
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+const PathSpecFunc = "PathSpec"
+
+func (r *Record) pathSpecType() string {
+	return r.Name + "PathSpec"
+}
+
+func (r *Record) pathSpecVar() string {
+	return r.Name + "Fields"
+}
+
+// referencedRecord returns the record this type refers to, if any
+func (t *RestliType) referencedRecord() *Record {
+	if t.Reference == nil {
+		return nil
+	}
+	record, _ := t.Reference.Resolve().(*Record)
+	return record
+}
+
+// generatePathSpecs generates a type that builds the protocol.PathSpec of each field of the record, along with a var
+// rooted at the record itself. Fields that are records return their own PathSpec type, so that nested fields can be
+// selected as well, e.g. GroupFields.Owner().Id(). Since each nested type is built lazily, recursive records are
+// supported.
+func (r *Record) generatePathSpecs(def *Statement) {
+	pathSpec := r.pathSpecType()
+	receiver := ReceiverName(pathSpec)
+
+	def.Commentf("%s identifies the fields of %s (or of a field of type %s) in projections", pathSpec, r.Name, r.Name).Line()
+	def.Type().Id(pathSpec).Qual(ProtocolPackage, "PathSpec").Line().Line()
+
+	def.Commentf("%s holds the PathSpecs of the fields of %s", r.pathSpecVar(), r.Name).Line()
+	def.Var().Id(r.pathSpecVar()).Id(pathSpec).Line().Line()
+
+	def.Commentf("%s returns the PathSpec of the field this %s belongs to", PathSpecFunc, pathSpec).Line()
+	def.Func().Params(Id(receiver).Id(pathSpec)).Id(PathSpecFunc).Params().Qual(ProtocolPackage, "PathSpec").
+		Block(Return(Qual(ProtocolPackage, "PathSpec").Call(Id(receiver)))).
+		Line().Line()
+
+	for _, f := range r.Fields {
+		name := ExportedIdentifier(f.Name)
+		if name == PathSpecFunc {
+			Logger.Printf("Warning: cannot generate the PathSpec of %s.%s since it conflicts with %s", r.Identifier, f.Name, PathSpecFunc)
+			continue
+		}
+
+		spec := Qual(ProtocolPackage, "PathSpec").Call(Id(receiver)).Dot("Append").Call(Lit(f.Name))
+
+		def.Commentf("%s returns the PathSpec of the %s field", name, f.Name).Line()
+		method := def.Func().Params(Id(receiver).Id(pathSpec)).Id(name).Params()
+		if record := f.Type.referencedRecord(); record != nil {
+			fieldPathSpec := Qual(record.PackagePath(), record.pathSpecType())
+			method.Add(fieldPathSpec).Block(Return(fieldPathSpec.Clone().Call(spec)))
+		} else {
+			method.Qual(ProtocolPackage, "PathSpec").Block(Return(spec))
+		}
+		def.Line().Line()
+	}
+}
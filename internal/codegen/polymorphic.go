@@ -0,0 +1,190 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+// PolymorphicRecords maps the fully-qualified names of base records to the name of their discriminator field. Record
+// fields whose type is one of these records can hold the base record or any record that includes it, and are decoded
+// into the concrete type named by the discriminator field, which holds the fully-qualified name of the record
+var PolymorphicRecords map[string]string
+
+// polymorphicTypes are the Polymorphic types created by registerPolymorphicTypes, sorted by name
+var polymorphicTypes []*Polymorphic
+
+// Polymorphic is the wrapper type generated for each base record in PolymorphicRecords. It holds a value of the
+// <Base>Subtype interface, which is implemented by the base record and by every record that includes all its fields
+type Polymorphic struct {
+	NamedType
+	Base          *Record
+	Discriminator string
+}
+
+func (p *Polymorphic) InnerTypes() IdentifierSet {
+	return nil
+}
+
+func (p *Polymorphic) subtypeInterface() string {
+	return p.Base.Name + "Subtype"
+}
+
+// isSubtype returns true if the given record includes all the fields of the base record, which is what including the
+// base record in a schema amounts to since includes are flattened by the parser
+func (p *Polymorphic) isSubtype(r *Record) bool {
+	fields := make(map[string]Field, len(r.Fields))
+	for _, f := range r.Fields {
+		fields[f.Name] = f
+	}
+	for _, baseField := range p.Base.Fields {
+		f, ok := fields[baseField.Name]
+		if !ok || f.IsOptional != baseField.IsOptional || f.Type.GoType().GoString() != baseField.Type.GoType().GoString() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Polymorphic) GenerateCode() (def *Statement, err error) {
+	def = Empty()
+	receiver := ReceiverName(p.Name)
+	value := Id(receiver).Dot("Value")
+	discriminator := Lit(p.Discriminator)
+
+	def.Commentf("%s is implemented by %s and by every record that includes all of its fields", p.subtypeInterface(),
+		p.Base.Name).Line()
+	def.Type().Id(p.subtypeInterface()).Interface(
+		Qual(ProtocolPackage, "RestLiObject"),
+		Id(p.subtypeInterface()).Params(),
+	).Line().Line()
+
+	AddWordWrappedComment(def, fmt.Sprintf("%s holds an instance of %s or of any of its subtypes. The concrete type is given by the "+
+		"%s field, which holds the fully-qualified name of the record and is always set when the value is encoded",
+		p.Name, p.Base.Name, p.Discriminator)).Line()
+	def.Type().Id(p.Name).Struct(Id("Value").Id(p.subtypeInterface())).Line().Line()
+
+	// setValue checks that the decoded object is a subtype, since the discriminator can name any registered record
+	setValue := func(def *Group) {
+		IfErrReturn(def)
+		def.List(Id("v"), Id("ok")).Op(":=").Id("obj").Assert(Id(p.subtypeInterface()))
+		def.If(Op("!").Id("ok")).Block(
+			Return(Qual("fmt", "Errorf").Call(Lit("go-restli: %T is not a subtype of "+p.Base.GetQualifiedClasspath()), Id("obj"))),
+		)
+		def.Add(value).Op("=").Id("v")
+		def.Return(Nil())
+	}
+
+	AddMarshalJSON(def, receiver, p.Name, func(def *Group) {
+		def.Return(Qual(ProtocolPackage, "MarshalPolymorphic").Call(discriminator, value))
+	}).Line().Line()
+	AddUnmarshalJSON(def, receiver, p.Name, func(def *Group) {
+		def.List(Id("obj"), Err()).Op(":=").Qual(ProtocolPackage, "UnmarshalPolymorphic").Call(discriminator, Id("data"))
+		setValue(def)
+	}).Line().Line()
+
+	AddRestLiEncode(def, receiver, p.Name, func(def *Group) {
+		def.Return(Qual(ProtocolPackage, "EncodePolymorphic").Call(Id(Codec), discriminator, value))
+	}).Line().Line()
+	AddRestLiDecode(def, receiver, p.Name, func(def *Group) {
+		def.List(Id("obj"), Err()).Op(":=").Qual(ProtocolPackage, "DecodePolymorphic").Call(Id(Codec), discriminator, Id("data"))
+		setValue(def)
+	}).Line().Line()
+
+	return def, nil
+}
+
+// generateSubtypeMarkers implements the <Base>Subtype interface of every polymorphic base record this record is a
+// subtype of
+func (r *Record) generateSubtypeMarkers(def *Statement) {
+	for _, p := range polymorphicTypes {
+		if !p.isSubtype(r) {
+			continue
+		}
+		def.Commentf("%s marks %s as a subtype of %s", p.subtypeInterface(), r.Name, p.Base.GetQualifiedClasspath()).Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, p.subtypeInterface()).Params().Block().Line().Line()
+	}
+}
+
+// registerPolymorphicTypes registers a Polymorphic type for each of the PolymorphicRecords, and makes all the record
+// fields that reference a base record reference its Polymorphic type instead
+func (reg typeRegistry) registerPolymorphicTypes() error {
+	polymorphicTypes = nil
+	if len(PolymorphicRecords) == 0 {
+		return nil
+	}
+
+	wrappers := make(map[Identifier]*Identifier)
+	for fqn, discriminator := range PolymorphicRecords {
+		idx := strings.LastIndex(fqn, ".")
+		if idx <= 0 {
+			return errors.Errorf("go-restli: Polymorphic record %s is not a fully-qualified name", fqn)
+		}
+		id := Identifier{Namespace: fqn[:idx], Name: fqn[idx+1:]}
+
+		t, ok := reg[id]
+		if !ok {
+			return errors.Errorf("go-restli: Unknown polymorphic record %s", fqn)
+		}
+		base, ok := t.Type.(*Record)
+		if !ok {
+			return errors.Errorf("go-restli: Polymorphic type %s is not a record", fqn)
+		}
+		if !base.hasStringField(discriminator) {
+			return errors.Errorf("go-restli: Polymorphic record %s has no %s string field to use as its discriminator",
+				fqn, discriminator)
+		}
+
+		p := &Polymorphic{
+			NamedType: NamedType{
+				Identifier: Identifier{Namespace: id.Namespace, Name: id.Name + "Polymorphic"},
+				SourceFile: base.SourceFile,
+			},
+			Base:          base,
+			Discriminator: discriminator,
+		}
+		if err := reg.Register(p); err != nil {
+			return err
+		}
+		polymorphicTypes = append(polymorphicTypes, p)
+		wrappers[id] = &p.Identifier
+	}
+	sort.Slice(polymorphicTypes, func(i, j int) bool {
+		return polymorphicTypes[i].Identifier.String() < polymorphicTypes[j].Identifier.String()
+	})
+
+	for _, t := range reg {
+		if r, ok := t.Type.(*Record); ok {
+			for i := range r.Fields {
+				r.Fields[i].Type.replaceReferences(wrappers)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Record) hasStringField(name string) bool {
+	for _, f := range r.Fields {
+		if f.Name == name {
+			return f.Type.Primitive != nil && f.Type.Primitive.Type == "string"
+		}
+	}
+	return false
+}
+
+// replaceReferences replaces the references to the given identifiers, including those of array elements and map values
+func (t *RestliType) replaceReferences(replacements map[Identifier]*Identifier) {
+	switch {
+	case t.Reference != nil:
+		if replacement, ok := replacements[*t.Reference]; ok {
+			t.Reference = replacement
+		}
+	case t.Array != nil:
+		t.Array.replaceReferences(replacements)
+	case t.Map != nil:
+		t.Map.replaceReferences(replacements)
+	}
+}
@@ -61,14 +61,14 @@ func (p *PrimitiveType) GoType() *Statement {
 	}
 }
 
-func (p *PrimitiveType) getLit(rawJson string) interface{} {
+func (p *PrimitiveType) getLit(rawJson string) (interface{}, error) {
 	v := p.newInstance()
 
 	err := json.Unmarshal([]byte(rawJson), v)
 	if err != nil {
-		Logger.Panicf("(%v) Illegal primitive literal: \"%s\" (%s)", p, rawJson, err)
+		return nil, errors.Wrapf(err, "go-restli: Illegal %s literal: %s", p.Type, rawJson)
 	}
-	return reflect.ValueOf(v).Elem().Interface()
+	return reflect.ValueOf(v).Elem().Interface(), nil
 }
 
 func (p *PrimitiveType) encode(accessor *Statement) *Statement {
@@ -2,22 +2,35 @@ package codegen
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
+	"unicode"
 
 	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
 var (
 	emptyMapRegex   = regexp.MustCompile("{ *}")
 	emptyArrayRegex = regexp.MustCompile("\\[ *]")
+
+	// PropertyTags are the keys of the field properties that are copied into struct tags of the same name, next to the
+	// json tag. Only string, number and boolean properties can be copied
+	PropertyTags []string
 )
 
 type Record struct {
 	NamedType
 	Fields []Field
 
-	populateDefaultValues *Statement
-	validateUnionFields   *Statement
+	populateDefaultValues      *Statement
+	populateRequiredDefaults   *Statement
+	validateUnionFields        *Statement
+	validateDecodedUnionFields *Statement
+	// tracksChanges is set for the ChangeTrackedRecords
+	tracksChanges bool
+	// conversions are the records this record is converted to, see RecordConversions
+	conversions []*Record
 }
 
 func (r *Record) InnerTypes() IdentifierSet {
@@ -25,6 +38,9 @@ func (r *Record) InnerTypes() IdentifierSet {
 	for _, f := range r.Fields {
 		innerTypes.AddAll(f.Type.InnerTypes())
 	}
+	for _, to := range r.conversions {
+		innerTypes.Add(to.Identifier)
+	}
 
 	return innerTypes
 }
@@ -33,8 +49,11 @@ type Field struct {
 	Type         RestliType
 	Name         string
 	Doc          string
+	Deprecated   *string
 	IsOptional   bool
 	DefaultValue *string
+	Validators   map[string]json.RawMessage
+	Properties   map[string]json.RawMessage
 }
 
 func (r *Record) field(f Field) *Statement {
@@ -45,11 +64,12 @@ func (f *Field) IsPointer() bool {
 	return !f.Type.IsUnion() && !f.Type.IsMapOrArray()
 }
 
-func (r *Record) generateStruct() *Statement {
+// generateStruct generates the record's struct. The given extra fields are added after the record's own fields
+func (r *Record) generateStruct(extraFields ...Code) *Statement {
 	return Type().Id(r.Name).StructFunc(func(def *Group) {
 		for _, f := range r.Fields {
 			field := def.Empty()
-			AddWordWrappedComment(field, f.Doc).Line()
+			AddDocComment(field, f.Doc, f.Deprecated).Line()
 			field.Id(ExportedIdentifier(f.Name))
 
 			if f.IsPointer() {
@@ -58,18 +78,137 @@ func (r *Record) generateStruct() *Statement {
 				field.Add(f.Type.GoType())
 			}
 
-			field.Tag(JsonFieldTag(f.Name, true))
+			field.Tag(r.fieldTags(f))
+		}
+		for _, f := range extraFields {
+			def.Add(f)
+		}
+		if r.tracksChanges {
+			def.Line().Comment("changes holds a bit for each field that was set through its SetXxx function")
+			def.Id(changesField).Add(r.changesType())
 		}
 	})
 }
 
-func (r *Record) GenerateCode() (def *Statement) {
+// fieldTags returns the struct tags of the given field, i.e. its json tag and the PropertyTags it declares
+func (r *Record) fieldTags(f Field) map[string]string {
+	tags := JsonFieldTag(f.Name, true)
+	for _, key := range PropertyTags {
+		raw, ok := f.Properties[key]
+		if !ok {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			Logger.Printf("Warning: could not parse the %q property of %s.%s: %s", key, r.Identifier, f.Name, err)
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if !isStructTagValue(v) {
+				Logger.Printf("Warning: the %q property of %s.%s contains quotes, backslashes or control characters, it "+
+					"cannot be copied to a struct tag", key, r.Identifier, f.Name)
+				continue
+			}
+			tags[key] = v
+		case float64, bool:
+			tags[key] = string(raw)
+		default:
+			Logger.Printf("Warning: the %q property of %s.%s is not a string, number or boolean, it cannot be copied "+
+				"to a struct tag", key, r.Identifier, f.Name)
+		}
+	}
+	return tags
+}
+
+// isStructTagValue returns whether the given value can be written verbatim between the quotes of a struct tag
+func isStructTagValue(v string) bool {
+	for _, c := range v {
+		if c == '"' || c == '`' || c == '\\' || !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Record) GenerateCode() (def *Statement, err error) {
+	def, err = r.generateModelCode()
+	if err != nil {
+		return nil, err
+	}
+
+	r.generatePatch(def)
+	r.generateOverlay(def)
+	r.generateFromMap(def)
+	r.generateCanonicalJSON(def)
+	r.generateHasFieldFuncs(def)
+	r.generateBuilder(def)
+	r.generateValidate(def)
+	r.generatePathSpecs(def)
+	r.generateSubtypeMarkers(def)
+	r.generateLazyGetters(def)
+	r.generateChangeTracking(def)
+	r.generateConversions(def)
+	for _, f := range r.Fields {
+		if union := f.Type.Union; union != nil {
+			union.generateVisitor(def, r.Receiver(), r.Name, ExportedIdentifier(f.Name), r.field(f),
+				fmt.Sprintf("%s.%s", r.Name, f.Name), !f.IsOptional)
+		}
+	}
+
+	return def, nil
+}
+
+// generateHasFieldFuncs generates a HasXxx function for every optional field, which reports whether the field is present.
+// Functions that would conflict with the name of another field are skipped
+func (r *Record) generateHasFieldFuncs(def *Statement) {
+	fieldNames := make(map[string]bool)
+	for _, f := range r.Fields {
+		fieldNames[ExportedIdentifier(f.Name)] = true
+	}
+
+	for _, f := range r.Fields {
+		if !f.IsOptional {
+			continue
+		}
+
+		funcName := "Has" + ExportedIdentifier(f.Name)
+		if fieldNames[funcName] {
+			Logger.Printf("Warning: cannot generate %s on %s since it conflicts with a field", funcName, r.Identifier)
+			continue
+		}
+
+		def.Commentf("%s returns whether the optional %s field is present", funcName, f.Name).Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, funcName).
+			Params().
+			Bool().
+			Block(Return(r.isSet(f, r.field(f)))).
+			Line().Line()
+	}
+}
+
+func (r *Record) generateCanonicalJSON(def *Statement) {
+	def.Commentf("%s returns the canonical JSON form of this %s, see protocol.%s", CanonicalJSON, r.Name, CanonicalJSON).Line()
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, CanonicalJSON).
+		Params().
+		Params(Index().Byte(), Error()).
+		Block(Return(Qual(ProtocolPackage, CanonicalJSON).Call(Id(r.Receiver())))).
+		Line().Line()
+}
+
+// generateModelCode generates the struct and all its serialization code, without any of the helpers that only make
+// sense for top-level models (e.g. the partial update patch type)
+func (r *Record) generateModelCode() (def *Statement, err error) {
 	def = Empty()
 
-	AddWordWrappedComment(def, r.Doc).Line()
+	AddDocComment(def, r.Doc, r.Deprecated).Line()
 	def.Add(r.generateStruct()).Line().Line()
 
-	hasDefaultValue := r.generatePopulateDefaultValues(def)
+	hasDefaultValue, err := r.generatePopulateDefaultValues(def)
+	if err != nil {
+		return nil, err
+	}
 	hasUnionField := r.generateValidateUnionFields(def)
 
 	if hasDefaultValue {
@@ -91,18 +230,22 @@ func (r *Record) GenerateCode() (def *Statement) {
 		}).Line().Line()
 	}
 
-	if hasDefaultValue || hasUnionField {
+	// Tracked records need their own UnmarshalJSON to clear their changes
+	if hasUnionField || r.tracksChanges {
 		r.jsonSerDe(def)
 	}
 	r.restLiSerDe(def)
 	r.generateInitializeUnionFields(def)
 
-	return def
+	return def, nil
 }
 
 func (r *Record) restLiSerDe(def *Statement) {
 	AddRestLiEncode(def, r.Receiver(), r.Name, func(def *Group) {
-		def.Add(r.populateDefaultValues, r.validateUnionFields)
+		checkComplexValue(def)
+		// Only the defaults of required fields are sent. The defaults of optional fields are applied by the reader, so
+		// unset optional fields are omitted while set ones are always sent, even if they hold the default value
+		def.Add(r.populateRequiredDefaults, r.validateUnionFields)
 
 		def.Var().Id("buf").Qual("strings", "Builder")
 		def.Id("buf").Dot("WriteByte").Call(LitRune('('))
@@ -115,7 +258,8 @@ func (r *Record) restLiSerDe(def *Statement) {
 
 			serialize.BlockFunc(func(def *Group) {
 				if i != 0 {
-					def.Id("buf").Dot("WriteByte").Call(LitRune(','))
+					// Previous fields may have been absent, in which case the opening parenthesis is all that was written
+					def.If(Id("buf").Dot("Len").Call().Op("!=").Lit(1)).Block(Id("buf").Dot("WriteByte").Call(LitRune(',')))
 				}
 
 				accessor := r.field(f)
@@ -133,6 +277,37 @@ func (r *Record) restLiSerDe(def *Statement) {
 		def.Id("data").Op("=").Id("buf").Dot("String").Call()
 		def.Return()
 	}).Line().Line()
+
+	AddRestLiDecode(def, r.Receiver(), r.Name, func(def *Group) {
+		def.Var().Id("fields").Map(String()).String()
+		def.List(Id("fields"), Err()).Op("=").Id(Codec).Dot("DecodeObject").Call(Id("data"))
+		IfErrReturn(def, Err()).Line()
+
+		for _, f := range r.Fields {
+			def.If(List(Id("field"), Id("ok")).Op(":=").Id("fields").Index(Lit(f.Name)), Id("ok")).BlockFunc(func(def *Group) {
+				if f.IsPointer() {
+					def.Add(r.field(f)).Op("=").New(f.Type.GoType())
+					f.Type.ReadFromString(def, pointerTarget(r.field(f)), Id("field"), 0)
+				} else {
+					f.Type.ReadFromString(def, valueTarget(r.field(f)), Id("field"), 0)
+				}
+			}).Line()
+		}
+
+		// Like UnmarshalJSON, default values are not populated and unions are allowed to be absent
+		def.Add(r.validateDecodedUnionFields)
+		r.resetChanges(def)
+		def.Return()
+	}).Line().Line()
+}
+
+func (r *Record) preservesUnknownUnionMembers() bool {
+	for _, f := range r.Fields {
+		if union := f.Type.Union; union != nil && union.preservesUnknownMembers() {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Record) jsonSerDe(def *Statement) {
@@ -141,24 +316,55 @@ func (r *Record) jsonSerDe(def *Statement) {
 		//def.Add(r.populateDefaultValues)
 		def.Add(r.validateUnionFields)
 		def.Type().Id("_t").Id(r.Name)
-		def.Return(Qual(EncodingJson, Marshal).Call(Call(Op("*").Id("_t")).Call(Id(r.Receiver()))))
+		if !r.preservesUnknownUnionMembers() {
+			def.Return(Qual(JsonPackage, Marshal).Call(Call(Op("*").Id("_t")).Call(Id(r.Receiver()))))
+			return
+		}
+
+		def.List(Id("data"), Err()).Op("=").Qual(JsonPackage, Marshal).Call(Call(Op("*").Id("_t")).Call(Id(r.Receiver())))
+		IfErrReturn(def).Line()
+		for _, f := range r.Fields {
+			if union := f.Type.Union; union != nil && union.preservesUnknownMembers() {
+				unknown := r.field(f).Dot(UnknownMember)
+				def.If(Add(unknown).Op("!=").Nil()).Block(
+					List(Id("data"), Err()).Op("=").Qual(ProtocolPackage, "SetUnknownUnionMember").Call(Id("data"), Lit(f.Name), unknown),
+					If(Err().Op("!=").Nil()).Block(Return()),
+				)
+			}
+		}
+		def.Return()
 	}).Line().Line()
 
 	AddUnmarshalJSON(def, r.Receiver(), r.Name, func(def *Group) {
 		def.Type().Id("_t").Id(r.Name)
-		def.Err().Op("=").Qual(EncodingJson, Unmarshal).Call(Id("data"), Call(Op("*").Id("_t")).Call(Id(r.Receiver())))
+		def.Err().Op("=").Qual(JsonPackage, Unmarshal).Call(Id("data"), Call(Op("*").Id("_t")).Call(Id(r.Receiver())))
 		IfErrReturn(def).Line()
-		def.Add(r.populateDefaultValues, r.validateUnionFields)
+		for _, f := range r.Fields {
+			if union := f.Type.Union; union != nil && union.preservesUnknownMembers() {
+				def.List(r.field(f).Dot(UnknownMember), Err()).Op("=").Qual(ProtocolPackage, "FindUnknownUnionMemberInField").
+					Call(append([]Code{Id("data"), Lit(f.Name)}, union.aliases()...)...)
+				IfErrReturn(def)
+			}
+		}
+		// Default values are not populated on the way in either, since fields may be absent from the response simply
+		// because they were not part of the requested projection
+		def.Add(r.validateDecodedUnionFields)
+		r.resetChanges(def)
 		def.Return()
 	}).Line().Line()
 }
 
-func (r *Record) setDefaultValue(def *Group, name, rawJson string, t *RestliType) {
+func (r *Record) setDefaultValue(def *Group, name, rawJson string, t *RestliType) (err error) {
 	def.If(Id(r.Receiver()).Dot(name).Op("==").Nil()).BlockFunc(func(def *Group) {
 		switch {
 		// Special case for primitives, instead of parsing them from JSON every time, we can leave them as literals
 		case t.Primitive != nil:
-			def.Id("val").Op(":=").Lit(t.Primitive.getLit(rawJson))
+			var lit interface{}
+			lit, err = t.Primitive.getLit(rawJson)
+			if err != nil {
+				return
+			}
+			def.Id("val").Op(":=").Lit(lit)
 			def.Id(r.Receiver()).Dot(name).Op("= &").Id("val")
 			return
 		// If the default value for an array is the empty array, we can leave it as nil since that will behave
@@ -173,9 +379,14 @@ func (r *Record) setDefaultValue(def *Group, name, rawJson string, t *RestliType
 		case t.Reference != nil:
 			if enum, ok := t.Reference.Resolve().(*Enum); ok {
 				var v string
-				err := json.Unmarshal([]byte(rawJson), &v)
+				err = json.Unmarshal([]byte(rawJson), &v)
 				if err != nil {
-					Logger.Panicln("illegal enum", err)
+					err = errors.Wrapf(err, "go-restli: Illegal enum default value for %s: %s", name, rawJson)
+					return
+				}
+				if !enum.hasSymbol(v) {
+					err = errors.Errorf("go-restli: Illegal enum default value for %s: %s is not a symbol of %s", name, v, enum.Identifier)
+					return
 				}
 				def.Id("val").Op(":=").Qual(enum.PackagePath(), enum.SymbolIdentifier(v))
 				def.Id(r.Receiver()).Dot(name).Op("= &").Id("val")
@@ -185,9 +396,10 @@ func (r *Record) setDefaultValue(def *Group, name, rawJson string, t *RestliType
 
 		field := Op("&").Id(r.Receiver()).Dot(name)
 
-		def.Err().Op(":=").Qual(EncodingJson, Unmarshal).Call(Index().Byte().Call(Lit(rawJson)), field)
+		def.Err().Op(":=").Qual(JsonPackage, Unmarshal).Call(Index().Byte().Call(Lit(rawJson)), field)
 		def.If(Err().Op("!=").Nil()).Block(Qual("log", "Panicln").Call(Lit("Illegal default value"), Err()))
 	})
+	return err
 }
 
 func (r *Record) hasDefaultValue() bool {
@@ -199,28 +411,57 @@ func (r *Record) hasDefaultValue() bool {
 	return false
 }
 
-func (r *Record) generatePopulateDefaultValues(def *Statement) bool {
+func (r *Record) generatePopulateDefaultValues(def *Statement) (hasDefaultValue bool, err error) {
 	r.populateDefaultValues = Empty()
+	r.populateRequiredDefaults = Empty()
 
 	if !r.hasDefaultValue() {
-		return false
+		return false, nil
 	}
 
 	AddFuncOnReceiver(def, r.Receiver(), r.Name, PopulateDefaultValues).Params().BlockFunc(func(def *Group) {
 		for _, f := range r.Fields {
 			if f.DefaultValue != nil {
-				r.setDefaultValue(def, ExportedIdentifier(f.Name), *f.DefaultValue, &f.Type)
+				err = r.setDefaultValue(def, ExportedIdentifier(f.Name), *f.DefaultValue, &f.Type)
+				if err != nil {
+					return
+				}
 				def.Line()
 			}
 		}
 	}).Line().Line()
+	if err != nil {
+		return false, err
+	}
 
 	r.populateDefaultValues.Id(r.Receiver()).Dot(PopulateDefaultValues).Call().Line()
-	return true
+
+	hasRequiredDefault := false
+	for _, f := range r.Fields {
+		if f.DefaultValue != nil && !f.IsOptional {
+			hasRequiredDefault = true
+		}
+	}
+	if !hasRequiredDefault {
+		return true, nil
+	}
+
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, PopulateRequiredDefaults).Params().BlockFunc(func(def *Group) {
+		for _, f := range r.Fields {
+			if f.DefaultValue != nil && !f.IsOptional {
+				// The default values were already checked above
+				_ = r.setDefaultValue(def, ExportedIdentifier(f.Name), *f.DefaultValue, &f.Type)
+				def.Line()
+			}
+		}
+	}).Line().Line()
+	r.populateRequiredDefaults.Id(r.Receiver()).Dot(PopulateRequiredDefaults).Call().Line()
+	return true, nil
 }
 
 func (r *Record) generateValidateUnionFields(def *Statement) bool {
 	r.validateUnionFields = Empty()
+	r.validateDecodedUnionFields = Empty()
 
 	hasUnion := false
 	for _, f := range r.Fields {
@@ -234,7 +475,7 @@ func (r *Record) generateValidateUnionFields(def *Statement) bool {
 	}
 
 	AddFuncOnReceiver(def, r.Receiver(), r.Name, ValidateUnionFields).
-		Params().
+		Params(Id(RequireSet).Bool()).
 		Params(Err().Error()).
 		BlockFunc(func(def *Group) {
 			for _, f := range r.Fields {
@@ -245,16 +486,21 @@ func (r *Record) generateValidateUnionFields(def *Statement) bool {
 								Block(Return(Nil())).Line()
 						}
 
-						union.validateUnionFields(def, Id(r.Receiver()).Dot(ExportedIdentifier(f.Name)))
+						union.validateUnionFields(def, Id(r.Receiver()).Dot(ExportedIdentifier(f.Name)),
+							fmt.Sprintf("%s.%s", r.Name, f.Name), !f.IsOptional)
 					})
 				}
 			}
 			def.Return()
 		}).Line().Line()
 
-	r.validateUnionFields.Err().Op("=").Id(r.Receiver()).Dot(ValidateUnionFields).Call().Line()
+	r.validateUnionFields.Err().Op("=").Id(r.Receiver()).Dot(ValidateUnionFields).Call(True()).Line()
 	r.validateUnionFields.If(Err().Op("!=").Nil()).Block(Return()).Line()
 
+	// Unions may legitimately be absent from responses, e.g. when they were not part of the requested projection
+	r.validateDecodedUnionFields.Err().Op("=").Id(r.Receiver()).Dot(ValidateUnionFields).Call(False()).Line()
+	r.validateDecodedUnionFields.If(Err().Op("!=").Nil()).Block(Return()).Line()
+
 	return true
 }
 
@@ -10,8 +10,20 @@ import (
 
 const CreateParam = "create"
 const UpdateParam = "update"
-const PartialUpdateSetParam = "set"
-const PartialUpdateDeleteParam = "delete"
+const PartialUpdateParam = "patch"
+const FieldsParam = "fields"
+const ExistsFunc = "Exists"
+const RawFuncSuffix = "Raw"
+const RawBodyVar = "rawBody"
+const StreamFuncSuffix = "Stream"
+
+// RawGet makes each GET method also generate a GetRaw variant, which returns the raw response body alongside the
+// decoded entity
+var RawGet bool
+
+// CreateStream makes each CREATE method also generate a CreateStream variant, which streams the entity from an
+// io.Reader instead of serializing it in memory
+var CreateStream bool
 
 func (m *Method) RestLiMethod() protocol.RestLiMethod {
 	return protocol.RestLiMethodNameMapping[m.Name]
@@ -27,35 +39,77 @@ func (m *Method) restMethodFuncName() string {
 		}
 		name = name[:idx] + string(unicode.ToUpper(rune(name[idx+1]))) + name[idx+2:]
 	}
+	if m.alternativeKey != nil {
+		name += m.alternativeKey.suffix()
+	}
 	return ExportedIdentifier(name)
 }
 
-func (m *Method) restMethodFuncParams(def *Group, resourceSchema *RestliType) {
+func (m *Method) restMethodFuncParams(def *Group, r *Resource) {
+	resourceSchema := r.ResourceSchema
 	switch m.RestLiMethod() {
 	case protocol.Method_get:
 		m.addEntityTypes(def)
+		if !r.IsUnstructuredData {
+			addFieldsParam(def)
+		}
 	case protocol.Method_create:
 		m.addEntityTypes(def)
-		def.Id(CreateParam).Add(resourceSchema.PointerType())
+		if r.IsUnstructuredData {
+			def.Id(ContentTypeParam).String()
+			def.Id(UnstructuredDataParam).Qual("io", "Reader")
+		} else {
+			def.Id(CreateParam).Add(resourceSchema.PointerType())
+		}
 	case protocol.Method_update:
 		m.addEntityTypes(def)
 		def.Id(UpdateParam).Add(resourceSchema.PointerType())
 	case protocol.Method_partial_update:
 		m.addEntityTypes(def)
-		def.Id(PartialUpdateSetParam).Add(resourceSchema.PointerType())
-		def.Id(PartialUpdateDeleteParam).Add(Index().String())
+		def.Id(PartialUpdateParam).Add(resourceSchema.PatchType())
 	case protocol.Method_delete:
 		m.addEntityTypes(def)
+	case protocol.Method_batch_get:
+		m.addEntityTypes(def)
+		def.Id(BatchKeysParam).Index().Add(r.entityKey().Type.GoType())
+		addFieldsParam(def)
+	case protocol.Method_batch_partial_update:
+		m.addEntityTypes(def)
+		def.Id(BatchPatchesParam).Map(r.entityKey().Type.GoType()).Add(resourceSchema.PatchType())
 	}
 }
 
-func (m *Method) restMethodFuncReturnParams(def *Group) {
+func addFieldsParam(def *Group) {
+	def.Id(FieldsParam).Op("...").Qual(ProtocolPackage, "PathSpec")
+}
+
+func (m *Method) restMethodFuncReturnParams(def *Group, r *Resource) {
 	switch m.RestLiMethod() {
 	case protocol.Method_get:
-		def.Add(m.Return.PointerType())
+		if r.IsUnstructuredData {
+			def.Id("body").Qual("io", "ReadCloser")
+			def.Id(ContentTypeParam).String()
+			def.Err().Error()
+		} else {
+			def.Add(m.Return.PointerType())
+			def.Error()
+		}
+	case protocol.Method_batch_get:
+		def.Add(r.batchResultsType(m))
 		def.Error()
-	case protocol.Method_create:
+	case protocol.Method_batch_partial_update:
+		def.Add(r.batchStatusesType())
 		def.Error()
+	case protocol.Method_create:
+		if key := r.createdKey(); key != nil {
+			def.Id(key.Name).Add(key.Type.ReferencedType())
+			if key.Params != nil {
+				def.Id(key.paramsName()).Add(key.Params.PointerType())
+			}
+			def.Err().Error()
+		} else {
+			def.Error()
+		}
 	case protocol.Method_update:
 		def.Error()
 	case protocol.Method_partial_update:
@@ -67,6 +121,20 @@ func (m *Method) restMethodFuncReturnParams(def *Group) {
 
 // https://linkedin.github.io/rest.li/user_guide/restli_server#resource-methods
 func (r *Resource) GenerateRestMethodCode(m *Method) *Statement {
+	code := r.generateRestMethodCode(m)
+	if code == nil {
+		return nil
+	}
+	if doc := m.addDocComment(Empty()); len(*doc) > 0 {
+		return doc.Line().Add(code)
+	}
+	return code
+}
+
+func (r *Resource) generateRestMethodCode(m *Method) *Statement {
+	if r.IsUnstructuredData {
+		return r.generateUnstructuredDataMethod(m)
+	}
 	switch m.RestLiMethod() {
 	case protocol.Method_get:
 		return r.generateGet(m)
@@ -78,6 +146,10 @@ func (r *Resource) GenerateRestMethodCode(m *Method) *Statement {
 		return r.generatePartialUpdate(m)
 	case protocol.Method_delete:
 		return r.generateDelete(m)
+	case protocol.Method_batch_get:
+		return r.generateBatchGet(m)
+	case protocol.Method_batch_partial_update:
+		return r.generateBatchPartialUpdate(m)
 	default:
 		Logger.Printf("Warning: %s method is not currently implemented", m.Name)
 		return nil
@@ -85,57 +157,222 @@ func (r *Resource) GenerateRestMethodCode(m *Method) *Statement {
 }
 
 func (m *Method) callResourcePath(def *Group) {
-	if m.OnEntity {
-		def.List(Id(PathVar), Err()).Op(":=").Id(ResourceEntityPath).Call(m.entityParams()...)
-	} else {
-		def.List(Id(PathVar), Err()).Op(":=").Id(ResourcePath).Call(m.entityParams()...)
+	pathFunc := ResourcePath
+	if m.alternativeKey != nil {
+		pathFunc = ResourceEntityPath + m.alternativeKey.suffix()
+	} else if m.OnEntity {
+		pathFunc = ResourceEntityPath
 	}
+	m.callPathFunc(def, pathFunc)
+}
+
+// callPathFunc calls the variant of the given path function that encodes the keys with the client's codec
+func (m *Method) callPathFunc(def *Group, pathFunc string) {
+	def.List(Id(PathVar), Err()).Op(":=").Id(codecFuncName(pathFunc)).Call(append([]Code{clientCodec()}, m.entityParams()...)...)
 }
 
 func (r *Resource) generateGet(m *Method) *Statement {
 	def := Empty()
 	r.addClientFunc(def, m)
+	def.BlockFunc(func(def *Group) {
+		r.getRequest(def, m.funcName(), m, Nil(), Err())
+
+		// Pass the pointer as is, rather than a pointer to it, so that DoAndDecode can use its RestLiDecode
+		def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot(DoAndDecode).Call(Id(ReqVar), Id(DoAndDecodeResult))
+		IfErrReturn(def, Nil(), Err()).Line()
+		def.Return(Id(DoAndDecodeResult), Err())
+	})
+
+	return def
+}
+
+// getRequest builds the GET request of the given method and declares the entity it is decoded into
+func (r *Resource) getRequest(def *Group, name string, m *Method, errReturn ...Code) {
+	r.formatURL(def, m, errReturn...)
+
+	r.namedWithMethodTimeout(def, name, m, protocol.Method_get)
+	def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_get))
+	IfErrReturn(def, errReturn...).Line()
+
+	def.Id(DoAndDecodeResult).Op(":=").New(m.Return.GoType())
+}
+
+func (m *Method) rawGetFuncName() string {
+	return m.funcName() + RawFuncSuffix
+}
+
+func addRawGetDocComment(def *Statement, m *Method) *Statement {
+	return AddWordWrappedComment(def, m.rawGetFuncName()+" is like "+m.funcName()+", but also returns the raw "+
+		"body of the response, exactly as it was sent by the server")
+}
+
+func (r *Resource) rawGetFunc(m *Method) *Statement {
+	return Id(m.rawGetFuncName()).ParamsFunc(func(def *Group) {
+		def.Id(CtxVar).Qual("context", "Context")
+		m.restMethodFuncParams(def, r)
+	}).Params(m.Return.PointerType(), Index().Byte(), Error())
+}
+
+// generateRawGet generates the GetRaw method that accompanies each GET method when RawGet is set
+func (r *Resource) generateRawGet(m *Method) *Statement {
+	def := addRawGetDocComment(Empty(), m).Line()
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.rawGetFunc(m))
+
+	def.BlockFunc(func(def *Group) {
+		r.getRequest(def, m.rawGetFuncName(), m, Nil(), Nil(), Err())
+
+		def.List(Id(RawBodyVar), Err()).Op(":=").Id(ClientReceiver).Dot("DoAndDecodeWithBody").Call(Id(ReqVar), Id(DoAndDecodeResult))
+		IfErrReturn(def, Nil(), Nil(), Err()).Line()
+		def.Return(Id(DoAndDecodeResult), Id(RawBodyVar), Nil())
+	})
+
+	return def
+}
+
+func addExistsDocComment(def *Statement) *Statement {
+	return AddWordWrappedComment(def, ExistsFunc+" reports whether the entity exists. It sends a GET request with an "+
+		"empty projection, returning true for 2xx responses and false for 404 responses. Any other response is "+
+		"returned as an error")
+}
+
+func (r *Resource) existsFunc(m *Method) *Statement {
+	return Id(ExistsFunc).ParamsFunc(func(def *Group) {
+		def.Id(CtxVar).Qual("context", "Context")
+		m.addEntityTypes(def)
+	}).Params(Bool(), Error())
+}
+
+// generateExists generates the Exists method that accompanies each GET method. Rest.li does not route HEAD requests,
+// so a GET with a zero-field projection is sent instead, which spares the server from serializing the entity
+func (r *Resource) generateExists(m *Method) *Statement {
+	def := addExistsDocComment(Empty()).Line()
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.existsFunc(m))
 
 	def.BlockFunc(func(def *Group) {
 		m.callResourcePath(def)
-		IfErrReturn(def, Nil(), Err()).Line()
+		IfErrReturn(def, False(), Err())
+		def.Id(PathVar).Op("+=").Lit("?").Op("+").Qual(ProtocolPackage, "ExistsProjection")
 		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Nil(), Err()).Line()
+		IfErrReturn(def, False(), Err()).Line()
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_get))
-		IfErrReturn(def, Nil(), Err()).Line()
+		r.namedWithMethodTimeout(def, ExistsFunc, m, protocol.Method_get)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("GetRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_get))
+		IfErrReturn(def, False(), Err()).Line()
 
-		def.Id(DoAndDecodeResult).Op(":=").New(m.Return.GoType())
-		callDoAndDecode(def)
-		def.Return(Id(DoAndDecodeResult), Err())
+		def.Return(Id(ClientReceiver).Dot("DoAndCheckExists").Call(Id(ReqVar)))
 	})
 
 	return def
 }
 
+// createdKey returns the key of the entities created by this resource's CREATE method, if it can be parsed from the
+// response
+func (r *Resource) createdKey() *PathKey {
+	key := r.entityKey()
+	if key == nil || !key.isParseable() {
+		return nil
+	}
+	return key
+}
+
 func (r *Resource) generateCreate(m *Method) *Statement {
 	def := Empty()
 	r.addClientFunc(def, m)
 
-	def.BlockFunc(func(def *Group) {
-		m.callResourcePath(def)
-		IfErrReturn(def, Err()).Line()
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Err()).Line()
+	var newRequest *Statement
+	if r.IsUnstructuredData {
+		newRequest = Id(ClientReceiver).Dot("UnstructuredDataPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_create), Id(ContentTypeParam), Id(UnstructuredDataParam))
+	} else {
+		newRequest = Id(ClientReceiver).Dot("JsonPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_create), Id(CreateParam))
+	}
+	return def.Add(r.createBlock(m.funcName(), m, newRequest))
+}
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonPostRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_create), Id(CreateParam))
-		IfErrReturn(def, Err()).Line()
+// createBlock generates the body of the CREATE method with the given name, which sends the request returned by
+// newRequest
+func (r *Resource) createBlock(name string, m *Method, newRequest *Statement) *Statement {
+	key := r.createdKey()
+	// When the created key is returned, the results are named so that errors can be returned with a bare return
+	returnErr := func(def *Group) {
+		if key != nil {
+			IfErrReturn(def)
+		} else {
+			IfErrReturn(def, Err())
+		}
+	}
 
-		def.List(Id(ResVar), Err()).Op(":=").Id(ClientReceiver).Dot(DoAndDecode).Call(Id(ReqVar), Id(CreateParam))
-		IfErrReturn(def, Err()).Line()
+	return BlockFunc(func(def *Group) {
+		if key != nil {
+			r.formatURL(def, m)
+		} else {
+			r.formatURL(def, m, Err())
+		}
 
-		def.If(Id(ResVar).Dot("StatusCode").Op("/").Lit(100).Op("!=").Lit(2)).BlockFunc(func(def *Group) {
-			def.Return(Qual("fmt", "Errorf").Call(Lit("Invalid response code from %s: %d"), Id(UrlVar), Id(ResVar).Dot("StatusCode")))
-		})
-		def.Return(Nil())
+		if name == m.createStreamFuncName() {
+			// Streamed entities can take arbitrarily long to send, so they are only bound by the caller's context
+			r.withRequestInfo(def, name, m, protocol.Method_create)
+		} else {
+			r.namedWithMethodTimeout(def, name, m, protocol.Method_create)
+		}
+		def.List(Id(ReqVar), Err()).Op(":=").Add(newRequest)
+		returnErr(def)
+		def.Line()
+
+		// The created entity is only returned when explicitly requested, the response's body is otherwise empty
+		def.List(Id(ResVar), Err()).Op(":=").Id(ClientReceiver).Dot(DoAndIgnore).Call(Id(ReqVar))
+		returnErr(def)
+		def.Line()
+
+		invalidResponseCode := Qual("fmt", "Errorf").Call(Lit("Invalid response code from %s: %d"), Id(UrlVar), Id(ResVar).Dot("StatusCode"))
+		if key == nil {
+			def.If(Id(ResVar).Dot("StatusCode").Op("/").Lit(100).Op("!=").Lit(2)).Block(Return(invalidResponseCode))
+			def.Return(Nil())
+			return
+		}
+
+		def.If(Id(ResVar).Dot("StatusCode").Op("/").Lit(100).Op("!=").Lit(2)).Block(
+			Err().Op("=").Add(invalidResponseCode),
+			Return(),
+		).Line()
+
+		def.List(Id("id"), Err()).Op(":=").Qual(ProtocolPackage, "CreatedEntityID").Call(Id(ResVar))
+		returnErr(def)
+		if key.Params != nil {
+			def.Return(Id(codecFuncName(key.parseKeyWithParamsFunc())).Call(clientCodec(), Id("id")))
+		} else {
+			def.Return(Id(codecFuncName(key.parseKeyFunc())).Call(clientCodec(), Id("id")))
+		}
 	})
+}
 
-	return def
+func (m *Method) createStreamFuncName() string {
+	return m.funcName() + StreamFuncSuffix
+}
+
+func addCreateStreamDocComment(def *Statement, m *Method) *Statement {
+	return AddWordWrappedComment(def, m.createStreamFuncName()+" is like "+m.funcName()+", but streams the JSON "+
+		"encoding of the entity from the given reader with chunked transfer encoding instead of serializing it in "+
+		"memory first. protocol.JsonStream can be used to encode an entity into the request as it is sent. Unlike "+
+		m.funcName()+", the client's method timeout is not applied since streaming can take arbitrarily long, so the "+
+		"request is only bound by the given context")
+}
+
+func (r *Resource) createStreamFunc(m *Method) *Statement {
+	return Id(m.createStreamFuncName()).ParamsFunc(func(def *Group) {
+		def.Id(CtxVar).Qual("context", "Context")
+		m.addEntityTypes(def)
+		def.Id(CreateParam).Qual("io", "Reader")
+	}).ParamsFunc(func(def *Group) {
+		m.restMethodFuncReturnParams(def, r)
+	})
+}
+
+// generateCreateStream generates the CreateStream method that accompanies the CREATE method when CreateStream is set
+func (r *Resource) generateCreateStream(m *Method) *Statement {
+	def := addCreateStreamDocComment(Empty(), m).Line()
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.createStreamFunc(m))
+	newRequest := Id(ClientReceiver).Dot("JsonStreamPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_create), Id(CreateParam))
+	return def.Add(r.createBlock(m.createStreamFuncName(), m, newRequest))
 }
 
 func (r *Resource) generateUpdate(m *Method) *Statement {
@@ -143,12 +380,10 @@ func (r *Resource) generateUpdate(m *Method) *Statement {
 	r.addClientFunc(def, m)
 
 	def.BlockFunc(func(def *Group) {
-		m.callResourcePath(def)
-		IfErrReturn(def, Err()).Line()
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Err()).Line()
+		r.formatURL(def, m, Err())
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonPutRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_update), Id(UpdateParam))
+		r.withMethodTimeout(def, m, protocol.Method_update)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonUpdateRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_update), Id(UpdateParam))
 		IfErrReturn(def, Err()).Line()
 
 		def.List(Id(ResVar), Err()).Op(":=").Id(ClientReceiver).Dot(DoAndIgnore).Call(Id(ReqVar))
@@ -168,19 +403,12 @@ func (r *Resource) generatePartialUpdate(m *Method) *Statement {
 	r.addClientFunc(def, m)
 
 	def.BlockFunc(func(def *Group) {
-		m.callResourcePath(def)
-		IfErrReturn(def, Err()).Line()
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Err()).Line()
-
-		def.Id(PatchVar).Op(":=").Struct(
-			Id("Set").Add(m.Return.PointerType()).Tag(JsonFieldTag("$set", true)),
-			Id("Delete").Add(Index().String()).Tag(JsonFieldTag("$delete", true)),
-		).Values(Id(PartialUpdateSetParam), Id(PartialUpdateDeleteParam))
+		r.formatURL(def, m, Err())
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonPostRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_partial_update), Op("&").Struct(
-			Id("Patch").Add(Interface()).Tag(JsonFieldTag("patch", true)),
-		).Values(Id(PatchVar)))
+		r.withMethodTimeout(def, m, protocol.Method_partial_update)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("JsonPostRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_partial_update), Op("&").Struct(
+			Id("Patch").Add(m.Return.PatchType()).Tag(JsonFieldTag("patch", true)),
+		).Values(Id(PartialUpdateParam)))
 		IfErrReturn(def, Err()).Line()
 
 		def.List(Id(ResVar), Err()).Op(":=").Id(ClientReceiver).Dot(DoAndIgnore).Call(Id(ReqVar))
@@ -200,12 +428,10 @@ func (r *Resource) generateDelete(m *Method) *Statement {
 	r.addClientFunc(def, m)
 
 	def.BlockFunc(func(def *Group) {
-		m.callResourcePath(def)
-		IfErrReturn(def, Err()).Line()
-		r.callFormatQueryUrl(def)
-		IfErrReturn(def, Err()).Line()
+		r.formatURL(def, m, Err())
 
-		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("DeleteRequest").Call(Id(UrlVar), RestLiMethod(protocol.Method_update))
+		r.withMethodTimeout(def, m, protocol.Method_delete)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("DeleteRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_delete))
 		IfErrReturn(def, Err()).Line()
 
 		def.List(Id(ResVar), Err()).Op(":=").Id(ClientReceiver).Dot(DoAndIgnore).Call(Id(ReqVar))
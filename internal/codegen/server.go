@@ -0,0 +1,552 @@
+package codegen
+
+import (
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+const (
+	ServerType        = "Server"
+	ServerFile        = "server"
+	ServerPrefixField = "Prefix"
+	ServerCodecField  = "Codec"
+
+	serverWriter    = "w"
+	serverRequest   = "req"
+	serverKeys      = "keys"
+	serverResult    = "result"
+	serveFuncPrefix = "serve"
+)
+
+// ServerStubs makes each resource also generate a Server, an http.Handler that routes the requests sent by its Client
+// to handler funcs, for contract tests against an in-process rest.li server
+var ServerStubs bool
+
+func (r *Resource) serverReceiver() string {
+	return ReceiverName(ServerType)
+}
+
+// serverMethods returns the methods that the generated Server can route: the REST methods that have a client
+// implementation, the finders and the actions, as long as their keys and parameters can all be decoded from the request
+func (r *Resource) serverMethods() (methods []*Method) {
+	if r.IsUnstructuredData {
+		return nil
+	}
+	for _, m := range r.Methods {
+		var err error
+		switch m.MethodType {
+		case REST_METHOD:
+			switch m.RestLiMethod() {
+			case protocol.Method_get, protocol.Method_create, protocol.Method_update, protocol.Method_partial_update,
+				protocol.Method_delete:
+			case protocol.Method_batch_get, protocol.Method_batch_partial_update:
+				err = r.checkBatchKeys()
+			default:
+				continue
+			}
+		case FINDER:
+			err = checkServerFinder(m)
+		case ACTION:
+		default:
+			continue
+		}
+
+		if err == nil {
+			err = checkServerKeys(m)
+		}
+		if err != nil {
+			Logger.Printf("Warning: %s of %s cannot be routed by the generated server: %s", m.funcName(), r.Namespace, err)
+			continue
+		}
+		methods = append(methods, m)
+	}
+	return methods
+}
+
+// checkServerFinder returns an error if the parameters of the given finder cannot be decoded from the query of its
+// requests. Finders on association keys are not supported either, since their path only holds part of the key
+func checkServerFinder(m *Method) error {
+	if m.finderAssocKey() != nil {
+		return errors.New("go-restli: Finders on association keys are not supported")
+	}
+	for _, p := range m.Params {
+		if _, err := p.Type.RestLiURLDecodeModel(Id(p.Name), Id("s")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkServerKeys(m *Method) error {
+	for _, pk := range m.PathKeys {
+		if _, err := pk.Type.RestLiURLDecodeModel(Id(pk.Name), Id("s")); err != nil {
+			return err
+		}
+		if pk.Params != nil {
+			if _, err := pk.Params.RestLiURLDecodeModel(Id(pk.paramsName()), Id("s")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Method) serveFuncName() string {
+	return serveFuncPrefix + m.funcName()
+}
+
+// GenerateServerCode generates the Server of this resource, see ServerStubs. It returns nil if none of the resource's
+// methods can be routed.
+func (r *Resource) GenerateServerCode() *CodeFile {
+	methods := r.serverMethods()
+	if len(methods) == 0 {
+		return nil
+	}
+
+	c := r.NewCodeFile(ServerFile)
+	receiver := r.serverReceiver()
+
+	AddWordWrappedComment(c.Code, ServerType+" is an in-process rest.li server for this resource, meant for contract "+
+		"tests: it routes the requests sent by "+ClientInterfaceType+" to the handler of the corresponding method, "+
+		"decoding keys and bodies and encoding results the same way the client does. Each handler has the same "+
+		"signature as the client method, such that any "+ClientInterfaceType+" implementation can serve the requests. "+
+		"The requests of methods that have no handler fail with 501 Not Implemented, as do those of the finders on "+
+		"association keys and of the finders whose parameters cannot be read from the query, which have no handler "+
+		"field. Handlers can return a "+
+		"*protocol.RestLiError to control the status of the response, and batch handlers a *protocol.BatchError keyed "+
+		"by the encoded keys to report the keys that failed.").Line()
+	c.Code.Type().Id(ServerType).StructFunc(func(def *Group) {
+		AddWordWrappedComment(def.Empty(), ServerPrefixField+" is the path the server is mounted under, if any, e.g. "+
+			"the path of the client's hostname. Requests are only routed when their path is made of exactly this "+
+			"prefix followed by the path of one of the resource's methods")
+		def.Id(ServerPrefixField).String()
+		AddWordWrappedComment(def.Empty(), ServerCodecField+" decodes the keys of the requests and encodes the keys of "+
+			"the responses, it must match the Codec of the clients that send the requests (see protocol.ServerUrlCodec)")
+		def.Id(ServerCodecField).Qual(ProtocolPackage, RestLiCodec)
+		for _, m := range methods {
+			params, returnParams := r.clientFuncParams(m)
+			def.Commentf("%s handles the requests sent by %s.%s", m.funcName(), ClientInterfaceType, m.funcName())
+			def.Id(m.funcName()).Func().ParamsFunc(func(def *Group) {
+				def.Id(CtxVar).Qual("context", "Context")
+				params(def)
+			}).ParamsFunc(returnParams)
+		}
+	}).Line().Line()
+
+	c.Code.Var().Id("_").Qual("net/http", "Handler").Op("=").Parens(Op("*").Id(ServerType)).Call(Nil()).Line().Line()
+
+	r.generateServeHTTP(c.Code, receiver, methods)
+	for _, m := range methods {
+		r.generateServeFunc(c.Code, receiver, m)
+	}
+
+	return c
+}
+
+// generateServeHTTP generates the ServeHTTP function of the Server, which matches the request's path against the path
+// of each method then dispatches on the rest.li method, and the name of the action or finder for actions and finders
+func (r *Resource) generateServeHTTP(def *Statement, receiver string, methods []*Method) {
+	var paths []string
+	methodsByPath := make(map[string][]*Method)
+	for _, m := range methods {
+		if _, ok := methodsByPath[m.Path]; !ok {
+			paths = append(paths, m.Path)
+		}
+		methodsByPath[m.Path] = append(methodsByPath[m.Path], m)
+	}
+
+	serve := func(m *Method) Code {
+		return Id(receiver).Dot(m.serveFuncName()).Call(Id(serverWriter), Id(serverRequest), Id(serverKeys))
+	}
+
+	def.Comment("ServeHTTP routes the request to the handler of the method it was sent for").Line()
+	AddFuncOnReceiver(def, receiver, ServerType, "ServeHTTP").
+		Params(Id(serverWriter).Qual("net/http", "ResponseWriter"), Id(serverRequest).Op("*").Qual("net/http", "Request")).
+		BlockFunc(func(def *Group) {
+			def.Qual(ProtocolPackage, "SetServerProtocolVersion").Call(Id(serverWriter), Id(serverRequest))
+			def.Id(PathVar).Op(":=").Id(serverRequest).Dot("URL").Dot("EscapedPath").Call()
+			def.Id("method").Op(":=").Qual(ProtocolPackage, "ServerRequestMethod").Call(Id(serverRequest))
+			for _, path := range paths {
+				def.If(
+					List(Id(serverKeys), Id("ok")).Op(":=").Qual(ProtocolPackage, "MatchPathTemplate").Call(Id(receiver).Dot(ServerPrefixField), Lit(path), Id(PathVar)),
+					Id("ok"),
+				).BlockFunc(func(def *Group) {
+					def.Switch(Id("method")).BlockFunc(func(def *Group) {
+						var actions, finders []*Method
+						for _, m := range methodsByPath[path] {
+							switch m.MethodType {
+							case ACTION:
+								actions = append(actions, m)
+							case FINDER:
+								finders = append(finders, m)
+							default:
+								def.Case(RestLiMethod(m.RestLiMethod())).Block(serve(m), Return())
+							}
+						}
+						if len(finders) > 0 {
+							def.Case(RestLiMethod(protocol.Method_finder)).Block(
+								Switch(Id(serverRequest).Dot("URL").Dot("Query").Call().Dot("Get").Call(Qual(ProtocolPackage, "FinderParam"))).BlockFunc(func(def *Group) {
+									for _, m := range finders {
+										def.Case(Id(m.finderFuncName())).Block(serve(m), Return())
+									}
+								}),
+							)
+						}
+						if len(actions) > 0 {
+							def.Case(RestLiMethod(protocol.Method_action)).Block(
+								Switch(Id(serverRequest).Dot("URL").Dot("Query").Call().Dot("Get").Call(Qual(ProtocolPackage, "ActionParam"))).BlockFunc(func(def *Group) {
+									for _, m := range actions {
+										def.Case(Id(m.actionNameConst())).Block(serve(m), Return())
+									}
+								}),
+							)
+						}
+					})
+					def.Qual(ProtocolPackage, "WriteErrorResponse").Call(Id(serverWriter),
+						Qual(ProtocolPackage, "NotImplementedError").Call(Id("ResourceName"), Id("method").Dot("String").Call()))
+					def.Return()
+				})
+			}
+			def.Qual(ProtocolPackage, "WriteErrorResponse").Call(Id(serverWriter), Op("&").Qual(ProtocolPackage, "RestLiError").Values(Dict{
+				Id("Status"):  Qual("net/http", "StatusNotFound"),
+				Id("Message"): Lit("go-restli: Unknown path ").Op("+").Id(PathVar),
+			}))
+		}).Line().Line()
+}
+
+// generateServeFunc generates the function that serves the requests of the given method, once they are routed to it by
+// ServeHTTP
+func (r *Resource) generateServeFunc(def *Statement, receiver string, m *Method) {
+	writeError := func(err Code) Code {
+		return Block(
+			Qual(ProtocolPackage, "WriteErrorResponse").Call(Id(serverWriter), err),
+			Return(),
+		)
+	}
+	badRequest := writeError(Qual(ProtocolPackage, "BadRequestError").Call(Err()))
+
+	def.Commentf("%s serves the requests routed to %s by ServeHTTP", m.serveFuncName(), m.funcName()).Line()
+	AddFuncOnReceiver(def, receiver, ServerType, m.serveFuncName()).
+		Params(
+			Id(serverWriter).Qual("net/http", "ResponseWriter"),
+			Id(serverRequest).Op("*").Qual("net/http", "Request"),
+			Id(serverKeys).Map(String()).String(),
+		).
+		BlockFunc(func(def *Group) {
+			def.If(Id(receiver).Dot(m.funcName()).Op("==").Nil()).Add(
+				writeError(Qual(ProtocolPackage, "NotImplementedError").Call(Id("ResourceName"), Lit(m.funcName()))),
+			).Line()
+
+			def.Var().Err().Error()
+			if r.serveFuncUsesCodec(m) {
+				def.Id(Codec).Op(":=").Qual(ProtocolPackage, "ServerUrlCodec").Call(Id(receiver).Dot(ServerCodecField), Id(serverRequest))
+			}
+			args := []Code{Id(serverRequest).Dot("Context").Call()}
+			for _, pk := range m.PathKeys {
+				decodeServerKey(def, pk, badRequest)
+				args = append(args, Id(pk.Name))
+				if pk.Params != nil {
+					args = append(args, Id(pk.paramsName()))
+				}
+			}
+
+			decodeBody := func(v Code) {
+				def.Err().Op("=").Qual(ProtocolPackage, "DecodeRequest").Call(Id(serverRequest), v)
+				def.If(Err().Op("!=").Nil()).Add(badRequest).Line()
+			}
+			call := Id(receiver).Dot(m.funcName())
+			handlerError := If(Err().Op("!=").Nil()).Add(writeError(Err()))
+			respond := func(status string, v Code) Code {
+				return Qual(ProtocolPackage, "WriteResponse").Call(Id(serverWriter), Qual("net/http", status), v)
+			}
+
+			if m.MethodType == ACTION {
+				if len(m.Params) > 0 {
+					def.Id("params").Op(":=").New(Id(m.actionStructType()))
+					decodeBody(Id("params"))
+					args = append(args, Id("params"))
+				}
+				if m.Return == nil {
+					def.Err().Op("=").Add(call).Call(args...)
+					def.Add(handlerError)
+					def.Add(respond("StatusOK", Nil()))
+					return
+				}
+				def.List(Id(serverResult), Err()).Op(":=").Add(call).Call(args...)
+				def.Add(handlerError)
+				def.Add(respond("StatusOK", Struct(
+					Id("Value").Add(m.Return.PointerType()).Tag(JsonFieldTag("value", false)),
+				).Values(Id(serverResult))))
+				return
+			}
+
+			if m.MethodType == FINDER {
+				r.serveFinder(def, receiver, m, call.Call(append(args, Id("params"))...), handlerError, writeError)
+				return
+			}
+
+			switch m.RestLiMethod() {
+			case protocol.Method_get:
+				def.List(Id(FieldsParam), Err()).Op(":=").Qual(ProtocolPackage, "DecodeProjection").Call(
+					Id(serverRequest).Dot("URL").Dot("Query").Call().Dot("Get").Call(Qual(ProtocolPackage, "FieldsParam")))
+				def.If(Err().Op("!=").Nil()).Add(badRequest).Line()
+				def.List(Id(serverResult), Err()).Op(":=").Add(call).Call(append(args, Id(FieldsParam).Op("..."))...)
+				def.Add(handlerError)
+				def.Add(respond("StatusOK", Id(serverResult)))
+			case protocol.Method_create:
+				def.Id(CreateParam).Op(":=").New(r.ResourceSchema.GoType())
+				decodeBody(Id(CreateParam))
+				r.serveCreate(def, m, call.Call(append(args, Id(CreateParam))...), handlerError, writeError)
+			case protocol.Method_update:
+				def.Id(UpdateParam).Op(":=").New(r.ResourceSchema.GoType())
+				decodeBody(Id(UpdateParam))
+				def.Err().Op("=").Add(call).Call(append(args, Id(UpdateParam))...)
+				def.Add(handlerError)
+				def.Add(respond("StatusNoContent", Nil()))
+			case protocol.Method_partial_update:
+				def.Var().Id("body").Struct(
+					Id("Patch").Add(r.ResourceSchema.PatchType()).Tag(JsonFieldTag("patch", false)),
+				)
+				decodeBody(Op("&").Id("body"))
+				def.Err().Op("=").Add(call).Call(append(args, Id("body").Dot("Patch"))...)
+				def.Add(handlerError)
+				def.Add(respond("StatusNoContent", Nil()))
+			case protocol.Method_delete:
+				def.Err().Op("=").Add(call).Call(args...)
+				def.Add(handlerError)
+				def.Add(respond("StatusNoContent", Nil()))
+			case protocol.Method_batch_get:
+				def.List(Id(BatchIdsParam), Err()).Op(":=").Qual(ProtocolPackage, "DecodeBatchIds").Call(Id(Codec), Id(serverRequest))
+				def.If(Err().Op("!=").Nil()).Add(badRequest)
+				def.Id("batchKeys").Op(":=").Make(Index().Add(r.entityKey().Type.GoType()), Lit(0), Len(Id(BatchIdsParam)))
+				def.For(List(Id("_"), Id("id")).Op(":=").Range().Id(BatchIdsParam)).BlockFunc(func(def *Group) {
+					r.parseServerBatchKey(def, Id("id"), badRequest)
+					def.Id("batchKeys").Op("=").Append(Id("batchKeys"), Id("key"))
+				}).Line()
+
+				def.List(Id(FieldsParam), Err()).Op(":=").Qual(ProtocolPackage, "DecodeProjection").Call(
+					Id(serverRequest).Dot("URL").Dot("Query").Call().Dot("Get").Call(Qual(ProtocolPackage, "FieldsParam")))
+				def.If(Err().Op("!=").Nil()).Add(badRequest).Line()
+
+				def.List(Id(serverResult), Err()).Op(":=").Add(call).Call(append(args, Id("batchKeys"), Id(FieldsParam).Op("..."))...)
+				r.writeBatchResponse(def, m.Return.PointerType(), func(v Code) Code { return v }, writeError)
+			case protocol.Method_batch_partial_update:
+				def.Var().Id("body").Struct(
+					Id("Entities").Map(String()).Struct(
+						Id("Patch").Add(r.ResourceSchema.PatchType()).Tag(JsonFieldTag("patch", false)),
+					).Tag(JsonFieldTag("entities", false)),
+				)
+				decodeBody(Op("&").Id("body"))
+				def.Id(BatchPatchesParam).Op(":=").Make(Map(r.entityKey().Type.GoType()).Add(r.ResourceSchema.PatchType()), Len(Id("body").Dot("Entities")))
+				def.For(List(Id("id"), Id("entity")).Op(":=").Range().Id("body").Dot("Entities")).BlockFunc(func(def *Group) {
+					r.parseServerBatchKey(def, Id("id"), badRequest)
+					def.Id(BatchPatchesParam).Index(Id("key")).Op("=").Id("entity").Dot("Patch")
+				}).Line()
+
+				def.List(Id(serverResult), Err()).Op(":=").Add(call).Call(append(args, Id(BatchPatchesParam))...)
+				status := Struct(Id("Status").Int().Tag(JsonFieldTag("status", false)))
+				r.writeBatchResponse(def, status, func(v Code) Code {
+					return status.Clone().Values(Dict{Id("Status"): v})
+				}, writeError)
+			}
+		}).Line().Line()
+}
+
+// serveFuncUsesCodec returns whether the serve func of the given method needs the codec of the request, i.e. whether
+// it decodes or encodes any key
+func (r *Resource) serveFuncUsesCodec(m *Method) bool {
+	if len(m.PathKeys) > 0 {
+		return true
+	}
+	if m.MethodType != REST_METHOD {
+		return false
+	}
+	switch m.RestLiMethod() {
+	case protocol.Method_batch_get, protocol.Method_batch_partial_update:
+		return true
+	case protocol.Method_create:
+		return r.createdKey() != nil
+	default:
+		return false
+	}
+}
+
+// serveFinder decodes the parameters of the given finder from the query of the request, calls its handler and writes
+// the found elements, alongside their metadata and paging if the handler returns them
+func (r *Resource) serveFinder(def *Group, receiver string, m *Method, call *Statement, handlerError Code, writeError func(Code) Code) {
+	def.Id("params").Op(":=").New(Id(m.finderStructType()))
+	if len(m.Params) > 0 {
+		// The parameters are unescaped by Query, what remains is the reduced encoding, see EncodeFinderParams
+		def.Id("reducedCodec").Op(":=").Qual(ProtocolPackage, "ServerReducedCodec").Call(Id(receiver).Dot(ServerCodecField), Id(serverRequest))
+		def.Id("query").Op(":=").Id(serverRequest).Dot("URL").Dot("Query").Call()
+	}
+	for _, p := range m.Params {
+		accessor := Id("params").Dot(ExportedIdentifier(p.Name))
+		value := Id("values").Index(Lit(0))
+		var decoder *Statement
+		if p.IsPointer() && p.Type.Primitive != nil {
+			// Like the records, optional primitives are decoded straight into the newly allocated value
+			decoder = Id("reducedCodec").Dot("Decode"+ExportedIdentifier(p.Type.Primitive.Type)).Call(value, accessor)
+		} else {
+			decoder, _ = p.Type.RestLiDecodeModel(Id("reducedCodec"), accessor, value)
+		}
+
+		decode := If(List(Id("values"), Id("ok")).Op(":=").Id("query").Index(Lit(p.Name)), Id("ok")).BlockFunc(func(def *Group) {
+			if p.IsPointer() {
+				def.Add(accessor).Op("=").New(p.Type.GoType())
+			}
+			def.Err().Op("=").Add(decoder)
+			def.If(Err().Op("!=").Nil()).Add(writeError(Qual(ProtocolPackage, "BadRequestError").Call(Err())))
+		})
+		if !p.IsOptional {
+			decode.Else().Add(writeError(Qual(ProtocolPackage, "BadRequestError").Call(Qual("fmt", "Errorf").Call(
+				Lit("go-restli: Missing required parameter " + p.Name + " of the " + m.Name + " finder")))))
+		}
+		def.Add(decode)
+	}
+	def.Line()
+
+	results := []Code{Id(serverResult)}
+	fields := []Code{Id("Elements").Add(m.finderReturnType()).Tag(JsonFieldTag("elements", false))}
+	values := Dict{Id("Elements"): Id(serverResult)}
+	if m.Metadata != nil {
+		results = append(results, Id("metadata"))
+		fields = append(fields, Id("Metadata").Add(m.Metadata.PointerType()).Tag(JsonFieldTag("metadata", true)))
+		values[Id("Metadata")] = Id("metadata")
+	}
+	if FinderPaging {
+		results = append(results, Id("paging"))
+		fields = append(fields, Id("Paging").Op("*").Qual(ProtocolPackage, "CollectionPaging").Tag(JsonFieldTag("paging", true)))
+		values[Id("Paging")] = Id("paging")
+	}
+	def.List(append(results, Err())...).Op(":=").Add(call)
+	def.Add(handlerError)
+	def.Qual(ProtocolPackage, "WriteResponse").Call(Id(serverWriter), Qual("net/http", "StatusOK"), Struct(fields...).Values(values))
+}
+
+// parseServerBatchKey parses the given encoded key of a batch request into the key variable, dropping its params if
+// it has any
+func (r *Resource) parseServerBatchKey(def *Group, encodedKey Code, badRequest Code) {
+	key := r.entityKey()
+	parsed := Id("key")
+	if key.Type.IsReferencedByPointer() {
+		parsed = Id("parsedKey")
+	}
+	if key.Params != nil {
+		def.List(parsed, Id("_"), Err()).Op(":=").Id(codecFuncName(key.parseKeyWithParamsFunc())).Call(Id(Codec), encodedKey)
+	} else {
+		def.List(parsed, Err()).Op(":=").Id(codecFuncName(key.parseKeyFunc())).Call(Id(Codec), encodedKey)
+	}
+	def.If(Err().Op("!=").Nil()).Add(badRequest)
+	if key.Type.IsReferencedByPointer() {
+		def.Id("key").Op(":=").Op("*").Id("parsedKey")
+	}
+}
+
+// writeBatchResponse writes the results returned by a batch handler, keyed by their encoded key, alongside the errors
+// of the *protocol.BatchError it may have returned. value converts each result into the given type
+func (r *Resource) writeBatchResponse(def *Group, valueType Code, value func(v Code) Code, writeError func(Code) Code) {
+	def.List(Id("batchError"), Id("ok")).Op(":=").Err().Assert(Op("*").Qual(ProtocolPackage, "BatchError"))
+	def.If(Err().Op("!=").Nil().Op("&&").Op("!").Id("ok")).Add(writeError(Err())).Line()
+
+	def.Id("response").Op(":=").Struct(
+		Id("Results").Map(String()).Add(valueType).Tag(JsonFieldTag("results", false)),
+		Id("Errors").Map(String()).Op("*").Qual(ProtocolPackage, "RestLiError").Tag(JsonFieldTag("errors", true)),
+	).Values(Dict{Id("Results"): Make(Map(String()).Add(valueType), Len(Id(serverResult)))})
+	def.For(List(Id("k"), Id("v")).Op(":=").Range().Id(serverResult)).BlockFunc(func(def *Group) {
+		// Complex keys are already encoded, see BatchKey
+		if r.hasEncodedBatchKeys() {
+			def.Id("response").Dot("Results").Index(Id("k")).Op("=").Add(value(Id("v")))
+			return
+		}
+		encoder, hasError, _ := r.entityKey().Type.RestLiCodecEncodeModel(Id("k"))
+		if hasError {
+			def.List(Id("encodedKey"), Err()).Op(":=").Add(encoder)
+			def.If(Err().Op("!=").Nil()).Add(writeError(Err()))
+		} else {
+			def.Id("encodedKey").Op(":=").Add(encoder)
+		}
+		def.Id("response").Dot("Results").Index(Id("encodedKey")).Op("=").Add(value(Id("v")))
+	})
+	def.If(Id("ok")).Block(Id("response").Dot("Errors").Op("=").Id("batchError").Dot("Errors"))
+	def.Qual(ProtocolPackage, "WriteResponse").Call(Id(serverWriter), Qual("net/http", "StatusOK"), Op("&").Id("response"))
+}
+
+// decodeServerKey decodes the given key, and its params if it has any, from the keys matched by ServeHTTP, with the codec
+// of the request
+func decodeServerKey(def *Group, pk PathKey, badRequest Code) {
+	encoded := Id(serverKeys).Index(Lit(pk.Name))
+	encodedParams := "encoded" + ExportedIdentifier(pk.paramsName())
+	if pk.Params != nil {
+		encodedKey := "encoded" + ExportedIdentifier(pk.Name)
+		def.List(Id(encodedKey), Id(encodedParams), Err()).Op(":=").
+			Qual(ProtocolPackage, "SplitComplexKey").Call(Id(Codec), encoded)
+		def.If(Err().Op("!=").Nil()).Add(badRequest)
+		encoded = Id(encodedKey)
+	}
+
+	decoder, _ := pk.Type.RestLiCodecDecodeModel(Id(pk.Name), encoded)
+	// Enums and fixed types are passed around as pointers, so they need to be allocated first
+	if pk.Type.IsReferencedByPointer() {
+		def.Id(pk.Name).Op(":=").New(pk.Type.GoType())
+	} else {
+		def.Var().Id(pk.Name).Add(pk.Type.ReferencedType())
+	}
+	def.Err().Op("=").Add(decoder)
+	def.If(Err().Op("!=").Nil()).Add(badRequest)
+
+	if pk.Params != nil {
+		paramsDecoder, _ := pk.Params.RestLiCodecDecodeModel(Id(pk.paramsName()), Id(encodedParams))
+		def.Var().Id(pk.paramsName()).Add(pk.Params.PointerType())
+		def.If(Id(encodedParams).Op("!=").Lit("")).Block(
+			Id(pk.paramsName()).Op("=").New(pk.Params.GoType()),
+			Err().Op("=").Add(paramsDecoder),
+			If(Err().Op("!=").Nil()).Add(badRequest),
+		)
+	}
+	def.Line()
+}
+
+// serveCreate calls the CREATE handler and returns the key of the created entity, if any, in the RestLiHeader_ID
+// header of a 201 Created response
+func (r *Resource) serveCreate(def *Group, m *Method, call *Statement, handlerError Code, writeError func(Code) Code) {
+	created := Qual(ProtocolPackage, "WriteResponse").Call(Id(serverWriter), Qual("net/http", "StatusCreated"), Nil())
+	key := r.createdKey()
+	if key == nil {
+		def.Err().Op("=").Add(call)
+		def.Add(handlerError)
+		def.Add(created)
+		return
+	}
+
+	results := []Code{Id(key.Name)}
+	if key.Params != nil {
+		results = append(results, Id(key.paramsName()))
+	}
+	def.List(append(results, Err())...).Op(":=").Add(call)
+	def.Add(handlerError).Line()
+
+	encodedKey := "encoded" + ExportedIdentifier(key.Name)
+	encoder, hasError, _ := key.Type.RestLiCodecEncodeModel(Id(key.Name))
+	if hasError {
+		def.List(Id(encodedKey), Err()).Op(":=").Add(encoder)
+		def.If(Err().Op("!=").Nil()).Add(writeError(Err()))
+	} else {
+		def.Id(encodedKey).Op(":=").Add(encoder)
+	}
+	if key.Params != nil {
+		paramsEncoder, paramsHasError, _ := key.Params.RestLiCodecEncodeModel(Id(key.paramsName()))
+		def.If(Id(key.paramsName()).Op("!=").Nil()).BlockFunc(func(def *Group) {
+			if paramsHasError {
+				def.List(Id("params"), Err()).Op(":=").Add(paramsEncoder)
+				def.If(Err().Op("!=").Nil()).Add(writeError(Err()))
+			} else {
+				def.Id("params").Op(":=").Add(paramsEncoder)
+			}
+			def.Id(encodedKey).Op("=").Qual(ProtocolPackage, "EncodeComplexKey").Call(Id(encodedKey), Id("params"))
+		})
+	}
+	def.Id(serverWriter).Dot("Header").Call().Dot("Set").Call(Qual(ProtocolPackage, "RestLiHeader_ID"), Id(encodedKey))
+	def.Add(created)
+}
@@ -0,0 +1,137 @@
+package codegen
+
+import (
+	"sort"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TimeTarget generates a typeref to long as a time.Time, encoded as the number of milliseconds since the epoch
+	TimeTarget = "time.Time"
+	// Int64Target generates a typeref to long as an int64, like any other primitive typeref
+	Int64Target = "int64"
+)
+
+// WellKnownTimeTyperefs are the typerefs to long that are known to hold the number of milliseconds since the epoch,
+// which are generated as a time.Time unless TimeTyperefs says otherwise
+var WellKnownTimeTyperefs = []string{
+	"com.linkedin.common.Time",
+}
+
+// TimeTyperefs maps typerefs to long (e.g. com.example.Timestamp) to the type they are generated as, either TimeTarget
+// or Int64Target. It takes precedence over WellKnownTimeTyperefs
+var TimeTyperefs map[string]string
+
+// registerTimeTyperefs flags the typerefs that are generated as a time.Time, see TimeTyperefs and WellKnownTimeTyperefs
+func (reg typeRegistry) registerTimeTyperefs() error {
+	targets := make(map[Identifier]string)
+	for _, fqn := range WellKnownTimeTyperefs {
+		id := timeTyperefIdentifier(fqn)
+		// Well-known typerefs are only coerced if they are used, and if they are what they are expected to be
+		if t, ok := reg[id]; ok {
+			if typeref, ok := t.Type.(*Typeref); ok && typeref.isLong() {
+				targets[id] = TimeTarget
+			}
+		}
+	}
+
+	var fqns []string
+	for fqn := range TimeTyperefs {
+		fqns = append(fqns, fqn)
+	}
+	sort.Strings(fqns)
+	for _, fqn := range fqns {
+		target := TimeTyperefs[fqn]
+		if target != TimeTarget && target != Int64Target {
+			return errors.Errorf("go-restli: Illegal target type %q for typeref %s (must be either %s or %s)",
+				target, fqn, TimeTarget, Int64Target)
+		}
+		id := timeTyperefIdentifier(fqn)
+		t, ok := reg[id]
+		if !ok {
+			return errors.Errorf("go-restli: Unknown time typeref %s", fqn)
+		}
+		typeref, ok := t.Type.(*Typeref)
+		if !ok || !typeref.isLong() {
+			return errors.Errorf("go-restli: %s is not a typeref to long and cannot be generated as a %s", fqn, target)
+		}
+		targets[id] = target
+	}
+
+	for id, target := range targets {
+		reg[id].Type.(*Typeref).isTime = target == TimeTarget
+	}
+	return nil
+}
+
+func timeTyperefIdentifier(fqn string) Identifier {
+	idx := strings.LastIndex(fqn, ".")
+	if idx < 0 {
+		return Identifier{Name: fqn}
+	}
+	return Identifier{Namespace: fqn[:idx], Name: fqn[idx+1:]}
+}
+
+func (r *Typeref) isLong() bool {
+	return r.Ref.Primitive != nil && r.Ref.Primitive.Type == "int64"
+}
+
+// generateTimeCode generates a typeref flagged by registerTimeTyperefs as a time.Time, which is encoded as the number
+// of milliseconds since the epoch both in JSON and in the rest.li encoding. MarshalJSON and MarshalText are declared on
+// the value so that the encoding is the same in maps and non-addressable fields.
+func (r *Typeref) generateTimeCode() *Statement {
+	def := Empty()
+	receiver := r.Receiver()
+	millis := func() *Statement {
+		return Qual("time", "Time").Call(Op("*").Id(receiver)).Dot("UnixMilli").Call()
+	}
+	fromMillis := Op("*").Id(receiver).Op("=").Id(r.Name).Call(Qual("time", "UnixMilli").Call(Id("millis")))
+
+	AddDocComment(def, r.Doc, r.Deprecated).Line()
+	def.Commentf("%s is encoded as the number of milliseconds since the epoch", r.Name).Line()
+	def.Type().Id(r.Name).Qual("time", "Time").Line().Line()
+
+	AddRestLiEncode(def, receiver, r.Name, func(def *Group) {
+		def.Return(Id(Codec).Dot("EncodeInt64").Call(millis()), Nil())
+	}).Line().Line()
+	AddRestLiDecode(def, receiver, r.Name, func(def *Group) {
+		def.Var().Id("millis").Int64()
+		def.Err().Op("=").Id(Codec).Dot("DecodeInt64").Call(Id("data"), Op("&").Id("millis"))
+		IfErrReturn(def)
+		def.Add(fromMillis)
+		def.Return(Nil())
+	}).Line().Line()
+
+	def.Func().Params(Id(receiver).Id(r.Name)).Id(MarshalJSON).Params().Params(Index().Byte(), Error()).Block(
+		Return(Qual(JsonPackage, Marshal).Call(Qual("time", "Time").Call(Id(receiver)).Dot("UnixMilli").Call())),
+	).Line().Line()
+	AddUnmarshalJSON(def, receiver, r.Name, func(def *Group) {
+		def.Var().Id("millis").Int64()
+		def.Err().Op("=").Qual(JsonPackage, Unmarshal).Call(Id("data"), Op("&").Id("millis"))
+		IfErrReturn(def)
+		def.Add(fromMillis)
+		def.Return(Nil())
+	}).Line().Line()
+
+	// encoding/json relies on MarshalText and UnmarshalText to encode the keys of maps
+	def.Func().Params(Id(receiver).Id(r.Name)).Id("MarshalText").Params().Params(Index().Byte(), Error()).Block(
+		Return(Index().Byte().Call(Qual("strconv", "FormatInt").Call(
+			Qual("time", "Time").Call(Id(receiver)).Dot("UnixMilli").Call(), Lit(10))), Nil()),
+	).Line().Line()
+	AddFuncOnReceiver(def, receiver, r.Name, "UnmarshalText").Params(Id("data").Index().Byte()).Params(Err().Error()).
+		BlockFunc(func(def *Group) {
+			def.List(Id("millis"), Err()).Op(":=").Qual("strconv", "ParseInt").Call(String().Call(Id("data")), Lit(10), Lit(64))
+			IfErrReturn(def)
+			def.Add(fromMillis)
+			def.Return(Nil())
+		}).Line().Line()
+
+	AddStringer(def, receiver, r.Name, func(def *Group) {
+		def.Return(Qual("time", "Time").Call(Op("*").Id(receiver)).Dot("String").Call())
+	}).Line().Line()
+
+	return def
+}
@@ -1,7 +1,9 @@
 package codegen
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 
 	. "github.com/dave/jennifer/jen"
 	"github.com/pkg/errors"
@@ -11,6 +13,7 @@ type NamedType struct {
 	Identifier
 	SourceFile string
 	Doc        string
+	Deprecated *string
 }
 
 func (t *NamedType) GetSourceFile() string {
@@ -22,7 +25,10 @@ type RestliType struct {
 	Reference *Identifier
 	Array     *RestliType
 	Map       *RestliType
-	Union     *UnionType
+	// MapKey is the typeref declared as the map's key type, if any. Keys are always strings on the wire, but a typeref
+	// lets them be coerced into a more specific type
+	MapKey *RestliType
+	Union  *UnionType
 }
 
 func (t *RestliType) UnmarshalJSON(data []byte) error {
@@ -32,10 +38,18 @@ func (t *RestliType) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if t.MapKey != nil {
+		if t.Map == nil || t.MapKey.Reference == nil {
+			return errors.Errorf("go-restli: Map keys can only be typerefs (%s)", string(data))
+		}
+		mapKeys = append(mapKeys, t.MapKey)
+	}
+
 	switch {
 	case t.Primitive != nil:
 		return nil
 	case t.Reference != nil:
+		typeReferences = append(typeReferences, t.Reference)
 		return nil
 	case t.Array != nil:
 		return nil
@@ -59,7 +73,14 @@ func (t *RestliType) InnerTypes() IdentifierSet {
 	case t.Array != nil:
 		return t.Array.InnerTypes()
 	case t.Map != nil:
-		return t.Map.InnerTypes()
+		innerTypes := t.Map.InnerTypes()
+		if t.MapKey != nil {
+			if innerTypes == nil {
+				innerTypes = make(IdentifierSet)
+			}
+			innerTypes.AddAll(t.MapKey.InnerTypes())
+		}
+		return innerTypes
 	default:
 		return t.Union.InnerModels()
 	}
@@ -74,12 +95,40 @@ func (t *RestliType) GoType() *Statement {
 	case t.Array != nil:
 		return Index().Add(t.Array.ReferencedType())
 	case t.Map != nil:
-		return Map(String()).Add(t.Map.ReferencedType())
+		return Map(t.mapKeyType()).Add(t.Map.ReferencedType())
 	default:
 		return t.Union.GoType()
 	}
 }
 
+// mapKeys holds the key of every map that declares one, so that they can be checked once all the types are registered
+var mapKeys []*RestliType
+
+// checkMapKeys returns an error for each map key that is not a typeref to string. Keys are always strings on the wire,
+// and encoding/json cannot use most other types as the keys of a map. Even time typerefs (see TimeTyperefs), which it
+// can use, would make poor keys since two time.Time that hold the same instant are not necessarily equal.
+func checkMapKeys() error {
+	var errs ErrorList
+	for _, key := range mapKeys {
+		typeref, ok := key.Reference.Resolve().(*Typeref)
+		if !ok || typeref.Ref.Primitive == nil || typeref.Ref.Primitive.Type != "string" {
+			errs.Add(errors.Errorf("go-restli: %s cannot be used as the key of a map since it is not a typeref to "+
+				"string", key.Reference))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (t *RestliType) mapKeyType() *Statement {
+	if t.MapKey != nil {
+		return t.MapKey.GoType()
+	}
+	return String()
+}
+
 func (t *RestliType) ReferencedType() *Statement {
 	switch {
 	case t.Primitive != nil:
@@ -97,6 +146,15 @@ func (t *RestliType) ReferencedType() *Statement {
 	return t.PointerType()
 }
 
+// IsReferencedByPointer returns true if ReferencedType returns a pointer to the type rather than the type itself
+func (t *RestliType) IsReferencedByPointer() bool {
+	if t.Reference == nil {
+		return false
+	}
+	ref, ok := t.Reference.Resolve().(*Typeref)
+	return !ok || !ref.isPrimitive()
+}
+
 func (t *RestliType) IsUnion() bool {
 	return t.Union != nil
 }
@@ -140,7 +198,14 @@ func (t *RestliType) WriteToBuf(def *Group, accessor *Statement) {
 		def.For(List(Id("key"), Id("val")).Op(":=").Range().Add(accessor)).BlockFunc(func(def *Group) {
 			def.If(Id("idx").Op("!=").Lit(0)).Block(Id("buf").Dot("WriteByte").Call(LitRune(','))).Line()
 			def.Id("idx").Op("++")
-			writeStringToBuf(def, Id(Codec).Dot("EncodeString").Call(Id("key")))
+			if t.MapKey != nil {
+				def.Var().Id("encodedKey").String()
+				def.List(Id("encodedKey"), Err()).Op("=").Id("key").Dot(RestLiEncode).Call(Id(Codec))
+				IfErrReturn(def)
+				writeStringToBuf(def, Id("encodedKey"))
+			} else {
+				writeStringToBuf(def, Id(Codec).Dot("EncodeString").Call(Id("key")))
+			}
 			def.Id("buf").Dot("WriteByte").Call(LitRune(':'))
 			t.Map.WriteToBuf(def, Id("val"))
 		})
@@ -150,6 +215,15 @@ func (t *RestliType) WriteToBuf(def *Group, accessor *Statement) {
 	default:
 		label := "end" + canonicalizeAccessor(accessor)
 
+		if t.Union.preservesUnknownMembers() {
+			// Unknown members are kept in their JSON form, which cannot be converted to the rest.li format
+			def.If(Add(accessor).Dot(UnknownMember).Op("!=").Nil()).Block(
+				Err().Op("=").Qual("fmt", "Errorf").Call(Lit("go-restli: Cannot encode unknown member %q of "+accessor.GoString()),
+					Add(accessor).Dot(UnknownMember).Dot("Alias")),
+				Return(),
+			).Line()
+		}
+
 		for _, m := range *t.Union {
 			def.If(Add(accessor).Dot(m.name()).Op("!=").Nil()).BlockFunc(func(def *Group) {
 				writeStringToBuf(def, Lit("("+m.Alias+":"))
@@ -167,6 +241,123 @@ func (t *RestliType) WriteToBuf(def *Group, accessor *Statement) {
 	}
 }
 
+// decodeTarget is the destination of the code generated by ReadFromString. It holds either an addressable value or a
+// pointer to one, which avoids generating expressions such as &(*v)
+type decodeTarget struct {
+	value   *Statement
+	pointer *Statement
+}
+
+func valueTarget(value *Statement) decodeTarget {
+	return decodeTarget{value: value}
+}
+
+func pointerTarget(pointer *Statement) decodeTarget {
+	return decodeTarget{pointer: pointer}
+}
+
+func (t decodeTarget) addr() *Statement {
+	if t.pointer != nil {
+		return t.pointer
+	}
+	return Op("&").Add(t.value)
+}
+
+// ref returns an expression on which methods and struct fields can be accessed
+func (t decodeTarget) ref() *Statement {
+	if t.pointer != nil {
+		return t.pointer
+	}
+	return t.value
+}
+
+func (t decodeTarget) assignable() *Statement {
+	if t.pointer != nil {
+		return Op("*").Add(t.pointer)
+	}
+	return t.value
+}
+
+// ReadFromString generates the code that decodes data, a string in the rest.li URL format, into target. This is the
+// inverse of WriteToBuf and expects to be in a function with a named err return value and a codec parameter. depth is
+// used to give unique names to the variables of nested maps and arrays.
+func (t *RestliType) ReadFromString(def *Group, target decodeTarget, data *Statement, depth int) {
+	suffix := func(name string) *Statement {
+		return Id(fmt.Sprintf("%s%d", name, depth))
+	}
+
+	// decodeElement decodes into a fresh value of the given type, which is then passed to assign
+	decodeElement := func(def *Group, elem *RestliType, data *Statement, assign func(value *Statement) *Statement) {
+		value := suffix("v")
+		if elem.IsReferencedByPointer() {
+			def.Add(value).Op(":=").New(elem.GoType())
+			elem.ReadFromString(def, pointerTarget(value), data, depth+1)
+		} else {
+			def.Var().Add(value).Add(elem.GoType())
+			elem.ReadFromString(def, valueTarget(value), data, depth+1)
+		}
+		def.Add(assign(value))
+	}
+
+	switch {
+	case t.Primitive != nil:
+		def.Err().Op("=").Id(Codec).Dot("Decode"+ExportedIdentifier(t.Primitive.Type)).Call(data, target.addr())
+		IfErrReturn(def, Err())
+	case t.Reference != nil:
+		def.Err().Op("=").Add(target.ref()).Dot(RestLiDecode).Call(Id(Codec), data)
+		IfErrReturn(def, Err())
+	case t.Array != nil:
+		items := suffix("items")
+		def.Var().Add(items).Index().String()
+		def.List(items, Err()).Op("=").Id(Codec).Dot("DecodeList").Call(data)
+		IfErrReturn(def, Err())
+		def.Add(target.assignable()).Op("=").Make(t.GoType(), Len(items))
+		def.For(List(suffix("i"), suffix("item")).Op(":=").Range().Add(items)).BlockFunc(func(def *Group) {
+			decodeElement(def, t.Array, suffix("item"), func(value *Statement) *Statement {
+				return Add(target.ref()).Index(suffix("i")).Op("=").Add(value)
+			})
+		})
+	case t.Map != nil:
+		entries := suffix("entries")
+		def.Var().Add(entries).Map(String()).String()
+		def.List(entries, Err()).Op("=").Id(Codec).Dot("DecodeObject").Call(data)
+		IfErrReturn(def, Err())
+		def.Add(target.assignable()).Op("=").Make(t.GoType(), Len(entries))
+		def.For(List(suffix("k"), suffix("item")).Op(":=").Range().Add(entries)).BlockFunc(func(def *Group) {
+			key := suffix("key")
+			def.Var().Add(key).Add(t.mapKeyType())
+			if t.MapKey != nil {
+				def.Err().Op("=").Add(key).Dot(RestLiDecode).Call(Id(Codec), suffix("k"))
+			} else {
+				def.Err().Op("=").Id(Codec).Dot("DecodeString").Call(suffix("k"), Op("&").Add(key))
+			}
+			IfErrReturn(def, Err())
+			decodeElement(def, t.Map, suffix("item"), func(value *Statement) *Statement {
+				return Add(target.ref()).Index(key).Op("=").Add(value)
+			})
+		})
+	default:
+		members := suffix("members")
+		def.Var().Add(members).Map(String()).String()
+		def.List(members, Err()).Op("=").Id(Codec).Dot("DecodeObject").Call(data)
+		IfErrReturn(def, Err())
+		// Unknown members are ignored, like they are when decoding JSON
+		for _, m := range *t.Union {
+			m := m
+			member := suffix("member")
+			field := Add(target.ref()).Dot(m.name())
+			def.If(List(member, Id("ok")).Op(":=").Add(members).Index(Lit(m.Alias)), Id("ok")).BlockFunc(func(def *Group) {
+				if m.Type.IsMapOrArray() {
+					m.Type.ReadFromString(def, valueTarget(field), member, depth+1)
+				} else {
+					def.Add(field).Op("=").New(m.Type.GoType())
+					m.Type.ReadFromString(def, pointerTarget(field), member, depth+1)
+				}
+			})
+		}
+	}
+}
+
 type GoRestliSpec struct {
 	DataTypes []struct {
 		Enum    *Enum
@@ -177,7 +368,52 @@ type GoRestliSpec struct {
 	Resources []Resource
 }
 
+// combineSpecs merges a JSON array of specs, such as the ones produced by tooling that bundles the specs of several
+// services into a single file, into a single spec. Data types are often shared between the combined specs, so identical
+// declarations are only kept once. Conflicting declarations are left for TypeRegistry.Register to report
+func combineSpecs(data []byte) ([]byte, error) {
+	var specs []struct {
+		DataTypes []json.RawMessage
+		Resources []json.RawMessage
+	}
+	err := json.Unmarshal(data, &specs)
+	if err != nil {
+		return nil, errors.Wrap(err, "go-restli: Could not split combined specs")
+	}
+
+	combined := struct {
+		DataTypes []json.RawMessage
+		Resources []json.RawMessage
+	}{}
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		for _, t := range spec.DataTypes {
+			var compacted bytes.Buffer
+			if err = json.Compact(&compacted, t); err != nil {
+				return nil, err
+			}
+			if !seen[compacted.String()] {
+				seen[compacted.String()] = true
+				combined.DataTypes = append(combined.DataTypes, t)
+			}
+		}
+		combined.Resources = append(combined.Resources, spec.Resources...)
+	}
+	return json.Marshal(combined)
+}
+
 func (s *GoRestliSpec) UnmarshalJSON(data []byte) error {
+	// A JSON array holds several specs that are combined into one, see combineSpecs
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		combined, err := combineSpecs(trimmed)
+		if err != nil {
+			return err
+		}
+		data = combined
+	}
+
+	// Map keys are collected as the spec is decoded, only the ones of this spec must be checked
+	mapKeys = nil
 	type t GoRestliSpec
 	err := json.Unmarshal(data, (*t)(s))
 	if err != nil {
@@ -198,16 +434,89 @@ func (s *GoRestliSpec) UnmarshalJSON(data []byte) error {
 		default:
 			return errors.New("go-restli: Must declare at least one underlying type")
 		}
-		TypeRegistry.Register(complexType)
+		err = TypeRegistry.Register(complexType)
+		if err != nil {
+			return err
+		}
 	}
 
-	TypeRegistry.FlagCyclicDependencies()
-	return nil
+	err = s.registerAssociationKeys()
+	if err != nil {
+		return err
+	}
+
+	// Report all the unknown references at once rather than one at a time
+	var errs ErrorList
+	for id, t := range TypeRegistry {
+		errs.Add(TypeRegistry.CheckReferences(id.String(), t.Type.InnerTypes()))
+	}
+	for _, r := range s.Resources {
+		errs.Add(TypeRegistry.CheckReferences(r.SourceFile, r.InnerTypes()))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	err = TypeRegistry.registerPolymorphicTypes()
+	if err != nil {
+		return err
+	}
+	err = TypeRegistry.registerLazyTypes()
+	if err != nil {
+		return err
+	}
+	err = TypeRegistry.registerTimeTyperefs()
+	if err != nil {
+		return err
+	}
+	err = checkMapKeys()
+	if err != nil {
+		return err
+	}
+	err = TypeRegistry.checkChangeTrackedRecords()
+	if err != nil {
+		return err
+	}
+	err = TypeRegistry.checkRecordConversions()
+	if err != nil {
+		return err
+	}
+	err = s.checkFeatureFlags()
+	if err != nil {
+		return err
+	}
+	err = s.selectLiteMethods()
+	if err != nil {
+		return err
+	}
+	s.pruneUnreachableTypes()
+
+	if FlatPackage {
+		err = TypeRegistry.Flatten()
+		if err != nil {
+			return err
+		}
+	} else {
+		TypeRegistry.FlagCyclicDependencies()
+	}
+
+	return TypeRegistry.CheckCollisions()
 }
 
-func (s *GoRestliSpec) GenerateClientCode() (codeFiles []*CodeFile) {
+// GenerateClientCode generates the code of every resource. Like GenerateTypeCode, it keeps going after a resource
+// fails, and returns the files of the other resources alongside the errors.
+func (s *GoRestliSpec) GenerateClientCode() (codeFiles []*CodeFile, err error) {
+	var errs ErrorList
 	for _, r := range s.Resources {
-		codeFiles = append(codeFiles, r.GenerateCode()...)
+		files, err := r.GenerateCode()
+		if err != nil {
+			errs.Add(errors.Wrapf(err, "go-restli: Could not generate code for %s", r.SourceFile))
+			continue
+		}
+		codeFiles = append(codeFiles, files...)
+	}
+	if len(errs) > 0 {
+		return codeFiles, errs
 	}
-	return codeFiles
+	return codeFiles, nil
 }
@@ -4,13 +4,14 @@ import (
 	"strings"
 
 	"github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
 type ComplexType interface {
 	GetIdentifier() Identifier
 	GetSourceFile() string
 	InnerTypes() IdentifierSet
-	GenerateCode() *jen.Statement
+	GenerateCode() (*jen.Statement, error)
 }
 
 var TypeRegistry = make(typeRegistry)
@@ -18,16 +19,39 @@ var TypeRegistry = make(typeRegistry)
 type registeredType struct {
 	Type     ComplexType
 	IsCyclic bool
+	// SchemaIdentifier is the type's identifier as declared in its schema, which may differ from the type's own
+	// identifier when it gets renamed (see Flatten)
+	SchemaIdentifier Identifier
 }
 
 type typeRegistry map[Identifier]*registeredType
 
-func (reg typeRegistry) Register(t ComplexType) {
+func (reg typeRegistry) Register(t ComplexType) error {
 	id := t.GetIdentifier()
+	if err := id.Validate(); err != nil {
+		return err
+	}
 	if _, ok := reg[id]; ok {
-		Logger.Panicf("Cannot register type %s twice!", id)
+		return errors.Errorf("go-restli: Cannot register type %s twice!", id)
+	}
+	reg[id] = &registeredType{Type: t, SchemaIdentifier: id}
+	return nil
+}
+
+// CheckReferences returns an error if any of the given types were never registered. Every type that is resolved during
+// code generation should have gone through this check first, otherwise an unknown type will cause a panic deep within
+// the code generator rather than a reportable error
+func (reg typeRegistry) CheckReferences(source string, ids IdentifierSet) error {
+	var errs ErrorList
+	for id := range ids {
+		if _, ok := reg[id]; !ok {
+			errs.Add(errors.Errorf("go-restli: Unknown type %s referenced by %s", id, source))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
 	}
-	reg[id] = &registeredType{Type: t}
+	return nil
 }
 
 func (reg typeRegistry) get(id Identifier) *registeredType {
@@ -46,16 +70,107 @@ func (reg typeRegistry) IsCyclic(id Identifier) bool {
 	return reg.get(id).IsCyclic
 }
 
-func (reg typeRegistry) GenerateTypeCode() (files []*CodeFile) {
+// GenerateTypeCode generates the code of every registered type. It keeps going after a type fails so that all the
+// errors are reported at once, in which case the files of the other types are returned alongside them.
+func (reg typeRegistry) GenerateTypeCode() (files []*CodeFile, err error) {
+	var errs ErrorList
 	for _, t := range reg {
+		code, err := t.Type.GenerateCode()
+		if err != nil {
+			errs.Add(errors.Wrapf(err, "go-restli: Could not generate code for %s", t.Type.GetIdentifier()))
+			continue
+		}
+		if declaresGoType(t.Type) {
+			// Polymorphic and Lazy types are not part of any schema, only the records they wrap are registered
+			switch t.Type.(type) {
+			case *Polymorphic, *Lazy:
+			default:
+				addObjectRegistration(code, t.SchemaIdentifier, t.Type.GetIdentifier().Name)
+			}
+			addInterfaceAssertions(code, t.Type)
+		}
 		files = append(files, &CodeFile{
 			SourceFile:  t.Type.GetSourceFile(),
 			PackagePath: t.Type.GetIdentifier().PackagePath(),
 			Filename:    t.Type.GetIdentifier().Name,
-			Code:        t.Type.GenerateCode(),
+			Code:        code,
 		})
+		if record, ok := t.Type.(*Record); ok && GenerateFuzzTests {
+			files = append(files, &CodeFile{
+				SourceFile:  record.GetSourceFile(),
+				PackagePath: record.PackagePath(),
+				Filename:    record.fuzzTestFilename(),
+				Code:        record.generateFuzzTest(),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return files, errs
+	}
+	return files, nil
+}
+
+// dependencies returns the types that must be generated alongside the given type. On top of its inner types, this
+// includes the subtypes of polymorphic records, which are only referenced at runtime by their discriminator
+func (reg typeRegistry) dependencies(t ComplexType) IdentifierSet {
+	dependencies := make(IdentifierSet)
+	dependencies.AddAll(t.InnerTypes())
+	if p, ok := t.(*Polymorphic); ok {
+		dependencies.Add(p.Base.Identifier)
+		for id, other := range reg {
+			if r, ok := other.Type.(*Record); ok && p.isSubtype(r) {
+				dependencies.Add(id)
+			}
+		}
+	}
+	return dependencies
+}
+
+// retainReachableTypes removes every type that cannot be reached from the given roots through the dependencies of the
+// types, and returns how many were removed
+func (reg typeRegistry) retainReachableTypes(roots IdentifierSet) int {
+	reachable := make(IdentifierSet)
+	var visit func(id Identifier)
+	visit = func(id Identifier) {
+		if reachable.Get(id) {
+			return
+		}
+		reachable.Add(id)
+		for dependency := range reg.dependencies(reg.Resolve(id)) {
+			visit(dependency)
+		}
+	}
+	for id := range roots {
+		visit(id)
+	}
+
+	removed := 0
+	for id := range reg {
+		if !reachable.Get(id) {
+			delete(reg, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// CheckCollisions returns an error for every pair of types that would be generated with the same name in the same
+// package, e.g. when two types with the same name are moved to the conflict resolution package because of a cycle
+func (reg typeRegistry) CheckCollisions() error {
+	var errs ErrorList
+	generated := make(map[string]Identifier)
+	for id := range reg {
+		name := id.PackagePath() + "." + id.Name
+		if other, ok := generated[name]; ok {
+			errs.Add(errors.Errorf("go-restli: %s and %s would both be generated as %s", other, id, name))
+		} else {
+			generated[name] = id
+		}
 	}
-	return files
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 func (reg typeRegistry) FindCycle(nextNode Identifier, path Path) []Identifier {
@@ -161,3 +276,59 @@ func (p Path) IntroducesCycle(nextNode Identifier) Path {
 	}
 	return nil
 }
+
+// declaresGoType returns false for the types that do not get a corresponding Go type, such as typerefs to other
+// complex types
+func declaresGoType(t ComplexType) bool {
+	if typeref, ok := t.(*Typeref); ok {
+		return typeref.Ref.Reference == nil
+	}
+	return true
+}
+
+// addObjectRegistration registers the type's constructor with the protocol package so that it can be looked up by its
+// fully-qualified name at runtime
+func addObjectRegistration(def *jen.Statement, id Identifier, typeName string) {
+	def.Func().Id("init").Params().Block(
+		jen.Qual(ProtocolPackage, "RegisterObject").Call(
+			jen.Lit(id.GetQualifiedClasspath()),
+			jen.Func().Params().Qual(ProtocolPackage, "RestLiObject").Block(jen.Return(jen.New(jen.Id(typeName)))),
+		),
+	).Line().Line()
+}
+
+// addInterfaceAssertions asserts that the type implements the interfaces of the protocol and encoding/json packages that
+// its generated methods are meant to satisfy, which turns any drift in their signatures into a compile error
+func addInterfaceAssertions(def *jen.Statement, t ComplexType) {
+	typeName := t.GetIdentifier().Name
+	assert := func(iface *jen.Statement) {
+		def.Var().Id("_").Add(iface).Op("=").Parens(jen.Op("*").Id(typeName)).Call(jen.Nil()).Line()
+	}
+
+	assert(jen.Qual(ProtocolPackage, "RestLiEncodable"))
+	if hasJSONSerDe(t) {
+		assert(jen.Qual(EncodingJson, "Marshaler"))
+		assert(jen.Qual(EncodingJson, "Unmarshaler"))
+	}
+	def.Line()
+}
+
+// hasJSONSerDe returns true if the type has custom MarshalJSON and UnmarshalJSON functions
+func hasJSONSerDe(t ComplexType) bool {
+	switch t := t.(type) {
+	case *Enum, *Fixed, *Polymorphic, *Lazy:
+		return true
+	case *Record:
+		if t.tracksChanges {
+			return true
+		}
+		for _, f := range t.Fields {
+			if f.Type.IsUnion() {
+				return true
+			}
+		}
+	case *Typeref:
+		return t.isTime || (t.Ref.Union != nil && t.Ref.Union.preservesUnknownMembers())
+	}
+	return false
+}
@@ -2,27 +2,35 @@ package codegen
 
 import (
 	. "github.com/dave/jennifer/jen"
+	"github.com/pkg/errors"
 )
 
 type Typeref struct {
 	NamedType
 	Ref RestliType
+
+	// isTime is set on typerefs to long that are generated as a time.Time, see TimeTyperefs
+	isTime bool
 }
 
 func (r *Typeref) InnerTypes() IdentifierSet {
 	return r.Ref.InnerTypes()
 }
 
-func (r *Typeref) GenerateCode() (def *Statement) {
+func (r *Typeref) GenerateCode() (def *Statement, err error) {
 	def = Empty()
 
 	if ref := r.Ref.Reference; ref != nil {
 		// TODO
 		Logger.Printf("Warning: type references to non-primitive types are not yet supported (%s)", r.Identifier)
-		return def
+		return def, nil
+	}
+
+	if r.isTime {
+		return r.generateTimeCode(), nil
 	}
 
-	AddWordWrappedComment(def, r.Doc).Line()
+	AddDocComment(def, r.Doc, r.Deprecated).Line()
 	def.Type().Id(r.Name).Add(r.Ref.GoType()).Line().Line()
 
 	if pt := r.Ref.Primitive; pt != nil {
@@ -33,32 +41,65 @@ func (r *Typeref) GenerateCode() (def *Statement) {
 			def.Return(pt.decode(Id(r.Receiver())))
 		}).Line().Line()
 
-		return def
+		return def, nil
 	}
 
 	if union := r.Ref.Union; union != nil {
 		AddRestLiEncode(def, r.Receiver(), r.Name, func(def *Group) {
-			def.Err().Op("=").Id(r.Receiver()).Dot(ValidateUnionFields).Call()
+			def.Err().Op("=").Id(r.Receiver()).Dot(ValidateUnionFields).Call(True())
 			def.If(Err().Op("!=").Nil()).Block(Return()).Line()
+			checkComplexValue(def)
 			def.Var().Id("buf").Qual("strings", "Builder")
 			r.Ref.WriteToBuf(def, Id(r.Receiver()))
 			def.Id("data").Op("=").Id("buf").Dot("String").Call()
 			def.Return()
 		}).Line().Line()
+		AddRestLiDecode(def, r.Receiver(), r.Name, func(def *Group) {
+			r.Ref.ReadFromString(def, pointerTarget(Id(r.Receiver())), Id("data"), 0)
+			def.Return(Id(r.Receiver()).Dot(ValidateUnionFields).Call(False()))
+		}).Line().Line()
+
+		if union.preservesUnknownMembers() {
+			AddMarshalJSON(def, r.Receiver(), r.Name, func(def *Group) {
+				def.If(Id(r.Receiver()).Dot(UnknownMember).Op("!=").Nil()).Block(
+					Return(Id(r.Receiver()).Dot(UnknownMember).Dot(MarshalJSON).Call()),
+				)
+				def.Type().Id("_t").Id(r.Name)
+				def.Return(Qual(JsonPackage, Marshal).Call(Call(Op("*").Id("_t")).Call(Id(r.Receiver()))))
+			}).Line().Line()
+			AddUnmarshalJSON(def, r.Receiver(), r.Name, func(def *Group) {
+				def.Type().Id("_t").Id(r.Name)
+				def.Err().Op("=").Qual(JsonPackage, Unmarshal).Call(Id("data"), Call(Op("*").Id("_t")).Call(Id(r.Receiver())))
+				IfErrReturn(def).Line()
+				def.List(Id(r.Receiver()).Dot(UnknownMember), Err()).Op("=").Qual(ProtocolPackage, "FindUnknownUnionMember").
+					Call(append([]Code{Id("data")}, union.aliases()...)...)
+				def.Return()
+			}).Line().Line()
+		}
 
 		AddFuncOnReceiver(def, r.Receiver(), r.Name, ValidateUnionFields).
-			Params().
+			Params(Id(RequireSet).Bool()).
 			Params(Err().Error()).
 			BlockFunc(func(def *Group) {
-				union.validateUnionFields(def, Id(r.Receiver()))
+				union.validateUnionFields(def, Id(r.Receiver()), r.Name, true)
 				def.Line().Return()
-			})
+			}).Line().Line()
+
+		union.generateVisitor(def, r.Receiver(), r.Name, "", Id(r.Receiver()), r.Name, true)
+
+		def.Commentf("%s adds a protocol.ValidationError to errs if %s does not have exactly one member set, and "+
+			"validates the member that is set. It is called by the %s functions of the records that hold this %s",
+			ValidateFields, r.Name, Validate, r.Name).Line()
+		AddFuncOnReceiver(def, r.Receiver(), r.Name, ValidateFields).
+			Params(Id("path").String(), Id("errs").Op("*").Qual(ProtocolPackage, "ValidationErrors")).
+			BlockFunc(func(def *Group) {
+				union.validateMembers(def, Id(r.Receiver()), Id("path"), true)
+			}).Line().Line()
 
-		return def
+		return def, nil
 	}
 
-	Logger.Panicf("Illegal typeref type %+v defined in %s", r.Ref, r.GetSourceFile())
-	return nil
+	return nil, errors.Errorf("go-restli: Illegal typeref type %+v defined in %s", r.Ref, r.GetSourceFile())
 }
 
 func (r *Typeref) isPrimitive() bool {
@@ -7,8 +7,35 @@ import (
 	. "github.com/dave/jennifer/jen"
 )
 
+// PreserveUnknownUnionMembers adds an Unknown field to the generated unions, which holds the member of a decoded JSON
+// union that is not part of the schema so that it can be re-emitted when the union is marshaled back to JSON. Unions
+// that have a member named Unknown are left as-is.
+var PreserveUnknownUnionMembers bool
+
+const UnknownMember = "Unknown"
+
 type UnionType []UnionMember
 
+// preservesUnknownMembers returns true if the union has an UnknownMember field, see PreserveUnknownUnionMembers
+func (u *UnionType) preservesUnknownMembers() bool {
+	if !PreserveUnknownUnionMembers {
+		return false
+	}
+	for _, m := range *u {
+		if m.name() == UnknownMember {
+			return false
+		}
+	}
+	return true
+}
+
+func (u *UnionType) aliases() (aliases []Code) {
+	for _, m := range *u {
+		aliases = append(aliases, Lit(m.Alias))
+	}
+	return aliases
+}
+
 func (u *UnionType) InnerModels() IdentifierSet {
 	innerTypes := make(IdentifierSet)
 	for _, m := range *u {
@@ -25,33 +52,64 @@ func (u *UnionType) GoType() *Statement {
 			field.Add(m.Type.PointerType())
 			field.Tag(JsonFieldTag(m.Alias, true))
 		}
+		if u.preservesUnknownMembers() {
+			def.Id(UnknownMember).Op("*").Qual(ProtocolPackage, "UnknownUnionMember").Tag(map[string]string{"json": "-"})
+		}
 	})
 }
 
-func (u *UnionType) validateUnionFields(def *Group, accessor *Statement) {
-	isSet := "is" + canonicalizeAccessor(accessor) + "Set"
-	def.Id(isSet).Op(":=").False().Line()
-	errorMessage := fmt.Sprintf("must specify exactly one member of %s", accessor.GoString())
-
-	for i, t := range *u {
-		def.If(Add(accessor).Dot(t.name()).Op("!=").Nil()).
-			BlockFunc(func(def *Group) {
-				if i == 0 {
-					def.Id(isSet).Op("=").True()
-				} else {
-					def.If(Op("!").Id(isSet)).BlockFunc(func(def *Group) {
-						def.Id(isSet).Op("=").True()
-					}).Else().BlockFunc(func(def *Group) {
-						def.Err().Op("=").Qual("fmt", "Errorf").Call(Lit(errorMessage))
-						def.Return()
-					})
+// validateUnionFields checks that at most one member of the union is set, see protocol.ValidateUnionMembers. If
+// required is true, the generated code also checks that at least one member is set, but only if the requireSet parameter
+// of the generated function is true. This allows unions to be absent from partial responses (e.g. when using
+// projections) while still rejecting them when sending requests. Unions that have a null member are never required,
+// since they are generated as optional fields
+func (u *UnionType) validateUnionFields(def *Group, accessor *Statement, description string, required bool) {
+	setMembers := u.collectSetMembers(def, accessor, nil)
+
+	requireSet := False()
+	if required {
+		requireSet = Id(RequireSet)
+	}
+	def.Err().Op("=").Qual(ProtocolPackage, "ValidateUnionMembers").Call(Lit(description), Id(setMembers), requireSet)
+	def.If(Err().Op("!=").Nil()).Block(Return())
+}
+
+// collectSetMembers declares a setMembers slice holding the aliases of the members of the union that are set. If
+// memberChecks is non-nil, the code it returns for each member is run when that member is set
+func (u *UnionType) collectSetMembers(def *Group, accessor *Statement, memberChecks func(m UnionMember) Code) string {
+	setMembers := "setMembers"
+	def.Var().Id(setMembers).Index().String()
+
+	for _, m := range *u {
+		def.If(Add(accessor).Dot(m.name()).Op("!=").Nil()).BlockFunc(func(def *Group) {
+			def.Id(setMembers).Op("=").Append(Id(setMembers), Lit(m.Alias))
+			if memberChecks != nil {
+				if check := memberChecks(m); check != nil {
+					def.Add(check)
 				}
-			}).Line()
+			}
+		})
+	}
+	if u.preservesUnknownMembers() {
+		unknown := Add(accessor).Dot(UnknownMember)
+		def.If(unknown.Clone().Op("!=").Nil()).Block(
+			Id(setMembers).Op("=").Append(Id(setMembers), unknown.Clone().Dot("Alias")),
+		)
 	}
-	def.If(Op("!").Id(isSet)).BlockFunc(func(def *Group) {
-		def.Err().Op("=").Qual("fmt", "Errorf").Call(Lit(errorMessage))
-		def.Return()
+	def.Line()
+	return setMembers
+}
+
+// validateMembers adds a protocol.ValidationError to errs if more than one member is set, or none if the union is
+// required. The members that are set are validated too, see validateValue
+func (u *UnionType) validateMembers(def *Group, accessor, path *Statement, required bool) {
+	setMembers := u.collectSetMembers(def, accessor, func(m UnionMember) Code {
+		if m.Type.IsMapOrArray() || !m.Type.IsReferencedByPointer() {
+			return nil
+		}
+		return validateValue(&m.Type, Add(accessor).Dot(m.name()), Qual(ProtocolPackage, "FieldPath").Call(path, Lit(m.Alias)), 0)
 	})
+	def.Id("errs").Dot("AddUnionMembers").Call(path, Id(setMembers), Lit(required))
 }
 
 type UnionMember struct {
@@ -62,3 +120,73 @@ type UnionMember struct {
 func (m *UnionMember) name() string {
 	return ExportedIdentifier(m.Alias[strings.LastIndex(m.Alias, ".")+1:])
 }
+
+// generateVisitor generates a visitor struct with one func per member of the union, along with a Visit function that
+// calls the func of the member that is set (if any) and an Accept function that fails if the member that is set has no
+// func. When the union is required, Accept also fails if no member is set.
+func (u *UnionType) generateVisitor(def *Statement, receiver, typeName, suffix string, accessor *Statement, description string, required bool) {
+	visitor := typeName + suffix + "Visitor"
+	visit := "Visit" + suffix
+	accept := "Accept" + suffix
+
+	memberValue := func(m UnionMember) *Statement {
+		value := Add(accessor).Dot(m.name())
+		if !m.Type.IsReferencedByPointer() && !m.Type.IsMapOrArray() {
+			value = Op("*").Add(value)
+		}
+		return value
+	}
+
+	def.Commentf("%s has a func for each member of %s, see %s and %s", visitor, description, visit, accept).Line()
+	def.Type().Id(visitor).StructFunc(func(def *Group) {
+		for _, m := range *u {
+			def.Id(m.name()).Func().Params(m.Type.ReferencedType())
+		}
+	}).Line().Line()
+
+	def.Commentf("%s calls the func of the given %s that corresponds to the member of %s that is set, if any. Members "+
+		"that have no func (and unknown members) are ignored", visit, visitor, description).Line()
+	AddFuncOnReceiver(def, receiver, typeName, visit).
+		Params(Id("visitor").Id(visitor)).
+		Block(Switch().BlockFunc(func(def *Group) {
+			for _, m := range *u {
+				def.Case(Add(accessor).Dot(m.name()).Op("!=").Nil()).Block(
+					If(Id("visitor").Dot(m.name()).Op("!=").Nil()).Block(Id("visitor").Dot(m.name()).Call(memberValue(m))),
+				)
+			}
+		})).Line().Line()
+
+	if required {
+		def.Commentf("%s is like %s, but returns an error if the member that is set has no func, or if no member is set",
+			accept, visit).Line()
+	} else {
+		def.Commentf("%s is like %s, but returns an error if the member that is set has no func", accept, visit).Line()
+	}
+	AddFuncOnReceiver(def, receiver, typeName, accept).
+		Params(Id("visitor").Id(visitor)).
+		Error().
+		BlockFunc(func(def *Group) {
+			def.Switch().BlockFunc(func(def *Group) {
+				for _, m := range *u {
+					def.Case(Add(accessor).Dot(m.name()).Op("!=").Nil()).Block(
+						If(Id("visitor").Dot(m.name()).Op("==").Nil()).Block(
+							Return(Qual("fmt", "Errorf").Call(Lit(fmt.Sprintf("go-restli: %s has no func for member %s of %s", visitor, m.Alias, description)))),
+						),
+						Id("visitor").Dot(m.name()).Call(memberValue(m)),
+						Return(Nil()),
+					)
+				}
+				if u.preservesUnknownMembers() {
+					def.Case(Add(accessor).Dot(UnknownMember).Op("!=").Nil()).Block(
+						Return(Qual("fmt", "Errorf").Call(Lit(fmt.Sprintf("go-restli: %s has no func for unknown member %%q of %s", visitor, description)),
+							Add(accessor).Dot(UnknownMember).Dot("Alias"))),
+					)
+				}
+			})
+			if required {
+				def.Return(Qual("fmt", "Errorf").Call(Lit(fmt.Sprintf("go-restli: No member of %s is set", description))))
+			} else {
+				def.Return(Nil())
+			}
+		}).Line().Line()
+}
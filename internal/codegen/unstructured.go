@@ -0,0 +1,48 @@
+package codegen
+
+import (
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+)
+
+const (
+	ContentTypeParam      = "contentType"
+	UnstructuredDataParam = "data"
+)
+
+// generateUnstructuredDataMethod generates the methods of resources that serve unstructured data, i.e. raw bytes such
+// as images or documents, instead of records. Only GET, CREATE and DELETE are supported
+// https://linkedin.github.io/rest.li/Unstructured_Data
+func (r *Resource) generateUnstructuredDataMethod(m *Method) *Statement {
+	switch m.RestLiMethod() {
+	case protocol.Method_get:
+		return r.generateUnstructuredDataGet(m)
+	case protocol.Method_create:
+		return r.generateCreate(m)
+	case protocol.Method_delete:
+		return r.generateDelete(m)
+	default:
+		Logger.Printf("Warning: %s method is not supported by unstructured data resources (%s)", m.Name, r.Namespace)
+		return nil
+	}
+}
+
+// generateUnstructuredDataGet generates a GET that returns the response's body without reading it, along with its
+// Content-Type. Since the body is read after the method returns, the method's timeout is not applied, and the caller's
+// context is the only way to bound the request
+func (r *Resource) generateUnstructuredDataGet(m *Method) *Statement {
+	def := Empty()
+	r.addClientFunc(def, m)
+
+	def.BlockFunc(func(def *Group) {
+		r.formatURL(def, m, Nil(), Lit(""), Err())
+
+		r.withRequestInfo(def, m.funcName(), m, protocol.Method_get)
+		def.List(Id(ReqVar), Err()).Op(":=").Id(ClientReceiver).Dot("UnstructuredDataGetRequest").Call(Id(CtxVar), Id(UrlVar), RestLiMethod(protocol.Method_get))
+		IfErrReturn(def, Nil(), Lit(""), Err()).Line()
+
+		def.Return(Id(ClientReceiver).Dot("DoAndStream").Call(Id(ReqVar)))
+	})
+
+	return def
+}
@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/bored-engineer/go-restli/protocol"
+	. "github.com/dave/jennifer/jen"
+)
+
+// URLSuffix is appended to the name of every method to name its companion function, which returns the URL the method
+// would send its request to
+const URLSuffix = "URL"
+
+func (m *Method) urlFuncName() string {
+	return m.funcName() + URLSuffix
+}
+
+// urlFunc declares the companion URL function of the given method. It takes the same parameters as the method, short of
+// the context and of anything that is only sent in the request's body. BATCH_PARTIAL_UPDATE's takes the keys of the
+// patches instead of the patches themselves
+func (r *Resource) urlFunc(m *Method) *Statement {
+	return Id(m.urlFuncName()).ParamsFunc(func(def *Group) {
+		m.addEntityTypes(def)
+		switch m.MethodType {
+		case REST_METHOD:
+			switch m.RestLiMethod() {
+			case protocol.Method_get:
+				if !r.IsUnstructuredData {
+					addFieldsParam(def)
+				}
+			case protocol.Method_batch_get:
+				def.Id(BatchKeysParam).Index().Add(r.entityKey().Type.GoType())
+				addFieldsParam(def)
+			case protocol.Method_batch_partial_update:
+				def.Id(BatchKeysParam).Index().Add(r.entityKey().Type.GoType())
+			}
+		case FINDER:
+			def.Id("params").Op("*").Id(m.finderStructType())
+		}
+	}).Params(String(), Error())
+}
+
+// generateURLFunc generates the companion URL function of the given method, which builds the URL exactly like the
+// method does (see formatURL) and returns it instead of sending the request
+func (r *Resource) generateURLFunc(m *Method) *Statement {
+	doc := fmt.Sprintf("%s returns the URL %s sends its request to, without sending it", m.urlFuncName(), m.funcName())
+	if m.MethodType == REST_METHOD && m.RestLiMethod() == protocol.Method_batch_get {
+		doc += ". All the keys are put in a single URL, regardless of how the request would be split into chunks"
+	}
+	def := AddWordWrappedComment(Empty(), doc).Line()
+	def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.urlFunc(m)).BlockFunc(func(def *Group) {
+		r.formatURL(def, m, Lit(""), Err())
+		def.Return(Id(UrlVar).Dot("String").Call(), Nil())
+	})
+	return def
+}
+
+// formatURL generates the code that builds the URL of the given method's request into the url variable, returning
+// errReturn if anything fails. It is shared by the methods and their companion URL functions, so that both always agree
+func (r *Resource) formatURL(def *Group, m *Method, errReturn ...Code) {
+	switch m.MethodType {
+	case REST_METHOD:
+		m.callResourcePath(def)
+		IfErrReturn(def, errReturn...)
+
+		switch m.RestLiMethod() {
+		case protocol.Method_get:
+			if !r.IsUnstructuredData {
+				addProjection(def)
+			}
+		case protocol.Method_batch_get:
+			r.addBatchIds(def, errReturn...)
+			addProjection(def)
+		case protocol.Method_batch_partial_update:
+			r.addBatchIds(def, errReturn...)
+		}
+	case FINDER:
+		pathFunc := ResourcePath
+		if m.finderAssocKey() != nil {
+			pathFunc = m.finderPathFunc()
+		}
+		m.callPathFunc(def, pathFunc)
+		IfErrReturn(def, errReturn...).Line()
+
+		def.List(Id("query"), Err()).Op(":=").Id("params").Dot(codecFuncName(EncodeFinderParams)).Call(
+			Id(ClientReceiver).Dot("ReducedCodec").Call())
+		IfErrReturn(def, errReturn...).Line()
+
+		def.Id(PathVar).Op("+=").Lit("?").Op("+").Id("query").Dot("Encode").Call()
+	case ACTION:
+		var pathFunc string
+		if m.OnEntity {
+			pathFunc = ResourceEntityPath
+		} else {
+			pathFunc = ResourcePath
+		}
+		m.callPathFunc(def, pathFunc)
+		IfErrReturn(def, errReturn...).Line()
+		def.Id(PathVar).Op("+=").Lit("?action=").Op("+").Id(m.actionNameConst())
+	}
+
+	r.callFormatQueryUrl(def)
+	IfErrReturn(def, errReturn...).Line()
+}
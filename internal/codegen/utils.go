@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"unicode"
 
+	"github.com/bored-engineer/go-restli/protocol"
 	. "github.com/dave/jennifer/jen"
 	"github.com/pkg/errors"
 )
@@ -16,20 +17,21 @@ import (
 const (
 	RestLiClient = "RestLiClient"
 
-	FormatQueryUrl     = "FormatQueryUrl"
-	ResourcePath       = "ResourcePath"
-	ResourceEntityPath = "ResourceEntityPath"
-	DoAndIgnore        = "DoAndIgnore"
-	DoAndDecode        = "DoAndDecode"
-	DoAndDecodeResult  = "doAndDecodeResult"
+	FormatQueryUrl      = "FormatQueryUrl"
+	ResourcePath        = "ResourcePath"
+	ResourceEntityPath  = "ResourceEntityPath"
+	DoAndIgnore         = "DoAndIgnore"
+	DoAndDecode         = "DoAndDecode"
+	DoAndDecodeEnvelope = "DoAndDecodeEnvelope"
+	DoAndDecodeResult   = "doAndDecodeResult"
 
 	FindBy = "FindBy"
 
-	ReqVar   = "req"
-	ResVar   = "res"
-	UrlVar   = "url"
-	PathVar  = "path"
-	PatchVar = "patch"
+	ReqVar  = "req"
+	ResVar  = "res"
+	UrlVar  = "url"
+	PathVar = "path"
+	CtxVar  = "ctx"
 
 	ClientReceiver      = "c"
 	ClientType          = "client"
@@ -47,7 +49,17 @@ func GenerateCode(specBytes []byte, outputDir string) error {
 		return errors.Wrapf(err, "go-restli: Could not deserialize GoRestliSpec")
 	}
 
-	codeFiles := append(TypeRegistry.GenerateTypeCode(), schemas.GenerateClientCode()...)
+	typeCodeFiles, err := TypeRegistry.GenerateTypeCode()
+	if err != nil {
+		return err
+	}
+
+	clientCodeFiles, err := schemas.GenerateClientCode()
+	if err != nil {
+		return err
+	}
+
+	codeFiles := append(typeCodeFiles, clientCodeFiles...)
 
 	for _, code := range codeFiles {
 		file, err := code.Write(outputDir)
@@ -58,9 +70,23 @@ func GenerateCode(specBytes []byte, outputDir string) error {
 		}
 	}
 
+	if AllImportsFile == "" {
+		return nil
+	}
 	return GenerateAllImportsFile(outputDir, codeFiles)
 }
 
+var (
+	// AllImportsFile is the path, relative to the package prefix, of the file that imports every generated package so
+	// that `go test` compiles all of them. It is not generated when empty
+	AllImportsFile = "all_imports_test.go"
+	// AllImportsPackage is the package of the AllImportsFile. When empty, it is main, or the external test package of
+	// the flat package when generating a flat package
+	AllImportsPackage string
+	// AllImportsFunc is the name of the empty test declared in the AllImportsFile, which is left out when empty
+	AllImportsFunc = "TestAllImports"
+)
+
 func GenerateAllImportsFile(outputDir string, codeFiles []*CodeFile) error {
 	imports := make(map[string]bool)
 	for _, code := range codeFiles {
@@ -69,15 +95,26 @@ func GenerateAllImportsFile(outputDir string, codeFiles []*CodeFile) error {
 		}
 		imports[code.PackagePath] = true
 	}
-	f := NewFile("main")
+	var f *File
+	if AllImportsPackage != "" {
+		f = NewFile(AllImportsPackage)
+	} else if FlatPackage {
+		// The flat package lives in the same directory, so this needs to be its external test package
+		f = NewFile(flatPackageName() + "_test")
+	} else {
+		f = NewFile("main")
+	}
+	addBuildTag(f)
 	for p := range imports {
 		f.Anon(p)
 	}
-	f.Func().Id("TestAllImports").Params(Op("*").Qual("testing", "T")).Block()
+	if AllImportsFunc != "" {
+		f.Func().Id(AllImportsFunc).Params(Op("*").Qual("testing", "T")).Block()
+	}
 
-	err := WriteJenFile(filepath.Join(outputDir, PackagePrefix, "all_imports_test.go"), f)
+	err := WriteJenFile(filepath.Join(outputDir, PackagePrefix, AllImportsFile), f)
 	if err != nil {
-		return errors.Wrapf(err, "Could not write all imports file: %+v", err)
+		return errors.Wrap(err, "go-restli: Could not write all imports file")
 	}
 	return nil
 }
@@ -96,34 +133,88 @@ func canonicalizeAccessor(accessor *Statement) string {
 	return label
 }
 
+func (m *Method) funcName() string {
+	switch m.MethodType {
+	case REST_METHOD:
+		return m.restMethodFuncName()
+	case ACTION:
+		return m.actionFuncName()
+	case FINDER:
+		return m.finderFuncName()
+	default:
+		return ""
+	}
+}
+
 func (r *Resource) clientFunc(m *Method) *Statement {
-	var name string
-	var params func(*Group)
-	var returnParams func(*Group)
+	return r.namedClientFunc(m.funcName(), m)
+}
+
+func (r *Resource) namedClientFunc(name string, m *Method) *Statement {
+	params, returnParams := r.clientFuncParams(m)
+	return Id(name).ParamsFunc(func(def *Group) {
+		def.Id(CtxVar).Qual("context", "Context")
+		params(def)
+	}).ParamsFunc(returnParams)
+}
 
+// clientFuncParams returns the functions that add the parameters of the client method for the given method, short of
+// its context, and its results
+func (r *Resource) clientFuncParams(m *Method) (params, returnParams func(*Group)) {
 	switch m.MethodType {
 	case REST_METHOD:
-		name = m.restMethodFuncName()
-		params = func(def *Group) { m.restMethodFuncParams(def, r.ResourceSchema) }
-		returnParams = m.restMethodFuncReturnParams
+		params = func(def *Group) { m.restMethodFuncParams(def, r) }
+		returnParams = func(def *Group) { m.restMethodFuncReturnParams(def, r) }
 	case ACTION:
-		name = m.actionFuncName()
 		params = m.actionFuncParams
 		returnParams = m.actionFuncReturnParams
 	case FINDER:
-		name = m.finderFuncName()
 		params = m.finderFuncParams
 		returnParams = m.finderFuncReturnParams
 	}
+	return params, returnParams
+}
+
+// withMethodTimeout applies the client's timeout for the given method to the context, unless the caller already set a
+// deadline. It also attaches the protocol.RequestInfo that describes the method to the context, for tracing
+func (r *Resource) withMethodTimeout(def *Group, m *Method, method protocol.RestLiMethod) {
+	r.namedWithMethodTimeout(def, m.funcName(), m, method)
+}
 
-	return Id(name).ParamsFunc(params).ParamsFunc(returnParams)
+func (r *Resource) namedWithMethodTimeout(def *Group, name string, m *Method, method protocol.RestLiMethod) {
+	def.List(Id(CtxVar), Id("cancel")).Op(":=").Id(ClientReceiver).Dot("WithMethodTimeout").Call(Id(CtxVar), RestLiMethod(method))
+	def.Defer().Id("cancel").Call()
+	r.withRequestInfo(def, name, m, method)
+}
+
+// withRequestInfo only attaches the protocol.RequestInfo to the context, for methods whose response outlives the call
+// and therefore cannot be bound to the method's timeout
+func (r *Resource) withRequestInfo(def *Group, name string, m *Method, method protocol.RestLiMethod) {
+	info := Dict{
+		Id("ResourceName"): Lit(r.Namespace),
+		Id("MethodName"):   Lit(name),
+		Id("Method"):       RestLiMethod(method),
+		Id("PathTemplate"): Lit(m.Path),
+	}
+	if m.featureFlag != "" {
+		info[Id("FeatureFlag")] = Lit(m.featureFlag)
+	}
+	def.Id(CtxVar).Op("=").Qual(ProtocolPackage, "WithRequestInfo").Call(Id(CtxVar), Qual(ProtocolPackage, "RequestInfo").Values(info)).Line()
 }
 
 func (r *Resource) addClientFunc(def *Statement, m *Method) *Statement {
 	return def.Func().Params(Id(ClientReceiver).Op("*").Id(ClientType)).Add(r.clientFunc(m))
 }
 
-func callDoAndDecode(def *Group) {
-	def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot(DoAndDecode).Call(Id(ReqVar), Op("&").Id(DoAndDecodeResult))
+// callDoAndDecodeBatchEnvelope decodes the response of a batch method into the envelope struct held by
+// DoAndDecodeResult. It must be called after addBatchIds, since array responses are mapped to the encoded keys
+func callDoAndDecodeBatchEnvelope(def *Group) {
+	def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot("DoAndDecodeBatchEnvelope").Call(Id(ReqVar), Id(BatchIdsParam), Op("&").Id(DoAndDecodeResult))
+	IfErrReturn(def, Nil(), Err()).Line()
+}
+
+// callDoAndDecodeEnvelope decodes the response into the envelope struct held by DoAndDecodeResult
+func callDoAndDecodeEnvelope(def *Group) {
+	def.List(Id("_"), Err()).Op("=").Id(ClientReceiver).Dot(DoAndDecodeEnvelope).Call(Id(ReqVar), Op("&").Id(DoAndDecodeResult))
 	IfErrReturn(def, Nil(), Err()).Line()
 }
@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorList aggregates multiple errors, so that they can all be reported at once
+type ErrorList []error
+
+// Add appends the given error to the list, if it is non-nil
+func (e *ErrorList) Add(err error) {
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+func (e ErrorList) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ValidateSpec runs everything GenerateCode does short of writing the files: the spec is parsed, all references are
+// resolved, cycles are flagged and the code for every type and resource is generated and rendered. Unlike GenerateCode,
+// it keeps going after the first error so that all the problems can be reported at once.
+func ValidateSpec(specBytes []byte) error {
+	var schemas GoRestliSpec
+
+	err := json.NewDecoder(bytes.NewBuffer(specBytes)).Decode(&schemas)
+	if err != nil {
+		return errors.Wrapf(err, "go-restli: Could not deserialize GoRestliSpec")
+	}
+
+	var errs ErrorList
+	typeCodeFiles, err := TypeRegistry.GenerateTypeCode()
+	errs.Add(err)
+	clientCodeFiles, err := schemas.GenerateClientCode()
+	errs.Add(err)
+
+	for _, code := range append(typeCodeFiles, clientCodeFiles...) {
+		errs.Add(code.validate())
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate renders the file without writing it anywhere
+func (f *CodeFile) validate() (err error) {
+	defer func() {
+		e := recover()
+		if e != nil {
+			err = errors.Errorf("go-restli: Could not generate %s: %+v", f.Identifier(), e)
+		}
+	}()
+
+	file, err := f.jenFile()
+	if err != nil {
+		return err
+	}
+	err = file.Render(ioutil.Discard)
+	if err != nil {
+		return errors.Wrapf(err, "go-restli: Could not generate %s", f.Identifier())
+	}
+	return nil
+}
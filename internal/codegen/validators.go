@@ -0,0 +1,299 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+const (
+	StrlenValidator = "strlen"
+	RegexValidator  = "regex"
+	RangeValidator  = "range"
+
+	Validate       = "Validate"
+	ValidateFields = "ValidateFields"
+)
+
+// Validators is the set of validators read from the "validate" property of fields (or of the typerefs they go
+// through). Any other validator is ignored.
+var Validators = []string{StrlenValidator, RegexValidator, RangeValidator}
+
+type boundsValidator struct {
+	Min *json.Number `json:"min"`
+	Max *json.Number `json:"max"`
+}
+
+type regexValidator struct {
+	Regex string `json:"regex"`
+}
+
+func isValidatorEnabled(name string) bool {
+	for _, v := range Validators {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// primitive returns the primitive type backing this type, either directly or through a typeref, or nil if there is none.
+// Typerefs generated as a time.Time are not considered to be backed by a primitive
+func (t *RestliType) primitive() *PrimitiveType {
+	if t.Primitive != nil {
+		return t.Primitive
+	}
+	if t.Reference != nil {
+		if ref, ok := t.Reference.Resolve().(*Typeref); ok && ref.isPrimitive() && !ref.isTime {
+			return ref.Ref.Primitive
+		}
+	}
+	return nil
+}
+
+// generatesValidate returns true if Validate and ValidateFields are generated on this record, i.e. unless they conflict
+// with one of its fields
+func (r *Record) generatesValidate() bool {
+	for _, f := range r.Fields {
+		switch ExportedIdentifier(f.Name) {
+		case Validate, ValidateFields:
+			return false
+		}
+	}
+	return true
+}
+
+// generateValidate generates a Validate function that checks the record against its schema without a server: required
+// fields must be set, enums must hold one of their symbols, unions must have exactly one member set (at most one if they
+// are optional) and fields must satisfy the enabled validators declared in the schema. The records held by the fields
+// are validated too. Every violation is reported in a protocol.ValidationErrors, under the path of the field.
+func (r *Record) generateValidate(def *Statement) {
+	if !r.generatesValidate() {
+		Logger.Printf("Warning: cannot generate %s on %s since it conflicts with a field", Validate, r.Identifier)
+		return
+	}
+
+	var checks []Code
+	var regexes []Code
+	for _, f := range r.Fields {
+		path := Qual(ProtocolPackage, "FieldPath").Call(Id("path"), Lit(f.Name))
+
+		if union := f.Type.Union; union != nil {
+			checks = append(checks, BlockFunc(func(def *Group) {
+				union.validateMembers(def, r.field(f), path, !f.IsOptional)
+			}))
+			continue
+		}
+
+		fieldChecks, fieldRegexes := r.fieldValidators(f, path)
+		regexes = append(regexes, fieldRegexes...)
+		if check := validateValue(&f.Type, r.field(f), path, 0); check != nil {
+			fieldChecks = append(fieldChecks, check)
+		}
+
+		if !f.IsOptional && f.DefaultValue == nil {
+			check := If(r.isUnset(f, r.field(f))).Block(
+				Id("errs").Dot("Add").Call(path, Qual(ProtocolPackage, "RequiredValidator"), Lit("is required")),
+			)
+			if len(fieldChecks) > 0 {
+				check.Else().Block(fieldChecks...)
+			}
+			checks = append(checks, check)
+		} else if len(fieldChecks) > 0 {
+			checks = append(checks, If(r.isSet(f, r.field(f))).Block(fieldChecks...))
+		}
+	}
+
+	if len(regexes) > 0 {
+		def.Var().DefsFunc(func(def *Group) {
+			for _, regex := range regexes {
+				def.Add(regex)
+			}
+		}).Line().Line()
+	}
+
+	def.Commentf("%s checks this %s against its schema: required fields must be set, enums must hold one of their "+
+		"symbols, unions must have at most one member set (exactly one if they are required) and fields must satisfy "+
+		"their validators. The records it holds are validated too. All the violations are returned at once in a "+
+		"protocol.ValidationErrors", Validate, r.Name).Line()
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, Validate).
+		Params().
+		Error().
+		BlockFunc(func(def *Group) {
+			def.Var().Id("errs").Qual(ProtocolPackage, "ValidationErrors")
+			def.Id(r.Receiver()).Dot(ValidateFields).Call(Lit(""), Op("&").Id("errs"))
+			def.Return(Id("errs").Dot("Err").Call())
+		}).Line().Line()
+
+	def.Commentf("%s adds the violations found by %s to errs, under the given path. It is called by the %s "+
+		"functions of the records that hold this %s", ValidateFields, Validate, Validate, r.Name).Line()
+	AddFuncOnReceiver(def, r.Receiver(), r.Name, ValidateFields).
+		Params(Id("path").String(), Id("errs").Op("*").Qual(ProtocolPackage, "ValidationErrors")).
+		BlockFunc(func(def *Group) {
+			for i, c := range checks {
+				if i != 0 {
+					def.Line()
+				}
+				def.Add(c)
+			}
+		}).Line().Line()
+}
+
+// validateValue returns the code that validates the given value of the given type, which is a pointer unless the type
+// is an array or a map, or nil if there is nothing to validate. Records and union typerefs are validated with their
+// ValidateFields function, enums must hold a known symbol, and the elements of arrays and maps are validated according
+// to their type
+func validateValue(t *RestliType, value, path *Statement, depth int) Code {
+	errs := Id("errs")
+	switch {
+	case t.Reference != nil:
+		switch ref := t.Reference.Resolve().(type) {
+		case *Record:
+			if ref.generatesValidate() {
+				return Add(value).Dot(ValidateFields).Call(path, errs)
+			}
+		case *Typeref:
+			if ref.Ref.Union != nil {
+				return Add(value).Dot(ValidateFields).Call(path, errs)
+			}
+		case *Enum:
+			return If(Qual(ref.PackagePath(), ref.Name+"Ordinal").Call(Op("*").Add(value)).Op("<").Lit(0)).Block(
+				errs.Clone().Dot("Add").Call(path, Qual(ProtocolPackage, "EnumValidator"), Lit("must be a symbol of "+ref.Name)),
+			)
+		}
+	case t.Array != nil, t.Map != nil:
+		items := t.Array
+		if items == nil {
+			items = t.Map
+		}
+		if items.Union != nil || !items.IsReferencedByPointer() {
+			return nil
+		}
+
+		index, item := fmt.Sprintf("i%d", depth), fmt.Sprintf("v%d", depth)
+		if depth == 0 {
+			index, item = "i", "v"
+		}
+		check := validateValue(items, Id(item), Qual(ProtocolPackage, "IndexPath").Call(path, Id(index)), depth+1)
+		if check == nil {
+			return nil
+		}
+		return For(List(Id(index), Id(item)).Op(":=").Range().Add(value)).Block(
+			If(Id(item).Op("!=").Nil()).Block(check),
+		)
+	}
+	return nil
+}
+
+func (r *Record) fieldValidators(f Field, path Code) (checks []Code, regexes []Code) {
+	if len(f.Validators) == 0 {
+		return nil, nil
+	}
+
+	pt := f.Type.primitive()
+	if pt == nil || pt.IsBytes() || pt.Type == "bool" {
+		Logger.Printf("Warning: validators are only supported on string and numeric fields, ignoring %s.%s", r.Identifier, f.Name)
+		return nil, nil
+	}
+
+	value := Op("*").Add(r.field(f))
+	if f.Type.Reference != nil {
+		value = pt.Cast(value)
+	}
+
+	fail := func(validator, message string, args ...interface{}) Code {
+		return Id("errs").Dot("Add").Call(path, Lit(validator), Lit(fmt.Sprintf(message, args...)))
+	}
+
+	var names []string
+	for name := range f.Validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !isValidatorEnabled(name) {
+			Logger.Printf("Warning: ignoring unsupported validator %q on %s.%s", name, r.Identifier, f.Name)
+			continue
+		}
+
+		switch name {
+		case StrlenValidator, RangeValidator:
+			isStrlen := name == StrlenValidator
+			if isStrlen != (pt.Type == "string") {
+				Logger.Printf("Warning: %q validator cannot be applied to %s field %s.%s", name, pt.Type, r.Identifier, f.Name)
+				continue
+			}
+
+			var bounds boundsValidator
+			if !r.parseValidator(f, name, &bounds) {
+				continue
+			}
+
+			subject := value
+			if isStrlen {
+				subject = Qual("unicode/utf8", "RuneCountInString").Call(value)
+			}
+			check := func(bound *json.Number, op, message string) {
+				if bound == nil {
+					return
+				}
+				if _, err := strconv.ParseFloat(bound.String(), 64); err != nil || (isStrlen && !isInt(*bound)) {
+					Logger.Printf("Warning: illegal %q bound %s on %s.%s", name, bound, r.Identifier, f.Name)
+					return
+				}
+				checks = append(checks, If(subject.Clone().Op(op).Op(bound.String())).Block(fail(name, message, bound)))
+			}
+			if isStrlen {
+				check(bounds.Min, "<", "must be at least %s characters long")
+				check(bounds.Max, ">", "must be at most %s characters long")
+			} else {
+				check(bounds.Min, "<", "must be at least %s")
+				check(bounds.Max, ">", "must be at most %s")
+			}
+		case RegexValidator:
+			if pt.Type != "string" {
+				Logger.Printf("Warning: %q validator cannot be applied to %s field %s.%s", name, pt.Type, r.Identifier, f.Name)
+				continue
+			}
+
+			var regex regexValidator
+			if !r.parseValidator(f, name, &regex) {
+				continue
+			}
+			// rest.li validators must match the entire value
+			pattern := "^(?:" + regex.Regex + ")$"
+			if _, err := regexp.Compile(pattern); err != nil {
+				Logger.Printf("Warning: cannot compile regex %q of %s.%s: %+v", regex.Regex, r.Identifier, f.Name, err)
+				continue
+			}
+
+			regexName := PrivateIdentifier(r.Name) + ExportedIdentifier(f.Name) + "Regex"
+			regexes = append(regexes, Id(regexName).Op("=").Qual("regexp", "MustCompile").Call(Lit(pattern)))
+			checks = append(checks, If(Op("!").Id(regexName).Dot("MatchString").Call(value)).
+				Block(fail(name, "must match %s", regex.Regex)))
+		}
+	}
+
+	return checks, regexes
+}
+
+func (r *Record) parseValidator(f Field, name string, v interface{}) bool {
+	d := json.NewDecoder(bytes.NewBuffer(f.Validators[name]))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		Logger.Printf("Warning: illegal %q validator on %s.%s: %+v", name, r.Identifier, f.Name, err)
+		return false
+	}
+	return true
+}
+
+func isInt(n json.Number) bool {
+	_, err := n.Int64()
+	return err == nil
+}
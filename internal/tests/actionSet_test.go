@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
 	conflictresolution "github.com/bored-engineer/go-restli/internal/tests/generated/conflictResolution"
@@ -11,20 +12,20 @@ import (
 
 func (s *TestServer) ActionsetEcho(t *testing.T, c Client) {
 	input := "Is anybody out there?"
-	output, err := c.EchoAction(&EchoActionParams{Input: &input})
+	output, err := c.EchoAction(context.Background(), &EchoActionParams{Input: &input})
 	require.NoError(t, err)
 	require.Equal(t, &input, output, "Invalid response from server")
 }
 
 func (s *TestServer) ActionsetReturnInt(t *testing.T, c Client) {
-	res, err := c.ReturnIntAction()
+	res, err := c.ReturnIntAction(context.Background())
 	require.NoError(t, err)
 	i := int32(42)
 	require.Equal(t, &i, res, "Invalid response from server")
 }
 
 func (s *TestServer) ActionsetReturnBool(t *testing.T, c Client) {
-	res, err := c.ReturnBoolAction()
+	res, err := c.ReturnBoolAction(context.Background())
 	require.NoError(t, err)
 	b := true
 	require.Equal(t, &b, res, "Invalid response from server")
@@ -33,7 +34,7 @@ func (s *TestServer) ActionsetReturnBool(t *testing.T, c Client) {
 func (s *TestServer) ActionsetEchoMessage(t *testing.T, c Client) {
 	msg := "test message"
 	message := conflictresolution.Message{Message: &msg}
-	res, err := c.EchoMessageAction(&EchoMessageActionParams{Message: &message})
+	res, err := c.EchoMessageAction(context.Background(), &EchoMessageActionParams{Message: &message})
 	require.NoError(t, err)
 	require.Equal(t, &message, res, "Invalid response from server")
 }
@@ -45,14 +46,14 @@ func (s *TestServer) ActionsetEchoMessageArray(t *testing.T, c Client) {
 		{Message: &msg1},
 		{Message: &msg2},
 	}
-	res, err := c.EchoMessageArrayAction(&EchoMessageArrayActionParams{Messages: messageArray})
+	res, err := c.EchoMessageArrayAction(context.Background(), &EchoMessageArrayActionParams{Messages: messageArray})
 	require.NoError(t, err)
 	require.Equal(t, messageArray, res, "Invalid response from server")
 }
 
 func (s *TestServer) ActionsetEchoStringArray(t *testing.T, c Client) {
 	stringArray := []string{"string one", "string two"}
-	res, err := c.EchoStringArrayAction(&EchoStringArrayActionParams{Strings: stringArray})
+	res, err := c.EchoStringArrayAction(context.Background(), &EchoStringArrayActionParams{Strings: stringArray})
 	require.NoError(t, err)
 	require.Equal(t, stringArray, res, "Invalid response from server")
 }
@@ -62,14 +63,14 @@ func (s *TestServer) ActionsetEchoStringMap(t *testing.T, c Client) {
 		"one": "string one",
 		"two": "string two",
 	}
-	res, err := c.EchoStringMapAction(&EchoStringMapActionParams{Strings: stringMap})
+	res, err := c.EchoStringMapAction(context.Background(), &EchoStringMapActionParams{Strings: stringMap})
 	require.NoError(t, err)
 	require.Equal(t, stringMap, res, "Invalid response from server")
 }
 
 func (s *TestServer) ActionsetEchoTyperefUrl(t *testing.T, c Client) {
 	var urlTyperef testsuite.Url = "http://rest.li"
-	res, err := c.EchoTyperefUrlAction(&EchoTyperefUrlActionParams{UrlTyperef: &urlTyperef})
+	res, err := c.EchoTyperefUrlAction(context.Background(), &EchoTyperefUrlActionParams{UrlTyperef: &urlTyperef})
 	require.NoError(t, err)
 	require.Equal(t, urlTyperef, *res, "Invalid response from server")
 }
@@ -80,7 +81,7 @@ func (s *TestServer) ActionsetEchoPrimitiveUnion(t *testing.T, c Client) {
 	union.PrimitivesUnion.Long = new(int64)
 	*union.PrimitivesUnion.Long = 100
 
-	res, err := c.EchoPrimitiveUnionAction(&EchoPrimitiveUnionActionParams{PrimitiveUnion: union})
+	res, err := c.EchoPrimitiveUnionAction(context.Background(), &EchoPrimitiveUnionActionParams{PrimitiveUnion: union})
 	require.NoError(t, err)
 	require.Equal(t, *union, *res, "Invalid response from server")
 }
@@ -90,7 +91,7 @@ func (s *TestServer) ActionsetEchoComplexTypesUnion(t *testing.T, c Client) {
 	union.ComplexTypeUnion.Fruits = new(conflictresolution.Fruits)
 	*union.ComplexTypeUnion.Fruits = conflictresolution.Fruits_APPLE
 
-	res, err := c.EchoComplexTypesUnionAction(&EchoComplexTypesUnionActionParams{ComplexTypesUnion: union})
+	res, err := c.EchoComplexTypesUnionAction(context.Background(), &EchoComplexTypesUnionActionParams{ComplexTypesUnion: union})
 	require.NoError(t, err)
 	require.Equal(t, *union, *res, "Invalid response from server")
 }
@@ -98,7 +99,7 @@ func (s *TestServer) ActionsetEchoComplexTypesUnion(t *testing.T, c Client) {
 func (s *TestServer) ActionsetEmptyResponse(t *testing.T, c Client) {
 	msg1 := "test message"
 	msg2 := "another message"
-	err := c.EmptyResponseAction(&EmptyResponseActionParams{
+	err := c.EmptyResponseAction(context.Background(), &EmptyResponseActionParams{
 		Message1: &conflictresolution.Message{Message: &msg1},
 		Message2: &conflictresolution.Message{Message: &msg2},
 	})
@@ -110,7 +111,7 @@ func (s *TestServer) ActionsetMultipleInputs(t *testing.T, c Client) {
 	str := "string"
 	url := testsuite.Url("http://rest.li")
 	msg := "test message"
-	res, err := c.MultipleInputsAction(&MultipleInputsActionParams{
+	res, err := c.MultipleInputsAction(context.Background(), &MultipleInputsActionParams{
 		String:         &str,
 		Message:        &conflictresolution.Message{Message: &msg},
 		UrlTyperef:     &url,
@@ -124,7 +125,7 @@ func (s *TestServer) ActionsetMultipleInputsNoOptional(t *testing.T, c Client) {
 	str := "string"
 	url := testsuite.Url("http//rest.li")
 	msg := "test message"
-	res, err := c.MultipleInputsAction(&MultipleInputsActionParams{
+	res, err := c.MultipleInputsAction(context.Background(), &MultipleInputsActionParams{
 		String:     &str,
 		Message:    &conflictresolution.Message{Message: &msg},
 		UrlTyperef: &url,
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
 	conflictresolution "github.com/bored-engineer/go-restli/internal/tests/generated/conflictResolution"
@@ -13,25 +14,25 @@ import (
 
 func (s *TestServer) CollectionGet(t *testing.T, c Client) {
 	id := int64(1)
-	res, err := c.Get(id)
+	res, err := c.Get(context.Background(), id)
 	require.NoError(t, err)
 	require.Equal(t, newMessage(id, "test message"), res)
 }
 
 func (s *TestServer) CollectionUpdate(t *testing.T, c Client) {
 	id := int64(1)
-	err := c.Update(id, newMessage(id, "updated message"))
+	err := c.Update(context.Background(), id, newMessage(id, "updated message"))
 	require.NoError(t, err)
 }
 
 func (s *TestServer) CollectionDelete(t *testing.T, c Client) {
 	id := int64(1)
-	err := c.Delete(id)
+	err := c.Delete(context.Background(), id)
 	require.NoError(t, err)
 }
 
 func (s *TestServer) CollectionGet404(t *testing.T, c Client) {
-	m, err := c.Get(2)
+	m, err := c.Get(context.Background(), 2)
 	require.Errorf(t, err, "Did not receive an error from the server (got %+v)", m)
 	require.Equal(t, 404, err.(*protocol.RestLiError).Status, "Unexpected status code from server")
 }
@@ -43,13 +44,13 @@ func (s *TestServer) CollectionUpdate400(t *testing.T, c Client) {
 
 func (s *TestServer) SubCollectionOfCollectionGet(t *testing.T, c Client) {
 	id := int64(100)
-	res, err := colletionSubCollection.NewClient(s.client).Get(1, id)
+	res, err := colletionSubCollection.NewClient(s.client).Get(context.Background(), 1, id)
 	require.NoError(t, err)
 	require.Equal(t, newMessage(id, "sub collection message"), res)
 }
 
 func (s *TestServer) SubSimpleOfCollectionGet(t *testing.T, c Client) {
-	res, err := colletionSubSimple.NewClient(s.client).Get(1)
+	res, err := colletionSubSimple.NewClient(s.client).Get(context.Background(), 1)
 	require.NoError(t, err)
 	msg := "sub simple message"
 	require.Equal(t, &conflictresolution.Message{Message: &msg}, res, "Invalid response from server")
@@ -59,7 +60,7 @@ func (s *TestServer) CollectionSearchFinder(t *testing.T, c Client) {
 	keyword := "message"
 	params := &FindBySearchParams{Keyword: &keyword}
 	expectedMessages := []*conflictresolution.Message{newMessage(1, "test message"), newMessage(2, "another message")}
-	res, err := c.FindBySearch(params)
+	res, err := c.FindBySearch(context.Background(), params)
 	require.NoError(t, err)
 	require.Equal(t, expectedMessages, res)
 }
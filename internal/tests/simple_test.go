@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
 	conflictresolution "github.com/bored-engineer/go-restli/internal/tests/generated/conflictResolution"
@@ -9,7 +10,7 @@ import (
 )
 
 func (s *TestServer) SimpleGet(t *testing.T, c Client) {
-	res, err := c.Get()
+	res, err := c.Get(context.Background())
 	require.NoError(t, err)
 	msg := "test message"
 	require.Equal(t, &msg, res.Message, "Invalid response from server")
@@ -17,11 +18,11 @@ func (s *TestServer) SimpleGet(t *testing.T, c Client) {
 
 func (s *TestServer) SimpleUpdate(t *testing.T, c Client) {
 	msg := "updated message"
-	err := c.Update(&conflictresolution.Message{Message: &msg})
+	err := c.Update(context.Background(), &conflictresolution.Message{Message: &msg})
 	require.NoError(t, err)
 }
 
 func (s *TestServer) SimpleDelete(t *testing.T, c Client) {
-	err := c.Delete()
+	err := c.Delete(context.Background())
 	require.NoError(t, err)
 }
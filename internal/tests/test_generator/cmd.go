@@ -20,6 +20,8 @@ const (
 
 func init() {
 	codegen.PackagePrefix = "github.com/bored-engineer/go-restli/internal/tests/" + generatedPackageSuffix
+	// The test suite also covers the schemas that are not used by any of its resources
+	codegen.AllTypes = true
 }
 
 func main() {
@@ -28,7 +30,7 @@ func main() {
 
 	restSpecs, err := filepath.Glob("rest.li-test-suite/client-testsuite/restspecs/*")
 	panicIfErrf(err, "Could not glob restspecs")
-	specBytes, err := cmd.ExecuteJar("rest.li-test-suite/client-testsuite/schemas", restSpecs)
+	specBytes, err := cmd.ExecuteJar([]string{"rest.li-test-suite/client-testsuite/schemas"}, restSpecs)
 	panicIfErrf(err, "Could not execute jar")
 	err = codegen.GenerateCode(specBytes, tmpDir)
 	panicIfErrf(err, "Failed to generate code")
@@ -0,0 +1,96 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BatchError is returned by batch methods when the server could not process some of the keys. The results for the
+// keys that were processed successfully are always returned alongside it.
+type BatchError struct {
+	// Errors is keyed by the encoded key
+	Errors map[string]*RestLiError
+	// KeyErrors holds the same errors as Errors, but keyed the same way as the results returned alongside this error,
+	// i.e. by the decoded key, or by its canonical encoding (see the generated BatchKey funcs) for complex keys
+	KeyErrors map[interface{}]*RestLiError
+}
+
+func (b *BatchError) Error() string {
+	keys := make([]string, 0, len(b.Errors))
+	for k := range b.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("go-restli: Batch request failed for %d keys: %s", len(keys), strings.Join(keys, ", "))
+}
+
+// BatchSizeError is returned by batch methods when they are called with more keys than the server accepts and
+// RestLiClient.BatchChunkSize is not set
+type BatchSizeError struct {
+	Method       RestLiMethod
+	Size         int
+	MaxBatchSize int
+}
+
+func (e *BatchSizeError) Error() string {
+	return fmt.Sprintf("go-restli: Cannot send a %s request for %d keys, the resource only accepts up to %d keys "+
+		"(set RestLiClient.BatchChunkSize to split batches into multiple requests)", e.Method, e.Size, e.MaxBatchSize)
+}
+
+// ChunkBatch splits a batch request for the given number of keys into chunks, calling do with the bounds of each chunk
+// in order. maxBatchSize is the max batch size declared by the resource, or 0 if there is none, and validated is true
+// if it is enforced by the server.
+//
+// Batches are only split when BatchChunkSize is positive, into chunks of at most BatchChunkSize keys, or maxBatchSize
+// keys if it is smaller. Otherwise, a single chunk is sent, unless it exceeds an enforced maxBatchSize in which case a
+// BatchSizeError is returned without calling do.
+//
+// The BatchErrors returned by do are merged together and returned after all the chunks have been sent, whereas any
+// other error is returned immediately.
+func (c *RestLiClient) ChunkBatch(method RestLiMethod, size, maxBatchSize int, validated bool, do func(start, end int) error) error {
+	chunkSize := size
+	if c.BatchChunkSize > 0 {
+		chunkSize = c.BatchChunkSize
+		if maxBatchSize > 0 && maxBatchSize < chunkSize {
+			chunkSize = maxBatchSize
+		}
+	} else if validated && maxBatchSize > 0 && size > maxBatchSize {
+		return &BatchSizeError{Method: method, Size: size, MaxBatchSize: maxBatchSize}
+	}
+
+	var batchError *BatchError
+	for start := 0; ; start += chunkSize {
+		end := start + chunkSize
+		if end > size {
+			end = size
+		}
+
+		err := do(start, end)
+		if chunkError, ok := err.(*BatchError); ok {
+			if batchError == nil {
+				batchError = &BatchError{Errors: make(map[string]*RestLiError)}
+			}
+			for k, v := range chunkError.Errors {
+				batchError.Errors[k] = v
+			}
+			if chunkError.KeyErrors != nil && batchError.KeyErrors == nil {
+				batchError.KeyErrors = make(map[interface{}]*RestLiError)
+			}
+			for k, v := range chunkError.KeyErrors {
+				batchError.KeyErrors[k] = v
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if end >= size {
+			break
+		}
+	}
+
+	if batchError != nil {
+		return batchError
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func collectChunks(c *RestLiClient, size, maxBatchSize int, validated bool) (chunks [][2]int, err error) {
+	err = c.ChunkBatch(Method_batch_get, size, maxBatchSize, validated, func(start, end int) error {
+		chunks = append(chunks, [2]int{start, end})
+		return nil
+	})
+	return chunks, err
+}
+
+func TestRestLiClient_ChunkBatch(t *testing.T) {
+	tests := []struct {
+		Name           string
+		BatchChunkSize int
+		Size           int
+		MaxBatchSize   int
+		Validated      bool
+		Expected       [][2]int
+	}{
+		{Name: "no limit", Size: 5, Expected: [][2]int{{0, 5}}},
+		{Name: "empty", Size: 0, Expected: [][2]int{{0, 0}}},
+		{Name: "unvalidated limit", Size: 5, MaxBatchSize: 2, Expected: [][2]int{{0, 5}}},
+		{Name: "within limit", Size: 2, MaxBatchSize: 2, Validated: true, Expected: [][2]int{{0, 2}}},
+		{Name: "chunk size", BatchChunkSize: 2, Size: 5, Expected: [][2]int{{0, 2}, {2, 4}, {4, 5}}},
+		{Name: "declared limit", BatchChunkSize: 10, Size: 5, MaxBatchSize: 3, Validated: true, Expected: [][2]int{{0, 3}, {3, 5}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := &RestLiClient{BatchChunkSize: test.BatchChunkSize}
+			chunks, err := collectChunks(c, test.Size, test.MaxBatchSize, test.Validated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.Expected, chunks) {
+				t.Errorf("Expected: %v, Got: %v", test.Expected, chunks)
+			}
+		})
+	}
+}
+
+func TestRestLiClient_ChunkBatchExceedsLimit(t *testing.T) {
+	chunks, err := collectChunks(new(RestLiClient), 3, 2, true)
+	if _, ok := err.(*BatchSizeError); !ok {
+		t.Fatalf("Expected a BatchSizeError, got %+v", err)
+	}
+	if len(chunks) != 0 {
+		t.Errorf("No request should be sent when the batch is too large")
+	}
+}
+
+func TestRestLiClient_ChunkBatchErrors(t *testing.T) {
+	c := &RestLiClient{BatchChunkSize: 1}
+	err := c.ChunkBatch(Method_batch_get, 3, 0, false, func(start, end int) error {
+		if start == 1 {
+			return nil
+		}
+		return &BatchError{
+			Errors:    map[string]*RestLiError{string(rune('a' + start)): {Status: 404}},
+			KeyErrors: map[interface{}]*RestLiError{int64(start): {Status: 404}},
+		}
+	})
+	batchError, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("Expected a BatchError, got %+v", err)
+	}
+	if len(batchError.Errors) != 2 || batchError.Errors["a"] == nil || batchError.Errors["c"] == nil {
+		t.Errorf("BatchErrors were not merged: %+v", batchError.Errors)
+	}
+	if len(batchError.KeyErrors) != 2 || batchError.KeyErrors[int64(0)] == nil || batchError.KeyErrors[int64(2)] == nil {
+		t.Errorf("BatchErrors were not merged: %+v", batchError.KeyErrors)
+	}
+
+	expected := errors.New("fail")
+	calls := 0
+	err = c.ChunkBatch(Method_batch_get, 3, 0, false, func(start, end int) error {
+		calls++
+		return expected
+	})
+	if err != expected || calls != 1 {
+		t.Errorf("Expected the first error to be returned immediately, got %+v after %d calls", err, calls)
+	}
+}
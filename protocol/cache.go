@@ -0,0 +1,170 @@
+package protocol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache stores the responses of GET and BATCH_GET requests, keyed by their URL and the credentials sent with them
+// (their Authorization and Cookie headers), when it is set as RestLiClient.Cache. Responses are cached following their
+// Cache-Control header: those with a max-age are kept for that long, whereas the others are kept for
+// RestLiClient.CacheTTL. Responses marked no-cache are only kept if they have an ETag, and are revalidated with the
+// server whenever they are used. Responses marked no-store, or that vary on every header, are never cached. Responses
+// that vary on some headers are only used for the requests that send the same values. Note that credentials added by a
+// RequestSigner are not part of the key since requests are signed after the cache is looked up, so a Cache must not be
+// shared between clients that sign requests differently.
+type Cache interface {
+	// Get returns the value stored under the given key, unless it was evicted or its TTL expired
+	Get(key string) ([]byte, bool)
+	// Set stores the given value under the given key for at most ttl
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// cacheEntry is the value stored in the Cache for each response
+type cacheEntry struct {
+	ContentType string `json:"contentType,omitempty"`
+	ETag        string `json:"etag,omitempty"`
+	// Revalidate is set on the entries that must be revalidated with the server, by sending their ETag in an
+	// If-None-Match header, before they can be used
+	Revalidate bool `json:"revalidate,omitempty"`
+	// Vary holds the values of the request headers named by the response's Vary header, which must match for the entry
+	// to be used
+	Vary map[string]string `json:"vary,omitempty"`
+	Body []byte            `json:"body"`
+}
+
+// cacheKeyHeaders are the request headers that carry the caller's credentials. Their values are hashed into the cache
+// key so that responses are never shared between callers
+var cacheKeyHeaders = []string{"Authorization", "Cookie"}
+
+// response returns the response that is given to the consumers of the cached body
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// cacheKey returns the key of the given request in the Cache, or false if its response cannot be cached
+func (c *RestLiClient) cacheKey(req *http.Request) (string, bool) {
+	if c.Cache == nil || req.Method != http.MethodGet {
+		return "", false
+	}
+	switch req.Header.Get(RestLiHeader_Method) {
+	case Method_get.String(), Method_batch_get.String():
+		key := req.URL.String()
+		h := sha256.New()
+		hasCredentials := false
+		for _, name := range cacheKeyHeaders {
+			for _, value := range req.Header[name] {
+				hasCredentials = true
+				h.Write([]byte(name + ":" + value + "\n"))
+			}
+		}
+		if hasCredentials {
+			key += " " + hex.EncodeToString(h.Sum(nil))
+		}
+		return key, true
+	default:
+		return "", false
+	}
+}
+
+// cachedResponse returns the entry stored in the Cache under the given key, if any and if the given request sends the
+// same values for the headers the entry varies on
+func (c *RestLiClient) cachedResponse(key string, req *http.Request) *cacheEntry {
+	value, ok := c.Cache.Get(key)
+	if !ok {
+		return nil
+	}
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(value, entry); err != nil {
+		// Treat entries that cannot be read as misses, the response will simply be cached again
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+	return entry
+}
+
+// cacheResponse stores the given response in the Cache if it is successful, following its Cache-Control and Vary
+// headers
+func (c *RestLiClient) cacheResponse(key string, req *http.Request, res *http.Response, body []byte) {
+	if res.StatusCode != http.StatusOK {
+		return
+	}
+
+	var vary map[string]string
+	for _, header := range res.Header["Vary"] {
+		for _, name := range strings.Split(header, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch name {
+			case "":
+				continue
+			case "*":
+				return
+			}
+			if vary == nil {
+				vary = make(map[string]string)
+			}
+			vary[name] = req.Header.Get(name)
+		}
+	}
+
+	ttl := c.CacheTTL
+	noCache := false
+	for _, directive := range strings.Split(res.Header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if maxAge, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				ttl = time.Duration(maxAge) * time.Second
+			}
+		}
+	}
+
+	entry := &cacheEntry{
+		ContentType: res.Header.Get("Content-Type"),
+		ETag:        res.Header.Get("ETag"),
+		Vary:        vary,
+		Body:        body,
+	}
+	if noCache || ttl <= 0 {
+		if entry.ETag == "" || c.CacheTTL <= 0 {
+			return
+		}
+		entry.Revalidate = true
+		ttl = c.CacheTTL
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.Cache.Set(key, value, ttl)
+}
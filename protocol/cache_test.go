@@ -0,0 +1,186 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mapCache map[string][]byte
+
+func (m mapCache) Get(key string) ([]byte, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m mapCache) Set(key string, value []byte, _ time.Duration) {
+	m[key] = value
+}
+
+func TestRestLiClient_Cache(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/maxAge":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/noStore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(`{"foo":"bar"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		method   RestLiMethod
+		requests int
+	}{
+		{path: "/maxAge", method: Method_get, requests: 1},
+		{path: "/maxAge", method: Method_finder, requests: 2},
+		{path: "/noStore", method: Method_get, requests: 2},
+		{path: "/etag", method: Method_batch_get, requests: 2},
+		{path: "/none", method: Method_get, requests: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.path+"/"+test.method.String(), func(t *testing.T) {
+			requests = 0
+			c := &RestLiClient{Cache: make(mapCache), CacheTTL: time.Minute}
+			for i := 0; i < 2; i++ {
+				req, err := c.GetRequest(context.Background(), mustParse(server.URL+test.path), test.method)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var v struct{ Foo string }
+				if _, err = c.DoAndDecode(req, &v); err != nil {
+					t.Fatal(err)
+				}
+				if v.Foo != "bar" {
+					t.Errorf("Unexpected result: %+v", v)
+				}
+			}
+			if requests != test.requests {
+				t.Errorf("Expected %d requests, got %d", test.requests, requests)
+			}
+		})
+	}
+}
+
+func TestRestLiClient_CacheKey(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		switch r.URL.Path {
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Language")
+		case "/varyAll":
+			w.Header().Set("Vary", "*")
+		}
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(`{"foo":"bar"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		header   string
+		values   []string
+		requests int
+	}{
+		{path: "/auth", header: "Authorization", values: []string{"Bearer a", "Bearer a"}, requests: 1},
+		{path: "/auth", header: "Authorization", values: []string{"Bearer a", "Bearer b"}, requests: 2},
+		{path: "/auth", header: "Authorization", values: []string{"Bearer a", ""}, requests: 2},
+		{path: "/cookie", header: "Cookie", values: []string{"session=a", "session=b"}, requests: 2},
+		{path: "/vary", header: "Accept-Language", values: []string{"en", "en"}, requests: 1},
+		{path: "/vary", header: "Accept-Language", values: []string{"en", "fr"}, requests: 2},
+		{path: "/varyAll", header: "Accept-Language", values: []string{"en", "en"}, requests: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			requests = 0
+			c := &RestLiClient{Cache: make(mapCache)}
+			for _, value := range test.values {
+				req, err := c.GetRequest(context.Background(), mustParse(server.URL+test.path), Method_get)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if value != "" {
+					req.Header.Set(test.header, value)
+				}
+				if _, err = c.DoAndIgnore(req); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if requests != test.requests {
+				t.Errorf("Expected %d requests for %s %q, got %d", test.requests, test.header, test.values, requests)
+			}
+		})
+	}
+}
+
+func TestRestLiClient_CacheHooks(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(`{"foo":"bar"}`))
+	}))
+	defer server.Close()
+
+	enabled := true
+	var traced, observed int
+	c := &RestLiClient{
+		Cache:        make(mapCache),
+		FeatureFlags: FeatureFlagsFunc(func(string) bool { return enabled }),
+		Tracer: TracerFunc(func(ctx context.Context, _ RequestInfo, _ *http.Request) (context.Context, func(*http.Response, error)) {
+			return ctx, func(*http.Response, error) { traced++ }
+		}),
+		Metrics: MetricsFunc(func(_ string, status int, _ time.Duration) {
+			if status != http.StatusOK {
+				t.Errorf("Unexpected status: %d", status)
+			}
+			observed++
+		}),
+	}
+
+	info := RequestInfo{ResourceName: "testsuite.simple", MethodName: "Get", Method: Method_get, FeatureFlag: "flag"}
+	send := func() error {
+		req, err := c.GetRequest(WithRequestInfo(context.Background(), info), mustParse(server.URL), Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.DoAndIgnore(req)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := send(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second request to be served from the cache, got %d requests", requests)
+	}
+	if traced != 2 || observed != 2 {
+		t.Errorf("Expected both requests to be traced and observed, got %d and %d", traced, observed)
+	}
+
+	enabled = false
+	var disabled *MethodDisabledError
+	if err := send(); !errors.As(err, &disabled) {
+		t.Errorf("Expected cache hits of disabled methods to fail, got: %v", err)
+	}
+}
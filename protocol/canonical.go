@@ -0,0 +1,28 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CanonicalJSON serializes the given value to its canonical JSON form: all object keys (including struct fields and
+// union members) are sorted and there is no insignificant whitespace. Two values that are equal always produce the same
+// bytes, making the output suitable as a cache key or a test fixture.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-tripping through interface{} sorts the keys of every object since encoding/json always marshals maps in key
+	// order. Numbers are kept as-is to avoid losing precision on int64s
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	err = decoder.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(raw)
+}
@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	type union struct {
+		String *string `json:"string,omitempty"`
+		Int    *int64  `json:"int,omitempty"`
+	}
+	type record struct {
+		Z     int64            `json:"z"`
+		A     map[string]int64 `json:"a"`
+		Union union            `json:"union"`
+	}
+
+	i := int64(9007199254740993)
+	data, err := CanonicalJSON(&record{
+		Z:     1,
+		A:     map[string]int64{"c": 3, "b": 2},
+		Union: union{Int: &i},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"a":{"b":2,"c":3},"union":{"int":9007199254740993},"z":1}`
+	if string(data) != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, string(data))
+	}
+}
@@ -8,24 +8,119 @@ import (
 type RestLiCodec struct {
 	encoder func(string) string
 	decoder func(string) (string, error)
+	// lenientBool is set by WithLenientBool
+	lenientBool bool
+	// plainFloats is set by WithPlainFloats
+	plainFloats bool
+	// protocolVersion1 is set on the codecs of clients that use RestLiProtocolVersion1
+	protocolVersion1 bool
 }
 
+// WithLenientBool returns a copy of this codec whose DecodeBool also accepts the representations used by some gateways,
+// namely quoted booleans ("true" and "false") and integers (1 and 0). By default, only true and false are accepted.
+func (r RestLiCodec) WithLenientBool() RestLiCodec {
+	r.lenientBool = true
+	return r
+}
+
+// WithPlainFloats returns a copy of this codec whose EncodeFloat32 and EncodeFloat64 never use scientific notation, e.g.
+// 1000000 instead of 1e+06, since some rest.li servers reject exponents. By default, the shortest representation is
+// used.
+func (r RestLiCodec) WithPlainFloats() RestLiCodec {
+	r.plainFloats = true
+	return r
+}
+
+// withOptionsOf returns a copy of this codec with the options of the given codec, see WithLenientBool and
+// WithPlainFloats
+func (r RestLiCodec) withOptionsOf(other RestLiCodec) RestLiCodec {
+	r.lenientBool = other.lenientBool
+	r.plainFloats = other.plainFloats
+	r.protocolVersion1 = other.protocolVersion1
+	return r
+}
+
+// RestLiUrlEncoder escapes strings following the rest.li spec, i.e. every character other than the unreserved ones
+// (letters, digits, '-', '.', '_' and '~') is percent-encoded, including spaces and the characters that are part of the
+// protocol's syntax. In particular, '/' is always escaped such that keys remain a single path segment, and so are the
+// "." and ".." strings, which would otherwise be removed from paths. Unlike url.QueryEscape, spaces are encoded as %20
+// rather than '+' since the encoded strings are also used in paths, where '+' is a literal plus sign.
+// https://linkedin.github.io/rest.li/spec/protocol#escaping
 var RestLiUrlEncoder = RestLiCodec{
-	encoder: url.QueryEscape,
+	encoder: escapeRestLiString,
+	// Other rest.li implementations may still encode spaces as '+', which QueryUnescape handles
 	decoder: url.QueryUnescape,
 }
 
+// RestLiReducedEncoder only escapes the characters that are part of the rest.li protocol's syntax. It is meant for values
+// that get escaped again as a whole, such as query parameters that are set in a url.Values
 var RestLiReducedEncoder = RestLiCodec{
 	encoder: strings.NewReplacer(
+		"%", url.QueryEscape("%"),
 		",", url.QueryEscape(","),
 		"(", url.QueryEscape("("),
 		")", url.QueryEscape(")"),
 		"'", url.QueryEscape("'"),
 		":", url.QueryEscape(":")).Replace,
-	decoder: url.QueryUnescape,
+	// Unlike QueryUnescape, PathUnescape leaves '+' as is since it is never used to encode spaces here
+	decoder: url.PathUnescape,
+}
+
+// UrlCodec returns the client's Codec, or RestLiUrlEncoder if it has none. The generated clients encode the keys in
+// their paths and decode the keys in their responses with it. If the client uses RestLiProtocolVersion1, the codec
+// follows rest.li 1.0 (see RestLiProtocolVersion1)
+func (c *RestLiClient) UrlCodec() RestLiCodec {
+	codec := c.Codec
+	if codec.encoder == nil {
+		codec = RestLiUrlEncoder.withOptionsOf(c.Codec)
+	}
+	codec.protocolVersion1 = c.isProtocolVersion1()
+	return codec
+}
+
+// ReducedCodec returns RestLiReducedEncoder with the options of the client's UrlCodec. The generated clients encode
+// the query parameters of finders with it, since query parameters are escaped again as a whole
+func (c *RestLiClient) ReducedCodec() RestLiCodec {
+	return RestLiReducedEncoder.withOptionsOf(c.UrlCodec())
 }
 
 type RestLiEncodable interface {
 	RestLiEncode(codec RestLiCodec) (data string, err error)
 	RestLiDecode(codec RestLiCodec, data string) (err error)
 }
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func escapeRestLiString(s string) string {
+	// Both are made of unreserved characters, but would be removed from paths as dot-segments (RFC 3986, section 5.2.4)
+	if s == "." || s == ".." {
+		return strings.Repeat("%2E", len(s))
+	}
+
+	escapedChars := 0
+	for i := 0; i < len(s); i++ {
+		if !isUnreserved(s[i]) {
+			escapedChars++
+		}
+	}
+	if escapedChars == 0 {
+		return s
+	}
+
+	const hex = "0123456789ABCDEF"
+	var buf strings.Builder
+	buf.Grow(len(s) + 2*escapedChars)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; isUnreserved(c) {
+			buf.WriteByte(c)
+		} else {
+			buf.WriteByte('%')
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&15])
+		}
+	}
+	return buf.String()
+}
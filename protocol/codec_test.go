@@ -0,0 +1,219 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestLiReducedEncoder(t *testing.T) {
+	s := "a (b:c), 'd' 100%+"
+
+	expected := "a %28b%3Ac%29%2C %27d%27 100%25+"
+	encoded := RestLiReducedEncoder.EncodeString(s)
+	if encoded != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, encoded)
+	}
+
+	var decoded string
+	err := RestLiReducedEncoder.DecodeString(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != s {
+		t.Errorf("Expected: %s, Got: %s", s, decoded)
+	}
+}
+
+func TestRestLiUrlEncoder(t *testing.T) {
+	tests := []struct {
+		Decoded string
+		Encoded string
+	}{
+		{Decoded: "abc-XYZ_0.9~", Encoded: "abc-XYZ_0.9~"},
+		{Decoded: ",()':", Encoded: "%2C%28%29%27%3A"},
+		{Decoded: "a b+c", Encoded: "a%20b%2Bc"},
+		{Decoded: "%/?#&=[]@!$*;", Encoded: "%25%2F%3F%23%26%3D%5B%5D%40%21%24%2A%3B"},
+		{Decoded: "a/b/", Encoded: "a%2Fb%2F"},
+		{Decoded: ".", Encoded: "%2E"},
+		{Decoded: "..", Encoded: "%2E%2E"},
+		{Decoded: "...", Encoded: "..."},
+		{Decoded: "../a", Encoded: "..%2Fa"},
+		{Decoded: "List(a:b)", Encoded: "List%28a%3Ab%29"},
+		{Decoded: "é", Encoded: "%C3%A9"},
+		{Decoded: "", Encoded: "''"},
+		{Decoded: "''", Encoded: "%27%27"},
+	}
+	for _, test := range tests {
+		encoded := RestLiUrlEncoder.EncodeString(test.Decoded)
+		if encoded != test.Encoded {
+			t.Errorf("Expected: %s, Got: %s", test.Encoded, encoded)
+		}
+
+		var decoded string
+		err := RestLiUrlEncoder.DecodeString(encoded, &decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != test.Decoded {
+			t.Errorf("Expected: %s, Got: %s", test.Decoded, decoded)
+		}
+	}
+
+	var decoded string
+	err := RestLiUrlEncoder.DecodeString("a+b", &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != "a b" {
+		t.Errorf("'+' should be decoded as a space, got %q", decoded)
+	}
+}
+
+func TestRestLiCodec_DecodeBool(t *testing.T) {
+	tests := []struct {
+		Data     string
+		Strict   bool
+		Lenient  bool
+		Expected bool
+	}{
+		{Data: "true", Strict: true, Lenient: true, Expected: true},
+		{Data: "false", Strict: true, Lenient: true, Expected: false},
+		{Data: `"true"`, Lenient: true, Expected: true},
+		{Data: "%22false%22", Lenient: true, Expected: false},
+		{Data: "1", Lenient: true, Expected: true},
+		{Data: "0", Lenient: true, Expected: false},
+		{Data: "TRUE"},
+		{Data: "2"},
+		{Data: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.Data, func(t *testing.T) {
+			for _, c := range []struct {
+				Codec RestLiCodec
+				Valid bool
+			}{
+				{Codec: RestLiUrlEncoder, Valid: test.Strict},
+				{Codec: RestLiUrlEncoder.WithLenientBool(), Valid: test.Lenient},
+			} {
+				var v bool
+				err := c.Codec.DecodeBool(test.Data, &v)
+				if !c.Valid {
+					if err == nil {
+						t.Errorf("Expected an error when lenient=%t", c.Codec.lenientBool)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("Unexpected error when lenient=%t: %+v", c.Codec.lenientBool, err)
+				} else if v != test.Expected {
+					t.Errorf("Expected %t when lenient=%t, got %t", test.Expected, c.Codec.lenientBool, v)
+				}
+			}
+		})
+	}
+}
+
+func TestRestLiCodec_WithPlainFloats(t *testing.T) {
+	tests := []struct {
+		Value    float64
+		Default  string
+		Expected string
+	}{
+		{Value: 1e6, Default: "1e+06", Expected: "1000000"},
+		{Value: 1.5e21, Default: "1.5e+21", Expected: "1500000000000000000000"},
+		{Value: 1e-7, Default: "1e-07", Expected: "0.0000001"},
+		{Value: -2.5e-5, Default: "-2.5e-05", Expected: "-0.000025"},
+		{Value: 3.25, Default: "3.25", Expected: "3.25"},
+		{Value: 0, Default: "0", Expected: "0"},
+	}
+	codec := RestLiUrlEncoder.WithPlainFloats()
+	for _, test := range tests {
+		t.Run(test.Expected, func(t *testing.T) {
+			if encoded := RestLiUrlEncoder.EncodeFloat64(test.Value); encoded != test.Default {
+				t.Errorf("Expected %q by default, got %q", test.Default, encoded)
+			}
+
+			encoded := codec.EncodeFloat64(test.Value)
+			if encoded != test.Expected {
+				t.Errorf("Expected %q, got %q", test.Expected, encoded)
+			}
+			var decoded float64
+			if err := codec.DecodeFloat64(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded != test.Value {
+				t.Errorf("%q did not round-trip, got %v", encoded, decoded)
+			}
+
+			encoded32 := codec.EncodeFloat32(float32(test.Value))
+			var decoded32 float32
+			if err := codec.DecodeFloat32(encoded32, &decoded32); err != nil {
+				t.Fatal(err)
+			}
+			if decoded32 != float32(test.Value) {
+				t.Errorf("%q did not round-trip, got %v", encoded32, decoded32)
+			}
+		})
+	}
+
+	if encoded := codec.EncodeFloat32(1e6); encoded != "1000000" {
+		t.Errorf("Expected 1000000, got %q", encoded)
+	}
+}
+
+type testBool bool
+
+func (b *testBool) RestLiEncode(codec RestLiCodec) (string, error) {
+	return codec.EncodeBool(bool(*b)), nil
+}
+
+func (b *testBool) RestLiDecode(codec RestLiCodec, data string) error {
+	return codec.DecodeBool(data, (*bool)(b))
+}
+
+func TestRestLiClient_Codec(t *testing.T) {
+	c := &RestLiClient{}
+	urlCodec, reducedCodec := c.UrlCodec(), c.ReducedCodec()
+	if encoded := urlCodec.EncodeString("a b"); encoded != "a%20b" {
+		t.Errorf("Expected RestLiUrlEncoder by default, got %q", encoded)
+	}
+	if encoded := reducedCodec.EncodeString("a b"); encoded != "a b" {
+		t.Errorf("Expected RestLiReducedEncoder by default, got %q", encoded)
+	}
+
+	c.Codec = RestLiUrlEncoder.WithPlainFloats().WithLenientBool()
+	for name, codec := range map[string]RestLiCodec{"UrlCodec": c.UrlCodec(), "ReducedCodec": c.ReducedCodec()} {
+		if encoded := codec.EncodeFloat64(1e6); encoded != "1000000" {
+			t.Errorf("Expected %s to use plain floats, got %q", name, encoded)
+		}
+		var v bool
+		if err := codec.DecodeBool("1", &v); err != nil || !v {
+			t.Errorf("Expected %s to use lenient bools, got %t (%v)", name, v, err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	for lenient, codec := range map[bool]RestLiCodec{false: {}, true: RestLiUrlEncoder.WithLenientBool()} {
+		c = &RestLiClient{Codec: codec}
+		req, err := c.GetRequest(context.Background(), mustParse(server.URL), Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v testBool
+		_, err = c.DoAndDecode(req, &v)
+		if lenient && (err != nil || !bool(v)) {
+			t.Errorf("Expected the response to be decoded with the client's codec, got %t (%v)", v, err)
+		}
+		if !lenient && err == nil {
+			t.Errorf("Expected the response to be rejected by the default codec")
+		}
+	}
+}
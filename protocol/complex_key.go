@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"sort"
+	"strings"
+)
+
+// ComplexKeyParams is the reserved field under which the params of a complex key are sent
+const ComplexKeyParams = "$params"
+
+// EncodeComplexKey adds the given encoded params to the given encoded complex key under the reserved $params field. Both
+// are expected to be encoded records, i.e. of the form (field:value,...). For example, the key (id:1) with the params
+// (version:2) is encoded as ($params:(version:2),id:1). Empty params are omitted entirely.
+func EncodeComplexKey(key, params string) string {
+	if params == "" || params == "()" {
+		return key
+	}
+
+	fields := strings.TrimSuffix(strings.TrimPrefix(key, "("), ")")
+	if fields == "" {
+		return "(" + ComplexKeyParams + ":" + params + ")"
+	}
+	return "(" + ComplexKeyParams + ":" + params + "," + fields + ")"
+}
+
+// SplitComplexKey is the inverse of EncodeComplexKey, it returns the given encoded complex key without its $params field
+// along with the encoded params, which is empty if the key has none
+func SplitComplexKey(codec RestLiCodec, data string) (key, params string, err error) {
+	fields, err := codec.DecodeObject(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	params, ok := fields[ComplexKeyParams]
+	if !ok {
+		return data, "", nil
+	}
+	delete(fields, ComplexKeyParams)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	buf.WriteByte('(')
+	for i, name := range names {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(name + ":" + fields[name])
+	}
+	buf.WriteByte(')')
+	return buf.String(), params, nil
+}
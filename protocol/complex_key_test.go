@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestEncodeComplexKey(t *testing.T) {
+	tests := []struct {
+		key, params, expected string
+	}{
+		{"(id:1)", "(version:2)", "($params:(version:2),id:1)"},
+		{"(id:1,name:foo)", "", "(id:1,name:foo)"},
+		{"(id:1)", "()", "(id:1)"},
+		{"()", "(version:2)", "($params:(version:2))"},
+	}
+
+	for _, test := range tests {
+		if actual := EncodeComplexKey(test.key, test.params); actual != test.expected {
+			t.Errorf("Expected: %s, Got: %s", test.expected, actual)
+		}
+	}
+}
+
+func TestSplitComplexKey(t *testing.T) {
+	tests := []struct {
+		data, key, params string
+	}{
+		{"($params:(version:2),id:1)", "(id:1)", "(version:2)"},
+		{"(name:foo,id:1)", "(name:foo,id:1)", ""},
+		{"($params:(version:2))", "()", "(version:2)"},
+		{"(id:1,$params:(a:List(1,2)),b:(c:d))", "(b:(c:d),id:1)", "(a:List(1,2))"},
+	}
+
+	for _, test := range tests {
+		key, params, err := SplitComplexKey(RestLiUrlEncoder, test.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != test.key || params != test.params {
+			t.Errorf("Expected: %s %s, Got: %s %s", test.key, test.params, key, params)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package protocol
+
+// JsonContentType is the Content-Type of the JSON bodies sent by rest.li clients
+const JsonContentType = "application/json"
+
+// DefaultContentTypes is used when a RestLiClient's ContentTypes does not specify a Content-Type for a given method. By
+// rest.li convention, all the methods that send a body send it as JSON.
+var DefaultContentTypes = map[RestLiMethod]string{
+	Method_create:         JsonContentType,
+	Method_update:         JsonContentType,
+	Method_partial_update: JsonContentType,
+
+	Method_batch_create:         JsonContentType,
+	Method_batch_update:         JsonContentType,
+	Method_batch_partial_update: JsonContentType,
+
+	Method_action: JsonContentType,
+}
+
+// ContentType returns the Content-Type of the JSON bodies sent by the given method. Methods that are neither in the
+// client's ContentTypes nor in DefaultContentTypes use JsonContentType
+func (c *RestLiClient) ContentType(method RestLiMethod) string {
+	if contentType, ok := c.ContentTypes[method]; ok {
+		return contentType
+	}
+	if contentType, ok := DefaultContentTypes[method]; ok {
+		return contentType
+	}
+	return JsonContentType
+}
@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CurlCommand returns a curl command that sends the same request as the given one, i.e. with the same method, URL,
+// headers and body. The request's body is read through GetBody, which is always set on the requests created by
+// RestLiClient, so that the request can still be sent afterwards.
+func CurlCommand(req *http.Request) (string, error) {
+	var b strings.Builder
+	b.WriteString("curl -X " + shellQuote(req.Method) + " " + shellQuote(req.URL.String()))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			b.WriteString(" -H " + shellQuote(name+": "+v))
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return "", err
+		}
+		if len(data) > 0 {
+			b.WriteString(" --data-binary " + shellQuote(string(data)))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// shellQuote wraps s in single quotes, which prevents the shell from interpreting any character in it other than
+// single quotes themselves
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// debugCurl passes the curl command for the request to the client's DebugCurl hook, if any
+func (c *RestLiClient) debugCurl(req *http.Request) {
+	if c.DebugCurl == nil {
+		return
+	}
+	command, err := CurlCommand(req)
+	if err != nil {
+		command = "# go-restli: Could not format request as a curl command: " + err.Error()
+	}
+	c.DebugCurl(command)
+}
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"testing"
+)
+
+func TestCurlCommand(t *testing.T) {
+	u, _ := url.Parse("http://localhost/foo?q=search&keywords=it's")
+	c := new(RestLiClient)
+	req, err := c.JsonPostRequest(context.Background(), u, Method_create, map[string]string{"message": "it's"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command, err := CurlCommand(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `curl -X 'POST' 'http://localhost/foo?q=search&keywords=it'\''s'` +
+		` -H 'Accept: application/json'` +
+		` -H 'Content-Type: application/json'` +
+		` -H 'X-Restli-Method: create'` +
+		` -H 'X-Restli-Protocol-Version: 2.0.0'` +
+		` --data-binary '{"message":"it'\''s"}'`
+	if command != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, command)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"message":"it's"}` {
+		t.Errorf("The request's body should not have been consumed, got %q", body)
+	}
+}
@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// EnvelopeFieldNames are the names of the fields of the envelopes that wrap the responses of finders, batch methods and
+// actions. Servers that follow the rest.li spec use DefaultEnvelopeFieldNames, but some rest.li-compatible servers
+// rename them. Empty names fall back to their default.
+type EnvelopeFieldNames struct {
+	// Elements holds the elements of a CollectionResponse
+	Elements string
+	// Paging holds the paging information of a CollectionResponse
+	Paging string
+	// Metadata holds the custom metadata of a CollectionResponse
+	Metadata string
+	// Results holds the successful results of a BatchResponse
+	Results string
+	// Errors holds the errors of a BatchResponse
+	Errors string
+	// Value holds the return value of an action
+	Value string
+}
+
+var DefaultEnvelopeFieldNames = EnvelopeFieldNames{
+	Elements: "elements",
+	Paging:   "paging",
+	Metadata: "metadata",
+	Results:  "results",
+	Errors:   "errors",
+	Value:    "value",
+}
+
+// renames returns the standard name of every field that is renamed by these names
+func (n *EnvelopeFieldNames) renames() map[string]string {
+	renames := make(map[string]string)
+	add := func(name, defaultName string) {
+		if name != "" && name != defaultName {
+			renames[name] = defaultName
+		}
+	}
+	add(n.Elements, DefaultEnvelopeFieldNames.Elements)
+	add(n.Paging, DefaultEnvelopeFieldNames.Paging)
+	add(n.Metadata, DefaultEnvelopeFieldNames.Metadata)
+	add(n.Results, DefaultEnvelopeFieldNames.Results)
+	add(n.Errors, DefaultEnvelopeFieldNames.Errors)
+	add(n.Value, DefaultEnvelopeFieldNames.Value)
+	return renames
+}
+
+// NormalizeEnvelope renames the top-level fields of the given JSON envelope from these names to their default, so that
+// it can be decoded by the generated code
+func (n *EnvelopeFieldNames) NormalizeEnvelope(data []byte) ([]byte, error) {
+	renames := n.renames()
+	if len(renames) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	normalized := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		if name, ok := renames[k]; ok {
+			normalized[name] = v
+		} else if _, ok := normalized[k]; !ok {
+			// Fields renamed to a default name take precedence over fields that already had that name
+			normalized[k] = v
+		}
+	}
+	return json.Marshal(normalized)
+}
+
+// DoAndDecodeEnvelope is like DoAndDecode, but for responses wrapped in an envelope, whose fields are renamed according
+// to RestLiClient.EnvelopeFieldNames before v is decoded
+func (c *RestLiClient) DoAndDecodeEnvelope(req *http.Request, v interface{}) (res *http.Response, err error) {
+	return c.doAndConsumeBody(req, func(res *http.Response, body []byte) error {
+		return c.decodeEnvelope(body, v)
+	})
+}
+
+// DoAndDecodeBatchEnvelope is like DoAndDecodeEnvelope, but for the responses of batch methods, which are usually a
+// BatchResponse whose results are keyed by the encoded keys. Some servers instead return a top-level JSON array that
+// holds the result of each key in the order they were requested, in which case the array is converted to the
+// BatchResponse of the given encoded keys (see BatchArrayToEnvelope) before v is decoded.
+func (c *RestLiClient) DoAndDecodeBatchEnvelope(req *http.Request, encodedKeys []string, v interface{}) (res *http.Response, err error) {
+	return c.doAndConsumeBody(req, func(res *http.Response, body []byte) error {
+		if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+			body, err = BatchArrayToEnvelope(body, encodedKeys)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(body, v)
+		}
+		return c.decodeEnvelope(body, v)
+	})
+}
+
+func (c *RestLiClient) decodeEnvelope(body []byte, v interface{}) (err error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if c.EnvelopeFieldNames != nil {
+		body, err = c.EnvelopeFieldNames.NormalizeEnvelope(body)
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(body, v)
+}
+
+// BatchArrayToEnvelope converts a batch response that is a JSON array, whose elements are the results of the given
+// encoded keys in the same order, into a BatchResponse whose results are keyed by the encoded keys. Null elements are
+// keys for which the server returned no result, and are left out of the results.
+func BatchArrayToEnvelope(data []byte, encodedKeys []string) ([]byte, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, err
+	}
+	if len(elements) != len(encodedKeys) {
+		return nil, fmt.Errorf("go-restli: Batch response holds %d results for %d keys", len(elements), len(encodedKeys))
+	}
+
+	results := make(map[string]json.RawMessage, len(elements))
+	for i, e := range elements {
+		if string(e) != "null" {
+			results[encodedKeys[i]] = e
+		}
+	}
+	return json.Marshal(map[string]interface{}{DefaultEnvelopeFieldNames.Results: results})
+}
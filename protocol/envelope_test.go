@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnvelopeFieldNames_NormalizeEnvelope(t *testing.T) {
+	names := &EnvelopeFieldNames{Elements: "items", Value: "value"}
+	data, err := names.NormalizeEnvelope([]byte(`{"items":[1],"elements":[2],"paging":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"elements":[1],"paging":{}}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	data = []byte(`{"elements":[1]}`)
+	normalized, err := new(EnvelopeFieldNames).NormalizeEnvelope(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(normalized) != string(data) {
+		t.Errorf("Default names should not modify the envelope, got %s", normalized)
+	}
+}
+
+func TestRestLiClient_DoAndDecodeEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(`{"data":"foo"}`))
+	}))
+	defer server.Close()
+	c := &RestLiClient{EnvelopeFieldNames: &EnvelopeFieldNames{Value: "data"}}
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL), Method_action)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct{ Value string }
+	_, err = c.DoAndDecodeEnvelope(req, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "foo" {
+		t.Errorf("Expected foo, got %q", result.Value)
+	}
+}
+
+func TestRestLiClient_DoAndDecodeBatchEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		if r.URL.Path == "/array" {
+			_, _ = w.Write([]byte(` [{"name":"a"}, null, {"name":"c"}]`))
+		} else {
+			_, _ = w.Write([]byte(`{"results":{"1":{"name":"a"},"3":{"name":"c"}}}`))
+		}
+	}))
+	defer server.Close()
+	c := new(RestLiClient)
+
+	for _, path := range []string{"/array", "/object"} {
+		t.Run(path, func(t *testing.T) {
+			req, err := c.GetRequest(context.Background(), mustParse(server.URL+path), Method_batch_get)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var result struct {
+				Results map[string]struct{ Name string } `json:"results"`
+			}
+			_, err = c.DoAndDecodeBatchEnvelope(req, []string{"1", "2", "3"}, &result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Results) != 2 || result.Results["1"].Name != "a" || result.Results["3"].Name != "c" {
+				t.Errorf("Unexpected results: %+v", result.Results)
+			}
+		})
+	}
+}
+
+func TestBatchArrayToEnvelope(t *testing.T) {
+	_, err := BatchArrayToEnvelope([]byte(`[{}]`), []string{"1", "2"})
+	if err == nil {
+		t.Error("Arrays that do not hold one result per key should be rejected")
+	}
+}
@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// FeatureFlags decides whether the generated methods that are behind a feature flag (see RequestInfo.FeatureFlag) can
+// be called
+type FeatureFlags interface {
+	// Enabled returns true if the methods behind the given feature flag can be called
+	Enabled(name string) bool
+}
+
+// FeatureFlagsFunc is an adapter to use ordinary functions as FeatureFlags
+type FeatureFlagsFunc func(name string) bool
+
+func (f FeatureFlagsFunc) Enabled(name string) bool {
+	return f(name)
+}
+
+// MethodDisabledError is returned by the generated methods whose feature flag is disabled, without sending any request
+type MethodDisabledError struct {
+	RequestInfo
+}
+
+func (e *MethodDisabledError) Error() string {
+	return fmt.Sprintf("go-restli: %s is disabled by feature flag %q", e.RequestInfo, e.FeatureFlag)
+}
+
+// checkFeatureFlag returns a MethodDisabledError if the request was issued by a method whose feature flag is disabled.
+// All the methods are enabled when RestLiClient.FeatureFlags is nil
+func (c *RestLiClient) checkFeatureFlag(req *http.Request) error {
+	if c.FeatureFlags == nil {
+		return nil
+	}
+	info, ok := RequestInfoFromContext(req.Context())
+	if !ok || info.FeatureFlag == "" || c.FeatureFlags.Enabled(info.FeatureFlag) {
+		return nil
+	}
+	return &MethodDisabledError{RequestInfo: info}
+}
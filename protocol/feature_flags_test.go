@@ -0,0 +1,51 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestLiClient_FeatureFlags(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+	}))
+	defer server.Close()
+	c := &RestLiClient{FeatureFlags: FeatureFlagsFunc(func(name string) bool { return name == "enabled" })}
+
+	do := func(flag string) error {
+		ctx := WithRequestInfo(context.Background(), RequestInfo{
+			ResourceName: "com.example.foo",
+			MethodName:   "Get",
+			Method:       Method_get,
+			FeatureFlag:  flag,
+		})
+		req, err := c.GetRequest(ctx, mustParse(server.URL+"/foo"), Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.DoAndIgnore(req)
+		return err
+	}
+
+	for _, flag := range []string{"", "enabled"} {
+		if err := do(flag); err != nil {
+			t.Errorf("Flag %q should be enabled: %+v", flag, err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("Expected 2 requests, got %d", requests)
+	}
+
+	var disabled *MethodDisabledError
+	if err := do("disabled"); !errors.As(err, &disabled) || disabled.FeatureFlag != "disabled" {
+		t.Errorf("Expected a MethodDisabledError, got %+v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Disabled methods should not send any request")
+	}
+}
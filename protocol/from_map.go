@@ -0,0 +1,93 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// The validators reported by the generated NewXxxFromMap functions
+const (
+	// TypeValidator rejects values that cannot be converted to the type of their field, see ConvertMapValue
+	TypeValidator = "type"
+	// UnknownFieldValidator rejects keys that are not the name of any field
+	UnknownFieldValidator = "unknown"
+)
+
+// ConvertMapValue converts a value of a map[string]interface{}, such as the ones decoded from YAML or assembled by
+// templates, into v, which must be a pointer to a generated type. The value is converted through its JSON
+// representation, so it is subject to the same rules as when decoding JSON. The map[interface{}]interface{} maps
+// produced by some YAML decoders are supported as long as all their keys are strings.
+func ConvertMapValue(value, v interface{}) error {
+	value, err := stringKeys(value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringKeys recursively converts the map[interface{}]interface{} maps held by the given value to
+// map[string]interface{}, which encoding/json cannot marshal
+func stringKeys(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("go-restli: Map keys must be strings, got %T", k)
+			}
+			e, err := stringKeys(e)
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = e
+		}
+		return converted, nil
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			e, err := stringKeys(e)
+			if err != nil {
+				return nil, err
+			}
+			converted[k] = e
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, e := range v {
+			e, err := stringKeys(e)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = e
+		}
+		return converted, nil
+	default:
+		return value, nil
+	}
+}
+
+// AddUnknownKeys appends a ValidationError for every key of the given map that is not one of the given fields, in
+// sorted order
+func (e *ValidationErrors) AddUnknownKeys(m map[string]interface{}, fields ...string) {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f] = true
+	}
+	var unknown []string
+	for k := range m {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		e.Add(k, UnknownFieldValidator, "not a field of the record")
+	}
+}
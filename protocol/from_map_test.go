@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertMapValue(t *testing.T) {
+	var v *struct {
+		Name  string
+		Tags  map[string][]int64
+		Count *int32
+	}
+	err := ConvertMapValue(map[interface{}]interface{}{
+		"name":  "foo",
+		"tags":  map[interface{}]interface{}{"a": []interface{}{1, 2}},
+		"count": 3,
+	}, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "foo" || !reflect.DeepEqual(v.Tags, map[string][]int64{"a": {1, 2}}) || v.Count == nil || *v.Count != 3 {
+		t.Errorf("Unexpected value: %+v", v)
+	}
+
+	var i int32
+	if err = ConvertMapValue("3", &i); err == nil {
+		t.Error("Strings should not be converted to numbers")
+	}
+	if err = ConvertMapValue(map[interface{}]interface{}{1: "a"}, &v); err == nil {
+		t.Error("Non-string keys should be rejected")
+	}
+}
+
+func TestValidationErrors_AddUnknownKeys(t *testing.T) {
+	var errs ValidationErrors
+	errs.AddUnknownKeys(map[string]interface{}{"b": 1, "foo": 2, "a": 3}, "foo", "bar")
+
+	var fields []string
+	for _, e := range errs {
+		if e.Validator != UnknownFieldValidator {
+			t.Errorf("Unexpected validator: %+v", e)
+		}
+		fields = append(fields, e.Field)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(expected, fields) {
+		t.Errorf("Expected %v, got %v", expected, fields)
+	}
+}
@@ -0,0 +1,35 @@
+package protocol
+
+import (
+	"net/http"
+)
+
+// ProtocolHeaders are the headers set by the RestLiClient itself. They are single-valued and authoritative, meaning
+// values given for them in RestLiClient.Headers are ignored
+var ProtocolHeaders = map[string]bool{
+	http.CanonicalHeaderKey(RestLiHeader_Method):          true,
+	http.CanonicalHeaderKey(RestLiHeader_ProtocolVersion): true,
+	http.CanonicalHeaderKey("Content-Type"):               true,
+}
+
+// MergeHeaders adds the values of src to dst, after any values dst already has for the same header, with the exception
+// of the ProtocolHeaders which are left untouched in dst. Header names are canonicalized, which means that headers that
+// only differ by case are merged as well.
+func MergeHeaders(dst, src http.Header) {
+	for name, values := range src {
+		name = http.CanonicalHeaderKey(name)
+		if ProtocolHeaders[name] {
+			continue
+		}
+		for _, v := range values {
+			dst.Add(name, v)
+		}
+	}
+}
+
+// addHeaders merges the client's Headers into the request's, see MergeHeaders
+func (c *RestLiClient) addHeaders(req *http.Request) {
+	if len(c.Headers) > 0 {
+		MergeHeaders(req.Header, c.Headers)
+	}
+}
@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestMergeHeaders(t *testing.T) {
+	dst := http.Header{}
+	dst.Set(RestLiHeader_Method, "get")
+	dst.Set("X-Trace", "a")
+
+	MergeHeaders(dst, http.Header{
+		"x-restli-method": {"create"},
+		"Content-Type":    {"text/plain"},
+		"x-trace":         {"b", "c"},
+		"X-Other":         {"d"},
+	})
+
+	expected := http.Header{
+		http.CanonicalHeaderKey(RestLiHeader_Method): {"get"},
+		"X-Trace": {"a", "b", "c"},
+		"X-Other": {"d"},
+	}
+	if !reflect.DeepEqual(expected, dst) {
+		t.Errorf("Expected: %v, Got: %v", expected, dst)
+	}
+}
+
+func TestRestLiClient_Headers(t *testing.T) {
+	u, _ := url.Parse("http://localhost/foo")
+	c := &RestLiClient{Headers: http.Header{
+		RestLiHeader_ProtocolVersion: {"1.0.0"},
+		"X-Trace":                    {"a"},
+	}}
+
+	req, err := c.JsonPostRequest(context.Background(), u, Method_create, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Values(RestLiHeader_ProtocolVersion); !reflect.DeepEqual(v, []string{RestLiProtocolVersion}) {
+		t.Errorf("Protocol headers should not be overridden, got %v", v)
+	}
+	if v := req.Header.Get("X-Trace"); v != "a" {
+		t.Errorf("Expected: a, Got: %q", v)
+	}
+}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 	//"github.com/pkg/errors"
 )
 
@@ -19,6 +21,9 @@ const (
 	RestLiHeader_Method          = "X-RestLi-Method"
 	RestLiHeader_ProtocolVersion = "X-RestLi-Protocol-Version"
 	RestLiHeader_ErrorResponse   = "X-RestLi-Error-Response"
+	RestLiHeader_ID              = "X-RestLi-Id"
+
+	MethodOverrideHeader = "X-HTTP-Method-Override"
 )
 
 type RestLiMethod int
@@ -80,36 +85,97 @@ func (r *RestLiError) Error() string {
 	return fmt.Sprintf("RestLiError(status: %d, exceptionClass: %s, message: %s)", r.Status, r.ExceptionClass, r.Message)
 }
 
+// TransportError is returned when a request could not be sent or its response could not be read, e.g. because of a
+// network failure or because the request's context expired. Unlike a RestLiError, the server may not have received or
+// processed the request, which usually makes it safe to retry. The underlying error is available through errors.As.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return "go-restli: Transport error: " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// newRestLiError parses the given body of an error response into a RestLiError. The response's status is used unless
+// the body holds one
+func newRestLiError(res *http.Response, body []byte) *RestLiError {
+	restLiError := &RestLiError{
+		Status:          res.StatusCode,
+		FullResponse:    body,
+		ResponseHeaders: res.Header,
+	}
+	if deserializationError := json.Unmarshal(body, restLiError); deserializationError != nil {
+		restLiError.DeserializationError = deserializationError
+	}
+	return restLiError
+}
+
+// IsErrorResponse returns a RestLiError if the rest.li error header is set or if the response's status is 4xx or 5xx,
+// in which case the response's body is consumed. A TransportError is returned if the body cannot be read.
 func IsErrorResponse(res *http.Response) error {
-	var err error
-	var body []byte
+	return isErrorResponse(res, nil)
+}
 
-	if strings.ToLower(res.Header.Get(RestLiHeader_ErrorResponse)) == "true" {
-		defer res.Body.Close()
-		body, err = ioutil.ReadAll(res.Body)
-		if err != nil {
+// isErrorResponse is IsErrorResponse, except that the body of error responses is first given to errorParser, if
+// non-nil, see RestLiClient.ErrorParser
+func isErrorResponse(res *http.Response, errorParser func(status int, body []byte) error) error {
+	if strings.ToLower(res.Header.Get(RestLiHeader_ErrorResponse)) != "true" && res.StatusCode < 400 {
+		return nil
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return &TransportError{Err: err}
+	}
+	return parseError(res, body, errorParser)
+}
+
+// parseError returns the error returned by errorParser for the given error response, if any, or the RestLiError its
+// body holds otherwise
+func parseError(res *http.Response, body []byte, errorParser func(status int, body []byte) error) error {
+	if errorParser != nil {
+		if err := errorParser(res.StatusCode, body); err != nil {
 			return err
 		}
-		restLiError := &RestLiError{
-			FullResponse:    body,
-			ResponseHeaders: res.Header,
-		}
-		if deserializationError := json.Unmarshal(body, restLiError); deserializationError != nil {
-			restLiError.DeserializationError = deserializationError
-		}
-		return restLiError
 	}
+	return newRestLiError(res, body)
+}
 
-	if res.StatusCode >= 500 {
-		defer res.Body.Close()
-		body, err = ioutil.ReadAll(res.Body)
+// CreatedEntityID returns the encoded key of the entity created by a CREATE request. It is sent in the X-RestLi-Id
+// header, or only as the last segment of the Location header by some older servers.
+func CreatedEntityID(res *http.Response) (string, error) {
+	if id := res.Header.Get(RestLiHeader_ID); id != "" {
+		return id, nil
+	}
+
+	if location := res.Header.Get("Location"); location != "" {
+		u, err := url.Parse(location)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("go-restli: Invalid Location header %q: %w", location, err)
+		}
+		path := u.EscapedPath()
+		if id := path[strings.LastIndex(path, "/")+1:]; id != "" {
+			return id, nil
 		}
-		return errors.New(string(body))
 	}
 
-	return nil
+	return "", fmt.Errorf("go-restli: Response to CREATE request has neither a %s nor a Location header", RestLiHeader_ID)
+}
+
+// ResponseTooLargeError is returned when the body of a response exceeds RestLiClient.MaxResponseBodySize. The
+// response's body is closed without being read entirely
+type ResponseTooLargeError struct {
+	MaxResponseBodySize int64
+	Response            *http.Response
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("go-restli: Response too large, its body exceeds the max size of %d bytes", e.MaxResponseBodySize)
 }
 
 type SimpleHostnameSupplier struct {
@@ -130,8 +196,74 @@ type HostnameResolver interface {
 }
 
 type RestLiClient struct {
+	// Client is used to send all the requests. When nil, a shared client configured with DefaultTransportOptions is used
+	// instead, see NewHTTPClient to create a client with different TransportOptions
 	*http.Client
 	HostnameResolver
+	// Codec encodes the keys and query parameters of the requests sent by the generated clients, and decodes the keys
+	// and rest.li encoded bodies of their responses. Defaults to RestLiUrlEncoder, and can be configured with e.g.
+	// RestLiUrlEncoder.WithPlainFloats().WithLenientBool(). See UrlCodec and ReducedCodec
+	Codec RestLiCodec
+	// MethodTimeouts is the timeout applied to each method when the caller's context has no deadline. Methods that
+	// are not in this map, or whose timeout is zero or negative, have no timeout. See RecommendedMethodTimeouts
+	MethodTimeouts map[RestLiMethod]time.Duration
+	// Tracer, if non-nil, is called around every request
+	Tracer Tracer
+	// Metrics, if non-nil, is notified of the status and latency of every request
+	Metrics Metrics
+	// BatchChunkSize, if positive, splits batch requests into multiple requests of at most BatchChunkSize keys, or of
+	// the max batch size declared by the resource if it is smaller. See ChunkBatch
+	BatchChunkSize int
+	// RequestIDHeader is the header in which the request ID attached to the context by WithRequestID is sent. Defaults to
+	// DefaultRequestIDHeader
+	RequestIDHeader string
+	// Headers are added to every request, after the headers set by the client itself. See MergeHeaders for how they are
+	// merged
+	Headers http.Header
+	// MaxResponseBodySize, if positive, is the maximum number of bytes read from the body of responses decoded by
+	// DoAndDecode and DoAndIgnore. Larger responses fail with a ResponseTooLargeError
+	MaxResponseBodySize int64
+	// DebugCurl, if non-nil, is called with the equivalent curl command of every request before it is sent (see
+	// CurlCommand). Note that the command includes all the headers, including any credentials
+	DebugCurl func(command string)
+	// EnvelopeFieldNames, if non-nil, overrides the names of the fields of the envelopes that wrap the responses of
+	// finders, batch methods and actions, see DoAndDecodeEnvelope
+	EnvelopeFieldNames *EnvelopeFieldNames
+	// KeepTrailingSlash disables the removal of the trailing slash from the paths of the URLs built by FormatQueryUrl, see
+	// NormalizePath
+	KeepTrailingSlash bool
+	// URIResolvers maps URI schemes (e.g. d2) to the URIResolver that translates the URLs with that scheme returned by
+	// the HostnameResolver into concrete URLs
+	URIResolvers map[string]URIResolver
+	// RequestSigner, if non-nil, is called on every request once all its headers are set, right before it is sent
+	RequestSigner RequestSigner
+	// UpdateWithPost sends UPDATE requests as POSTs with a MethodOverrideHeader set to PUT, instead of as PUTs, for
+	// servers behind proxies that block PUT. See JsonUpdateRequest
+	UpdateWithPost bool
+	// TunnelMethods sends all the PUT, DELETE and PATCH requests as POSTs with a MethodOverrideHeader set to the
+	// original method, for servers behind proxies that only let GET and POST through. See NewRequest
+	TunnelMethods bool
+	// ContentTypes overrides the Content-Type header of the JSON bodies sent by each method. Methods that are not in this
+	// map fall back to DefaultContentTypes, see ContentType
+	ContentTypes map[RestLiMethod]string
+	// ErrorParser, if non-nil, is called with the status and body of every error response, for servers whose error
+	// bodies are not shaped like a rest.li ErrorResponse. The error it returns is returned by the client. If it returns
+	// nil, the body is parsed into a RestLiError as usual
+	ErrorParser func(status int, body []byte) error
+	// ProtocolVersion is the version of the rest.li protocol spoken with the server, which is sent in the
+	// X-RestLi-Protocol-Version header of every request and expected in every response. Defaults to
+	// RestLiProtocolVersion, see RestLiProtocolVersion1 for legacy servers
+	ProtocolVersion string
+	// FeatureFlags, if non-nil, decides whether the methods that are behind a feature flag can be called. The requests of
+	// disabled methods fail with a MethodDisabledError before they are sent
+	FeatureFlags FeatureFlags
+	// Cache, if non-nil, stores the responses of GET and BATCH_GET requests, keyed by their URL and credentials. Cached
+	// responses are returned without sending the request, following the Cache-Control, ETag and Vary headers of the
+	// response (see Cache)
+	Cache Cache
+	// CacheTTL is how long the responses that do not declare a max-age are cached, as well as how long the responses
+	// that must be revalidated using their ETag are kept. Only responses with a max-age are cached when it is zero
+	CacheTTL time.Duration
 }
 
 // Assumes a leading slash
@@ -144,8 +276,35 @@ func getFirstPathSegment(path string) string {
 	}
 }
 
+// NormalizePath collapses the runs of slashes in the given path, which appear when a parent path already ends with a
+// slash, and removes its trailing slash unless keepTrailingSlash is set. The root path is always kept as is. Empty keys
+// never produce empty segments since the codecs encode empty strings as EmptyString.
+func NormalizePath(path string, keepTrailingSlash bool) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	path = b.String()
+
+	if !keepTrailingSlash && len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// FormatQueryUrl resolves the given path (which may include a query) against the hostname of the given resource. The
+// path is normalized by NormalizePath first
 func (c *RestLiClient) FormatQueryUrl(resourceBasename, rawQuery string) (*url.URL, error) {
-	rawQuery = "/" + strings.TrimPrefix(rawQuery, "/")
+	rawPath, rawQuery := rawQuery, ""
+	if idx := strings.IndexByte(rawPath, '?'); idx >= 0 {
+		rawPath, rawQuery = rawPath[:idx], rawPath[idx:]
+	}
+	rawQuery = NormalizePath("/"+rawPath, c.KeepTrailingSlash) + rawQuery
+
 	query, err := url.Parse(rawQuery)
 	if err != nil {
 		return nil, err
@@ -155,6 +314,10 @@ func (c *RestLiClient) FormatQueryUrl(resourceBasename, rawQuery string) (*url.U
 	if err != nil {
 		return nil, err
 	}
+	hostUrl, err = c.resolveURI(hostUrl)
+	if err != nil {
+		return nil, err
+	}
 
 	hostPath := hostUrl.EscapedPath()
 	if hostPath == "" || hostPath == "/" {
@@ -176,7 +339,7 @@ func SetJsonAcceptHeader(req *http.Request) {
 }
 
 func SetJsonContentTypeHeader(req *http.Request) {
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", JsonContentType)
 }
 
 func SetRestLiHeaders(req *http.Request, method RestLiMethod) {
@@ -184,77 +347,137 @@ func SetRestLiHeaders(req *http.Request, method RestLiMethod) {
 	req.Header.Set(RestLiHeader_Method, method.String())
 }
 
-func (c *RestLiClient) GetRequest(url *url.URL, method RestLiMethod) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, url.String(), emptyBuffer)
+// NewRequest creates a request with the given method. If TunnelMethods is set, PUT, DELETE and PATCH requests are
+// tunneled through a POST whose MethodOverrideHeader tells the server which method to handle it as
+func (c *RestLiClient) NewRequest(ctx context.Context, httpMethod string, url *url.URL, body io.Reader) (*http.Request, error) {
+	tunneled := false
+	if c.TunnelMethods {
+		switch httpMethod {
+		case http.MethodPut, http.MethodDelete, http.MethodPatch:
+			tunneled = true
+		}
+	}
+
+	if !tunneled {
+		return http.NewRequestWithContext(ctx, httpMethod, url.String(), body)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(MethodOverrideHeader, httpMethod)
+	return req, nil
+}
+
+func (c *RestLiClient) GetRequest(ctx context.Context, url *url.URL, method RestLiMethod) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), emptyBuffer)
 	if err != nil {
 		return nil, err
 	}
 
-	SetRestLiHeaders(req, method)
+	c.setRestLiHeaders(req, method)
 	SetJsonAcceptHeader(req)
+	c.setRequestID(req)
+	c.addHeaders(req)
 
 	return req, nil
 }
 
-func (c *RestLiClient) DeleteRequest(url *url.URL, method RestLiMethod) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodDelete, url.String(), emptyBuffer)
+func (c *RestLiClient) DeleteRequest(ctx context.Context, url *url.URL, method RestLiMethod) (*http.Request, error) {
+	req, err := c.NewRequest(ctx, http.MethodDelete, url, emptyBuffer)
 	if err != nil {
 		return nil, err
 	}
 
-	SetRestLiHeaders(req, method)
+	c.setRestLiHeaders(req, method)
 	SetJsonAcceptHeader(req)
+	c.setRequestID(req)
+	c.addHeaders(req)
 
 	return req, nil
 }
 
-func (c *RestLiClient) JsonPutRequest(url *url.URL, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
-	return jsonRequest(url, http.MethodPut, restLiMethod, contents)
+func (c *RestLiClient) JsonPutRequest(ctx context.Context, url *url.URL, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
+	return c.jsonRequest(ctx, url, http.MethodPut, restLiMethod, contents)
+}
+
+// JsonUpdateRequest creates the request of an UPDATE, which is a PUT unless UpdateWithPost is set, in which case it is a
+// POST whose MethodOverrideHeader tells the server to handle it as a PUT
+func (c *RestLiClient) JsonUpdateRequest(ctx context.Context, url *url.URL, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
+	if !c.UpdateWithPost {
+		return c.JsonPutRequest(ctx, url, restLiMethod, contents)
+	}
+
+	req, err := c.JsonPostRequest(ctx, url, restLiMethod, contents)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(MethodOverrideHeader, http.MethodPut)
+	return req, nil
 }
 
-func (c *RestLiClient) JsonPostRequest(url *url.URL, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
-	return jsonRequest(url, http.MethodPost, restLiMethod, contents)
+func (c *RestLiClient) JsonPostRequest(ctx context.Context, url *url.URL, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
+	return c.jsonRequest(ctx, url, http.MethodPost, restLiMethod, contents)
 }
 
-func jsonRequest(url *url.URL, httpMethod string, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
+func (c *RestLiClient) jsonRequest(ctx context.Context, url *url.URL, httpMethod string, restLiMethod RestLiMethod, contents interface{}) (*http.Request, error) {
 	buf, err := json.Marshal(contents)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(httpMethod, url.String(), bytes.NewBuffer(buf))
+	req, err := c.NewRequest(ctx, httpMethod, url, bytes.NewBuffer(buf))
 	if err != nil {
 		return nil, err
 	}
 
-	SetRestLiHeaders(req, restLiMethod)
+	c.setRestLiHeaders(req, restLiMethod)
 	SetJsonAcceptHeader(req)
-	SetJsonContentTypeHeader(req)
+	req.Header.Set("Content-Type", c.ContentType(restLiMethod))
+	c.setRequestID(req)
+	c.addHeaders(req)
 
 	return req, nil
 }
 
-func (c *RestLiClient) RawPostRequest(url *url.URL, method RestLiMethod, contents []byte) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodPost, url.String(), bytes.NewBuffer(contents))
+func (c *RestLiClient) RawPostRequest(ctx context.Context, url *url.URL, method RestLiMethod, contents []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewBuffer(contents))
 	if err != nil {
 		return nil, err
 	}
 
-	SetRestLiHeaders(req, method)
+	c.setRestLiHeaders(req, method)
+	c.setRequestID(req)
+	c.addHeaders(req)
 
 	return req, nil
 }
 
 // Do is a very thin shim between the standard http.Client.Do. All it does it parse the response into a RestLiError if
-// the RestLi error header is set. A non-nil Response with a non-nil error will only occur if http.Client.Do returns
+// the RestLi error header is set or if the status is 4xx or 5xx, and wrap the errors of http.Client.Do in a
+// TransportError. A non-nil Response with a non-nil error will only occur if http.Client.Do returns
 // such values (see the corresponding documentation). Otherwise, the response will only be non-nil if the error is nil.
-func (c *RestLiClient) Do(req *http.Request) (*http.Response, error) {
-	res, err := c.Client.Do(req)
+// Requests issued by methods that are disabled by FeatureFlags fail with a MethodDisabledError without being sent.
+func (c *RestLiClient) Do(req *http.Request) (res *http.Response, err error) {
+	if err = c.checkFeatureFlag(req); err != nil {
+		return nil, err
+	}
+
+	req, finish := c.startTrace(req)
+	defer func() { finish(res, err) }()
+
+	err = c.signRequest(req)
 	if err != nil {
-		return res, err
+		return nil, err
 	}
 
-	err = IsErrorResponse(res)
+	c.debugCurl(req)
+	res, err = c.httpClient().Do(req)
+	if err != nil {
+		return res, &TransportError{Err: err}
+	}
+
+	err = isErrorResponse(res, c.ErrorParser)
 	if err != nil {
 		return nil, err
 	}
@@ -263,34 +486,137 @@ func (c *RestLiClient) Do(req *http.Request) (*http.Response, error) {
 }
 
 // DoAndDecode calls Do and attempts to unmarshal the response into the given value. The response body will always be
-// read to EOF and closed, to ensure the connection can be reused.
+// read to EOF and closed, to ensure the connection can be reused. If the response's Content-Type is registered in
+// RestLiContentTypes and the value is RestLiEncodable, the body is decoded with RestLiDecode instead of as JSON. Empty
+// bodies, such as the ones of 204 No Content responses, leave the value untouched.
 func (c *RestLiClient) DoAndDecode(req *http.Request, v interface{}) (res *http.Response, err error) {
-	return c.doAndConsumeBody(req, func(body []byte) error {
-		return json.Unmarshal(body, v)
+	return c.doAndConsumeBody(req, func(res *http.Response, body []byte) error {
+		return c.decodeBody(res, body, v)
+	})
+}
+
+// DoAndDecodeWithBody is like DoAndDecode, but also returns the raw response body, e.g. to persist the exact payload
+// sent by the server alongside the decoded value.
+func (c *RestLiClient) DoAndDecodeWithBody(req *http.Request, v interface{}) (body []byte, err error) {
+	_, err = c.doAndConsumeBody(req, func(res *http.Response, data []byte) error {
+		body = data
+		return c.decodeBody(res, data, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// decodeBody decodes the given response body into v. Surrounding whitespace, which some proxies add to responses, is
+// trimmed first since the rest.li decoders do not tolerate it. Rest.li encoded bodies are decoded with the options of
+// the client's Codec, e.g. WithLenientBool
+func (c *RestLiClient) decodeBody(res *http.Response, body []byte, v interface{}) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if decodable, ok := v.(RestLiEncodable); ok {
+		if codec, ok := CodecForContentType(res.Header.Get("Content-Type")); ok {
+			return decodable.RestLiDecode(codec.withOptionsOf(c.UrlCodec()), string(body))
+		}
+	}
+	return json.Unmarshal(body, v)
+}
+
+// DoAndDecodeRaw calls Do and decodes the response's top-level JSON object without going through any generated type,
+// which is useful to inspect responses or to access fields that are not part of the schema. Like DoAndDecode, the
+// response body will always be read to EOF and closed. Non-2xx responses are returned as a RestLiError, even if the
+// server did not set the rest.li error header.
+func (c *RestLiClient) DoAndDecodeRaw(req *http.Request) (raw map[string]json.RawMessage, err error) {
+	_, err = c.doAndConsumeBody(req, func(res *http.Response, body []byte) error {
+		if res.StatusCode/100 != 2 {
+			return parseError(res, body, c.ErrorParser)
+		}
+
+		body = bytes.TrimSpace(body)
+		if len(body) == 0 {
+			return nil
+		}
+		return json.Unmarshal(body, &raw)
 	})
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
 }
 
 // DoAndDecode calls Do and drops the response's body. The response body will always be read to EOF and closed, to
 // ensure the connection can be reused.
 func (c *RestLiClient) DoAndIgnore(req *http.Request) (res *http.Response, err error) {
-	return c.doAndConsumeBody(req, func([]byte) error {
+	return c.doAndConsumeBody(req, func(*http.Response, []byte) error {
 		return nil
 	})
 }
 
-func (c *RestLiClient) doAndConsumeBody(req *http.Request, bodyConsumer func(body []byte) error) (*http.Response, error) {
+// DoAndCheckExists calls DoAndIgnore and reports whether the requested entity exists: 2xx responses return true and
+// 404 responses return false. Any other response is surfaced as an error.
+func (c *RestLiClient) DoAndCheckExists(req *http.Request) (bool, error) {
+	res, err := c.DoAndIgnore(req)
+	if err != nil {
+		var restLiError *RestLiError
+		if errors.As(err, &restLiError) && restLiError.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if res.StatusCode/100 != 2 {
+		return false, fmt.Errorf("go-restli: Invalid response code from %s: %d", req.URL, res.StatusCode)
+	}
+	return true, nil
+}
+
+// ExistsProjection is the zero-field projection sent by the generated Exists methods, so that servers don't serialize
+// the entity
+const ExistsProjection = FieldsParam + "="
+
+func (c *RestLiClient) doAndConsumeBody(req *http.Request, bodyConsumer func(res *http.Response, body []byte) error) (*http.Response, error) {
+	cacheKey, cacheable := c.cacheKey(req)
+	var cached *cacheEntry
+	if cacheable {
+		cached = c.cachedResponse(cacheKey, req)
+		if cached != nil {
+			if !cached.Revalidate {
+				return c.serveCachedResponse(req, cached, bodyConsumer)
+			}
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	start := time.Now()
 	res, err := c.Do(req)
+	c.observeRequest(req, res, err, time.Since(start))
 	if err != nil {
 		return res, err
 	}
 
-	if v := res.Header.Get(RestLiHeader_ProtocolVersion); v != RestLiProtocolVersion {
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		_ = res.Body.Close()
+		return consumeCachedResponse(req, cached, bodyConsumer)
+	}
+
+	if v := res.Header.Get(RestLiHeader_ProtocolVersion); v != c.protocolVersion() {
 		return nil, fmt.Errorf("go-restli: Unsupported rest.li protocol version: %s", v)
 	}
 
-	data, err := ioutil.ReadAll(res.Body)
+	var body io.Reader = res.Body
+	if c.MaxResponseBodySize > 0 {
+		// Read one more byte than allowed to tell apart bodies that are exactly at the limit from those that exceed it
+		body = io.LimitReader(res.Body, c.MaxResponseBodySize+1)
+	}
+	data, err := ioutil.ReadAll(body)
 	if err != nil {
-		return nil, err
+		_ = res.Body.Close()
+		return nil, &TransportError{Err: err}
+	}
+	if c.MaxResponseBodySize > 0 && int64(len(data)) > c.MaxResponseBodySize {
+		_ = res.Body.Close()
+		return nil, &ResponseTooLargeError{MaxResponseBodySize: c.MaxResponseBodySize, Response: res}
 	}
 
 	err = res.Body.Close()
@@ -298,10 +624,42 @@ func (c *RestLiClient) doAndConsumeBody(req *http.Request, bodyConsumer func(bod
 		return nil, err
 	}
 
-	err = bodyConsumer(data)
+	err = bodyConsumer(res, data)
 	if err != nil {
 		return nil, err
 	}
 
+	if cacheable {
+		c.cacheResponse(cacheKey, req, res, data)
+	}
+
+	return res, nil
+}
+
+// serveCachedResponse consumes the cached response in place of sending the request. The FeatureFlags, Tracer and
+// Metrics still see the request, as if it had been sent, so that cache hits are neither hidden from them nor able to
+// bypass a disabled method
+func (c *RestLiClient) serveCachedResponse(req *http.Request, cached *cacheEntry, bodyConsumer func(res *http.Response, body []byte) error) (*http.Response, error) {
+	if err := c.checkFeatureFlag(req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	req, finish := c.startTrace(req)
+	res := cached.response(req)
+	finish(res, nil)
+	c.observeRequest(req, res, nil, time.Since(start))
+
+	if err := bodyConsumer(res, cached.Body); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func consumeCachedResponse(req *http.Request, cached *cacheEntry, bodyConsumer func(res *http.Response, body []byte) error) (*http.Response, error) {
+	res := cached.response(req)
+	if err := bodyConsumer(res, cached.Body); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
@@ -1,7 +1,13 @@
 package protocol
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -54,6 +60,37 @@ func TestRestLiClient_FormatQuery(t *testing.T) {
 	}
 }
 
+func TestRestLiClient_FormatQueryNormalization(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Hostname          *url.URL
+		Query             string
+		KeepTrailingSlash bool
+		Expected          string
+	}{
+		{Name: "duplicate slashes", Hostname: emptyContext, Query: "/parent//child///1", Expected: "/parent/child/1"},
+		{Name: "no leading slash", Hostname: emptyContext, Query: "search/1", Expected: "/search/1"},
+		{Name: "trailing slash", Hostname: emptyContext, Query: "/search/", Expected: "/search"},
+		{Name: "kept trailing slash", Hostname: emptyContext, Query: "/search/", KeepTrailingSlash: true, Expected: "/search/"},
+		{Name: "empty string key", Hostname: emptyContext, Query: "/search/" + RestLiUrlEncoder.EncodeString("") + "/sub", Expected: "/search/''/sub"},
+		{Name: "query untouched", Hostname: emptyContext, Query: "/search/?q=a//b", Expected: "/search?q=a//b"},
+		{Name: "root resource", Hostname: slashContext, Query: "", Expected: "/"},
+		{Name: "root resource with context", Hostname: seasBroker, Query: "/", Expected: "/seas-broker/"},
+		{Name: "context", Hostname: seasBroker, Query: "//search//1/", Expected: "/seas-broker/search/1"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := &RestLiClient{
+				HostnameResolver:  &SimpleHostnameSupplier{Hostname: test.Hostname},
+				KeepTrailingSlash: test.KeepTrailingSlash,
+			}
+			if actual := c.formatQuery(t, test.Query); actual != test.Expected {
+				t.Errorf("Expected: %s, Got: %s", test.Expected, actual)
+			}
+		})
+	}
+}
+
 func (c *RestLiClient) formatQuery(t *testing.T, query string) string {
 	u, err := c.FormatQueryUrl("search", query)
 	if err != nil {
@@ -61,3 +98,443 @@ func (c *RestLiClient) formatQuery(t *testing.T, query string) string {
 	}
 	return u.String()
 }
+
+func TestCreatedEntityID(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		expected string
+	}{
+		{http.Header{http.CanonicalHeaderKey(RestLiHeader_ID): {"(id:1)"}, "Location": {"/foo/2"}}, "(id:1)"},
+		{http.Header{"Location": {"http://localhost/foo/a%2Fb?bar=1"}}, "a%2Fb"},
+	}
+	for _, test := range tests {
+		id, err := CreatedEntityID(&http.Response{Header: test.header})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != test.expected {
+			t.Errorf("Expected: %s, Got: %s", test.expected, id)
+		}
+	}
+
+	if _, err := CreatedEntityID(&http.Response{Header: http.Header{"Location": {"/foo/"}}}); err == nil {
+		t.Errorf("Expected an error when no ID is present")
+	}
+}
+
+func TestRestLiClient_MaxResponseBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(`{"a":1}`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	for size, tooLarge := range map[int64]bool{0: false, 6: true, 7: false} {
+		c := &RestLiClient{MaxResponseBodySize: size}
+		req, err := c.GetRequest(context.Background(), u, Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var v map[string]int
+		_, err = c.DoAndDecode(req, &v)
+		if _, ok := err.(*ResponseTooLargeError); ok != tooLarge {
+			t.Errorf("Unexpected error for a max size of %d: %v", size, err)
+		}
+		if !tooLarge && v["a"] != 1 {
+			t.Errorf("Response was not decoded for a max size of %d", size)
+		}
+	}
+}
+
+func TestRestLiClient_DoAndDecodeRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"message":"not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1,"unknown":{"a":[1,2]}}`))
+	}))
+	defer server.Close()
+	c := new(RestLiClient)
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL+"/foo"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := c.DoAndDecodeRaw(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["id"]) != "1" || string(raw["unknown"]) != `{"a":[1,2]}` {
+		t.Errorf("Unexpected raw response: %v", raw)
+	}
+
+	req, err = c.GetRequest(context.Background(), mustParse(server.URL+"/missing"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoAndDecodeRaw(req)
+	restLiError, ok := err.(*RestLiError)
+	if !ok || restLiError.Status != http.StatusNotFound || restLiError.Message != "not found" {
+		t.Errorf("Expected a RestLiError, got %+v", err)
+	}
+}
+
+func TestRestLiClient_DoAndDecodeWithBody(t *testing.T) {
+	const payload = `{"foo":"bar", "unknown":1}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+	c := new(RestLiClient)
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL+"/foo"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Foo string }
+	body, err := c.DoAndDecodeWithBody(req, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "bar" {
+		t.Errorf("Unexpected decoded value: %+v", v)
+	}
+	if string(body) != payload {
+		t.Errorf("Expected the raw body %q, got %q", payload, body)
+	}
+}
+
+func TestRestLiClient_DoAndDecodePaddedBody(t *testing.T) {
+	const padding = "\r\n \t"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		// Otherwise, the padded JSON bodies would be sniffed as text/plain
+		w.Header().Set("Content-Type", JsonContentType)
+		var body string
+		switch r.URL.Path {
+		case "/json":
+			body = `{"name":"foo"}`
+		case "/reduced":
+			w.Header().Set("Content-Type", "text/plain")
+			body = "(name:foo)"
+		case "/url":
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+			body = "(name:foo)"
+		case "/envelope":
+			body = `{"value":{"name":"foo"}}`
+		case "/batchObject":
+			body = `{"results":{"1":{"name":"foo"}}}`
+		case "/batchArray":
+			body = `[{"name":"foo"}]`
+		}
+		_, _ = w.Write([]byte(padding + body + padding))
+	}))
+	defer server.Close()
+	c := &RestLiClient{EnvelopeFieldNames: &EnvelopeFieldNames{Value: "value"}}
+
+	get := func(t *testing.T, path string) *http.Request {
+		req, err := c.GetRequest(context.Background(), mustParse(server.URL+path), Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	expectFoo := func(t *testing.T, name string, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "foo" {
+			t.Errorf("Unexpected name: %q", name)
+		}
+	}
+
+	for _, path := range []string{"/json", "/reduced", "/url"} {
+		t.Run("DoAndDecode"+path, func(t *testing.T) {
+			v := new(testSubtype)
+			_, err := c.DoAndDecode(get(t, path), v)
+			expectFoo(t, v.Name, err)
+		})
+	}
+	t.Run("DoAndDecodeWithBody", func(t *testing.T) {
+		v := new(testSubtype)
+		body, err := c.DoAndDecodeWithBody(get(t, "/reduced"), v)
+		expectFoo(t, v.Name, err)
+		if string(body) != padding+"(name:foo)"+padding {
+			t.Errorf("Expected the raw body to be returned as is, got %q", body)
+		}
+	})
+	t.Run("DoAndDecodeRaw", func(t *testing.T) {
+		raw, err := c.DoAndDecodeRaw(get(t, "/json"))
+		expectFoo(t, strings.Trim(string(raw["name"]), `"`), err)
+	})
+	t.Run("DoAndDecodeEnvelope", func(t *testing.T) {
+		var v struct{ Value testSubtype }
+		_, err := c.DoAndDecodeEnvelope(get(t, "/envelope"), &v)
+		expectFoo(t, v.Value.Name, err)
+	})
+	for _, path := range []string{"/batchObject", "/batchArray"} {
+		t.Run("DoAndDecodeBatchEnvelope"+path, func(t *testing.T) {
+			var v struct {
+				Results map[string]testSubtype `json:"results"`
+			}
+			_, err := c.DoAndDecodeBatchEnvelope(get(t, path), []string{"1"}, &v)
+			expectFoo(t, v.Results["1"].Name, err)
+		})
+	}
+	t.Run("WhitespaceOnly", func(t *testing.T) {
+		v := new(testSubtype)
+		if _, err := c.DoAndDecode(get(t, "/empty"), v); err != nil {
+			t.Errorf("Whitespace-only body should be treated as empty: %+v", err)
+		}
+	})
+}
+
+func TestRestLiClient_DoAndDecodeEmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		if r.URL.Path == "/noContent" {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	c := new(RestLiClient)
+
+	for _, path := range []string{"/noContent", "/empty"} {
+		t.Run(path, func(t *testing.T) {
+			req, err := c.GetRequest(context.Background(), mustParse(server.URL+path), Method_update)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v *struct{ Foo string }
+			_, err = c.DoAndDecode(req, &v)
+			if err != nil {
+				t.Fatalf("Empty body should not fail: %+v", err)
+			}
+			if v != nil {
+				t.Errorf("Expected a nil result, got %+v", v)
+			}
+		})
+	}
+}
+
+func TestRestLiClient_DoErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable"))
+	}))
+	c := new(RestLiClient)
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoAndIgnore(req)
+	var restLiError *RestLiError
+	if !errors.As(err, &restLiError) || restLiError.Status != http.StatusServiceUnavailable ||
+		string(restLiError.FullResponse) != "unavailable" {
+		t.Errorf("Expected a RestLiError, got %+v", err)
+	}
+
+	server.Close()
+	req, err = c.GetRequest(context.Background(), mustParse(server.URL), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoAndIgnore(req)
+	var transportError *TransportError
+	if !errors.As(err, &transportError) || errors.As(err, &restLiError) {
+		t.Errorf("Expected a TransportError, got %+v", err)
+	}
+}
+
+func TestRestLiClient_DoAndCheckExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		switch r.URL.Path {
+		case "/found":
+			_, _ = w.Write([]byte("{}"))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	c := new(RestLiClient)
+
+	for path, expected := range map[string]bool{"/found": true, "/missing": false} {
+		req, err := c.GetRequest(context.Background(), mustParse(server.URL+path), Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exists, err := c.DoAndCheckExists(req)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %+v", path, err)
+		}
+		if exists != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, path, exists)
+		}
+	}
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL+"/broken"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restLiError *RestLiError
+	if _, err = c.DoAndCheckExists(req); !errors.As(err, &restLiError) || restLiError.Status != http.StatusInternalServerError {
+		t.Errorf("Expected a RestLiError, got %+v", err)
+	}
+}
+
+func TestRestLiClient_JsonUpdateRequest(t *testing.T) {
+	u := mustParse("http://localhost/foo/1")
+
+	c := new(RestLiClient)
+	req, err := c.JsonUpdateRequest(context.Background(), u, Method_update, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPut || req.Header.Get(MethodOverrideHeader) != "" {
+		t.Errorf("Expected a plain PUT, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+
+	c.UpdateWithPost = true
+	req, err = c.JsonUpdateRequest(context.Background(), u, Method_update, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost || req.Header.Get(MethodOverrideHeader) != http.MethodPut {
+		t.Errorf("Expected a POST overridden to PUT, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+	if req.Header.Get(RestLiHeader_Method) != Method_update.String() {
+		t.Errorf("Expected the %s method header, got %q", Method_update, req.Header.Get(RestLiHeader_Method))
+	}
+}
+
+func TestRestLiClient_ContentType(t *testing.T) {
+	u := mustParse("http://localhost/foo/1")
+	c := &RestLiClient{
+		ContentTypes: map[RestLiMethod]string{Method_partial_update: "application/json+patch"},
+	}
+
+	tests := []struct {
+		Method   RestLiMethod
+		Expected string
+	}{
+		{Method: Method_create, Expected: JsonContentType},
+		{Method: Method_action, Expected: JsonContentType},
+		{Method: Method_partial_update, Expected: "application/json+patch"},
+	}
+	for _, test := range tests {
+		t.Run(test.Method.String(), func(t *testing.T) {
+			req, err := c.JsonPostRequest(context.Background(), u, test.Method, map[string]string{"foo": "bar"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := req.Header.Get("Content-Type"); actual != test.Expected {
+				t.Errorf("Expected %q, got %q", test.Expected, actual)
+			}
+		})
+	}
+}
+
+type bespokeError struct {
+	Status int
+	Code   string
+}
+
+func (e *bespokeError) Error() string {
+	return e.Code
+}
+
+func TestRestLiClient_ErrorParser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		w.WriteHeader(http.StatusBadRequest)
+		if r.URL.Path == "/bespoke" {
+			_, _ = w.Write([]byte(`{"error":{"code":"INVALID_NAME"}}`))
+		} else {
+			_, _ = w.Write([]byte(`{"status":400,"message":"bad name"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := &RestLiClient{
+		ErrorParser: func(status int, body []byte) error {
+			var envelope struct {
+				Error *struct{ Code string }
+			}
+			if json.Unmarshal(body, &envelope) != nil || envelope.Error == nil {
+				return nil
+			}
+			return &bespokeError{Status: status, Code: envelope.Error.Code}
+		},
+	}
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL+"/bespoke"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]interface{}
+	_, err = c.DoAndDecode(req, &v)
+	var bespoke *bespokeError
+	if !errors.As(err, &bespoke) || bespoke.Status != http.StatusBadRequest || bespoke.Code != "INVALID_NAME" {
+		t.Errorf("Expected a bespokeError, got %+v", err)
+	}
+
+	// The standard parsing is used when the parser does not recognize the body
+	req, err = c.GetRequest(context.Background(), mustParse(server.URL+"/standard"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoAndDecode(req, &v)
+	var restLiError *RestLiError
+	if !errors.As(err, &restLiError) || restLiError.Message != "bad name" {
+		t.Errorf("Expected a RestLiError, got %+v", err)
+	}
+}
+
+func TestRestLiClient_TunnelMethods(t *testing.T) {
+	u := mustParse("http://localhost/foo/1")
+	c := &RestLiClient{TunnelMethods: true}
+
+	req, err := c.DeleteRequest(context.Background(), u, Method_delete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost || req.Header.Get(MethodOverrideHeader) != http.MethodDelete {
+		t.Errorf("Expected a POST overridden to DELETE, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+
+	req, err = c.JsonUpdateRequest(context.Background(), u, Method_update, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost || req.Header.Get(MethodOverrideHeader) != http.MethodPut {
+		t.Errorf("Expected a POST overridden to PUT, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+
+	req, err = c.JsonPostRequest(context.Background(), u, Method_create, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost || req.Header.Get(MethodOverrideHeader) != "" {
+		t.Errorf("Expected a plain POST, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+
+	req, err = c.GetRequest(context.Background(), u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodGet || req.Header.Get(MethodOverrideHeader) != "" {
+		t.Errorf("Expected a plain GET, got %s with override %q", req.Method, req.Header.Get(MethodOverrideHeader))
+	}
+}
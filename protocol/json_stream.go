@@ -0,0 +1,123 @@
+package protocol
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// JsonStreamPostRequest creates a POST request whose JSON body is streamed from the given reader with chunked transfer
+// encoding, instead of being buffered in memory like the body of JsonPostRequest. It is meant for very large entities,
+// e.g. ones that are read from a file or encoded on the fly with JsonStream. Since the body is not buffered, it is
+// given as nil to RestLiClient.RequestSigner and the request cannot be replayed.
+func (c *RestLiClient) JsonStreamPostRequest(ctx context.Context, url *url.URL, restLiMethod RestLiMethod, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	// An unknown length makes the transport use chunked transfer encoding, even when http.NewRequest could determine
+	// the length of the body
+	req.ContentLength = -1
+	req.GetBody = nil
+
+	c.setRestLiHeaders(req, restLiMethod)
+	SetJsonAcceptHeader(req)
+	req.Header.Set("Content-Type", c.ContentType(restLiMethod))
+	c.setRequestID(req)
+	c.addHeaders(req)
+
+	return req, nil
+}
+
+// JsonStream returns a reader over the JSON encoding of the given value, which is encoded into a pipe as the reader is
+// consumed. Slices and arrays are encoded one element at a time, such that only the encoding of a single element is ever
+// held in memory. Any other value is fully marshaled by encoding/json before being written, since it cannot encode a
+// value incrementally, so JsonStream only saves the copy of the encoding into the request's buffer for those. The
+// encoding only starts on the first read, and closing the reader stops it, such that a request that is never sent does
+// not leak the encoding goroutine.
+func JsonStream(v interface{}) io.ReadCloser {
+	return &jsonStream{value: v}
+}
+
+type jsonStream struct {
+	value  interface{}
+	once   sync.Once
+	reader *io.PipeReader
+}
+
+func (s *jsonStream) start() {
+	s.once.Do(func() {
+		var w *io.PipeWriter
+		s.reader, w = io.Pipe()
+		go func() {
+			_ = w.CloseWithError(encodeJsonStream(w, s.value))
+		}()
+	})
+}
+
+func (s *jsonStream) Read(p []byte) (int, error) {
+	s.start()
+	return s.reader.Read(p)
+}
+
+func (s *jsonStream) Close() error {
+	// If the encoding has not started yet, prevent any subsequent Read from starting it
+	s.once.Do(func() {
+		s.reader, _ = io.Pipe()
+	})
+	return s.reader.Close()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodeJsonStream writes the same encoding as json.Encoder, but writes the elements of slices and arrays as they are
+// encoded instead of marshaling the whole value first
+func encodeJsonStream(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !isStreamableArray(rv) {
+		return json.NewEncoder(w).Encode(v)
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(rv.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+func isStreamableArray(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return false
+		}
+	case reflect.Array:
+	default:
+		return false
+	}
+	t := rv.Type()
+	// Byte slices are encoded as base64 strings, and custom marshalers decide their own encoding
+	return t.Elem().Kind() != reflect.Uint8 && !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType)
+}
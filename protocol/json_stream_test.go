@@ -0,0 +1,130 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRestLiClient_JsonStreamPostRequest(t *testing.T) {
+	type entity struct {
+		Name  string   `json:"name"`
+		Lines []string `json:"lines"`
+	}
+	expected := entity{Name: "big", Lines: make([]string, 10000)}
+	for i := range expected.Lines {
+		expected.Lines[i] = strings.Repeat("x", 100)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TransferEncoding) != 1 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("Expected a chunked body, got %q", r.TransferEncoding)
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != JsonContentType {
+			t.Errorf("Unexpected Content-Type: %q", contentType)
+		}
+		if method := r.Header.Get(RestLiHeader_Method); method != Method_create.String() {
+			t.Errorf("Unexpected method: %q", method)
+		}
+
+		var actual entity
+		if err := json.NewDecoder(r.Body).Decode(&actual); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Unexpected body: %+v", actual)
+		}
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		w.Header().Set(RestLiHeader_ID, "1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	c := &RestLiClient{}
+	for name, body := range map[string]func() io.Reader{
+		"JsonStream": func() io.Reader { return JsonStream(expected) },
+		"Reader": func() io.Reader {
+			data, _ := json.Marshal(expected)
+			return strings.NewReader(string(data))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := c.JsonStreamPostRequest(context.Background(), mustParse(server.URL+"/entities"),
+				Method_create, body())
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.DoAndIgnore(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id, _ := CreatedEntityID(res); id != "1" {
+				t.Errorf("Unexpected ID: %q", id)
+			}
+		})
+	}
+}
+
+func TestJsonStream(t *testing.T) {
+	data, err := ioutil.ReadAll(JsonStream(map[string]int{"a": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"a\":1}\n" {
+		t.Errorf("Unexpected encoding: %q", data)
+	}
+
+	_, err = ioutil.ReadAll(JsonStream(func() {}))
+	if err == nil {
+		t.Error("Expected the encoding error to be returned by Read")
+	}
+
+	_, err = ioutil.ReadAll(JsonStream([]interface{}{1, func() {}}))
+	if err == nil {
+		t.Error("Expected the encoding error to be returned by Read")
+	}
+
+	s := JsonStream(map[string]int{"a": 1})
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+		t.Errorf("Expected a closed stream to not be readable, got %v", err)
+	}
+}
+
+func TestJsonStream_Arrays(t *testing.T) {
+	type element struct {
+		Name string `json:"name"`
+	}
+	for name, v := range map[string]interface{}{
+		"slice":      []element{{Name: "a"}, {Name: "<b>"}},
+		"empty":      []element{},
+		"nil":        []element(nil),
+		"array":      [2]int{1, 2},
+		"bytes":      []byte("abc"),
+		"nested":     [][]int{{1}, {2, 3}},
+		"marshaler":  []json.RawMessage{json.RawMessage(`{"a":1}`)},
+		"rawMessage": json.RawMessage(`[1,2]`),
+	} {
+		t.Run(name, func(t *testing.T) {
+			expected, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := ioutil.ReadAll(JsonStream(v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != string(expected)+"\n" {
+				t.Errorf("Expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Metrics is notified of the outcome of every request sent by DoAndDecode and DoAndIgnore, which all the generated
+// methods use. It can be used to keep per-method counters and latency histograms.
+type Metrics interface {
+	// ObserveRequest is called once the response's status is known. The method is the RequestInfo's String (e.g.
+	// com.linkedin.foo.bar.Get) if one was attached to the request's context, otherwise the rest.li method (e.g. get).
+	// The status is the HTTP status of the response, including rest.li error responses, or 0 if it is unknown (e.g. the
+	// request never reached the server). The duration covers the HTTP round trip, which does not include reading the body
+	// of successful responses.
+	ObserveRequest(method string, status int, duration time.Duration)
+}
+
+// MetricsFunc is an adapter to use ordinary functions as Metrics
+type MetricsFunc func(method string, status int, duration time.Duration)
+
+func (f MetricsFunc) ObserveRequest(method string, status int, duration time.Duration) {
+	f(method, status, duration)
+}
+
+func (c *RestLiClient) observeRequest(req *http.Request, res *http.Response, err error, duration time.Duration) {
+	if c.Metrics == nil {
+		return
+	}
+
+	method := req.Header.Get(RestLiHeader_Method)
+	if info, ok := RequestInfoFromContext(req.Context()); ok {
+		method = info.String()
+	}
+
+	var status int
+	var restLiError *RestLiError
+	switch {
+	case res != nil:
+		status = res.StatusCode
+	case errors.As(err, &restLiError):
+		status = restLiError.Status
+	}
+
+	c.Metrics.ObserveRequest(method, status, duration)
+}
@@ -0,0 +1,52 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRestLiClient_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		if r.URL.Path == "/missing" {
+			w.Header().Set(RestLiHeader_ErrorResponse, "true")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404}`))
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	var observedMethod string
+	var observedStatus int
+	c := &RestLiClient{
+		Metrics: MetricsFunc(func(method string, status int, duration time.Duration) {
+			observedMethod, observedStatus = method, status
+		}),
+	}
+
+	send := func(path string, ctx context.Context) {
+		u, _ := url.Parse(server.URL + path)
+		req, err := c.GetRequest(ctx, u, Method_get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = c.DoAndIgnore(req)
+	}
+
+	send("/found", context.Background())
+	if observedMethod != "get" || observedStatus != http.StatusOK {
+		t.Errorf("Unexpected observation: %s %d", observedMethod, observedStatus)
+	}
+
+	info := RequestInfo{ResourceName: "testsuite.simple", MethodName: "Get", Method: Method_get}
+	send("/missing", WithRequestInfo(context.Background(), info))
+	if observedMethod != info.String() || observedStatus != http.StatusNotFound {
+		t.Errorf("Unexpected observation: %s %d", observedMethod, observedStatus)
+	}
+}
@@ -0,0 +1,47 @@
+package protocol
+
+// The rel of the links to the next and previous pages of a collection
+const (
+	LinkRelNext = "next"
+	LinkRelPrev = "prev"
+)
+
+// Link is a link to another page of a collection, as returned in the paging information of a CollectionResponse
+type Link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+	Type string `json:"type"`
+}
+
+// CollectionPaging is the paging information of a CollectionResponse, i.e. com.linkedin.restli.common.CollectionMetadata
+type CollectionPaging struct {
+	Start int `json:"start"`
+	Count int `json:"count"`
+	// Total is only set by servers that compute the total number of elements in the collection
+	Total *int   `json:"total,omitempty"`
+	Links []Link `json:"links,omitempty"`
+}
+
+// Link returns the href of the first link with the given rel, or an empty string if there is none. Hrefs are usually
+// relative to the host, and include the query of the request for the page they point to
+func (p *CollectionPaging) Link(rel string) string {
+	if p == nil {
+		return ""
+	}
+	for _, l := range p.Links {
+		if l.Rel == rel {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// NextLink returns the href of the link to the next page, or an empty string if this is the last page
+func (p *CollectionPaging) NextLink() string {
+	return p.Link(LinkRelNext)
+}
+
+// PrevLink returns the href of the link to the previous page, or an empty string if this is the first page
+func (p *CollectionPaging) PrevLink() string {
+	return p.Link(LinkRelPrev)
+}
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCollectionPaging_Links(t *testing.T) {
+	var paging *CollectionPaging
+	err := json.Unmarshal([]byte(`{
+  "start": 10,
+  "count": 10,
+  "links": [
+    {"rel": "prev", "href": "/greetings?count=10&start=0&q=search", "type": "application/json"},
+    {"rel": "next", "href": "/greetings?count=10&start=20&q=search", "type": "application/json"}
+  ]
+}`), &paging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if paging.Start != 10 || paging.Count != 10 || paging.Total != nil {
+		t.Errorf("Unexpected paging: %+v", paging)
+	}
+	if expected := "/greetings?count=10&start=20&q=search"; paging.NextLink() != expected {
+		t.Errorf("Expected %q, got %q", expected, paging.NextLink())
+	}
+	if expected := "/greetings?count=10&start=0&q=search"; paging.PrevLink() != expected {
+		t.Errorf("Expected %q, got %q", expected, paging.PrevLink())
+	}
+	if paging.Link("self") != "" {
+		t.Errorf("Unexpected self link %q", paging.Link("self"))
+	}
+
+	paging = nil
+	if paging.NextLink() != "" || paging.PrevLink() != "" {
+		t.Errorf("Expected no links on nil paging")
+	}
+}
@@ -0,0 +1,214 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ArrayMove moves the element at FromIndex to ToIndex, shifting the elements in between
+type ArrayMove struct {
+	FromIndex int32 `json:"fromIndex"`
+	ToIndex   int32 `json:"toIndex"`
+}
+
+// ArrayPatch holds the element-level operations that can be applied to an array field in a PARTIAL_UPDATE. Rest.li
+// only defines the $reorder directive for arrays, individual elements cannot be set or removed by index. To change the
+// contents of an array, the whole array must be sent via $set
+type ArrayPatch struct {
+	Reorder []ArrayMove `json:"$reorder,omitempty"`
+}
+
+// Move appends a $reorder directive that moves the element at fromIndex to toIndex
+func (p *ArrayPatch) Move(fromIndex, toIndex int32) *ArrayPatch {
+	p.Reorder = append(p.Reorder, ArrayMove{FromIndex: fromIndex, ToIndex: toIndex})
+	return p
+}
+
+// Apply applies all the $reorder directives, in order, to the given slice. The slice is modified in place
+func (p *ArrayPatch) Apply(slice interface{}) error {
+	s := reflect.ValueOf(slice)
+	if s.Kind() != reflect.Slice {
+		return fmt.Errorf("go-restli: Cannot apply array patch to %T", slice)
+	}
+
+	for _, m := range p.Reorder {
+		from, to := int(m.FromIndex), int(m.ToIndex)
+		if from < 0 || from >= s.Len() || to < 0 || to >= s.Len() {
+			return fmt.Errorf("go-restli: Cannot move element %d to %d in array of length %d", from, to, s.Len())
+		}
+
+		e := reflect.New(s.Type().Elem()).Elem()
+		e.Set(s.Index(from))
+		if from < to {
+			reflect.Copy(s.Slice(from, to), s.Slice(from+1, to+1))
+		} else {
+			reflect.Copy(s.Slice(to+1, from+1), s.Slice(to, from))
+		}
+		s.Index(to).Set(e)
+	}
+
+	return nil
+}
+
+// DeepCopier is implemented by pointer types whose unexported state must be copied too, such as the generated Lazy
+// types. DeepCopy returns a copy of the receiver, of the same type, that shares no memory with it
+type DeepCopier interface {
+	DeepCopy() interface{}
+}
+
+// DeepEqualer is implemented by pointer types that can be equal without being identical, such as the generated Lazy
+// types which can hold either the raw JSON or the decoded value. DeepEqual is only called with a non-nil receiver and a
+// non-nil value of the same type
+type DeepEqualer interface {
+	DeepEqual(other interface{}) bool
+}
+
+var (
+	deepCopierType  = reflect.TypeOf((*DeepCopier)(nil)).Elem()
+	deepEqualerType = reflect.TypeOf((*DeepEqualer)(nil)).Elem()
+)
+
+// DeepCopy returns a copy of v that shares no pointer, slice or map with it, such that modifying either one never
+// affects the other. Only exported struct fields are copied deeply, unexported ones are copied as is unless they belong
+// to a DeepCopier. It is used by the generated Diff functions to copy values into patches.
+func DeepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(v)).Interface()
+}
+
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if v.Type().Implements(deepCopierType) && v.CanInterface() {
+			return reflect.ValueOf(v.Interface().(DeepCopier).DeepCopy())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	default:
+		return v
+	}
+}
+
+// DeepEqual reports whether a and b are deeply equal like reflect.DeepEqual does, except that DeepEqualers are
+// compared with their DeepEqual method. It is used by the generated Diff functions to find the fields that changed.
+func DeepEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return deepEqual(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func deepEqual(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Pointer() == b.Pointer() {
+			return true
+		}
+		if a.Type().Implements(deepEqualerType) && a.CanInterface() {
+			return a.Interface().(DeepEqualer).DeepEqual(b.Interface())
+		}
+		return deepEqual(a.Elem(), b.Elem())
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return deepEqual(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if !deepEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			v := b.MapIndex(iter.Key())
+			if !v.IsValid() || !deepEqual(iter.Value(), v) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !deepEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Func:
+		// Like reflect.DeepEqual, funcs are only equal if both are nil
+		return a.IsNil() && b.IsNil()
+	default:
+		// Channels and unsafe pointers are only equal if they are the same
+		return a.Pointer() == b.Pointer()
+	}
+}
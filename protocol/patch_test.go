@@ -0,0 +1,163 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestArrayPatch_Move(t *testing.T) {
+	patch := new(ArrayPatch).Move(2, 0)
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"$reorder":[{"fromIndex":2,"toIndex":0}]}`
+	if string(data) != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, string(data))
+	}
+}
+
+func TestArrayPatch_Apply(t *testing.T) {
+	arr := []string{"a", "b", "c", "d"}
+
+	err := new(ArrayPatch).Move(0, 2).Move(3, 1).Apply(arr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"b", "d", "c", "a"}
+	if !reflect.DeepEqual(expected, arr) {
+		t.Errorf("Expected: %v, Got: %v", expected, arr)
+	}
+
+	err = new(ArrayPatch).Move(0, 4).Apply(arr)
+	if err == nil {
+		t.Errorf("Expected an error when moving out of bounds")
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	type inner struct {
+		Value *string
+	}
+	type record struct {
+		Inner  *inner
+		Ints   []int64
+		Map    map[string]*inner
+		Union  struct{ Long *int64 }
+		hidden *int
+	}
+
+	s, l, h := "a", int64(1), 2
+	original := &record{
+		Inner:  &inner{Value: &s},
+		Ints:   []int64{1, 2},
+		Map:    map[string]*inner{"k": {Value: &s}},
+		hidden: &h,
+	}
+	original.Union.Long = &l
+
+	c := DeepCopy(original).(*record)
+	if !reflect.DeepEqual(c, original) {
+		t.Fatalf("Expected %+v, got %+v", original, c)
+	}
+	if c.Inner == original.Inner || c.Inner.Value == original.Inner.Value || &c.Ints[0] == &original.Ints[0] ||
+		c.Map["k"] == original.Map["k"] || c.Union.Long == original.Union.Long {
+		t.Errorf("Copy shares memory with the original")
+	}
+
+	*original.Inner.Value = "b"
+	original.Ints[0] = 3
+	if *c.Inner.Value != "a" || c.Ints[0] != 1 {
+		t.Errorf("Changes to the original leaked into the copy: %+v", c)
+	}
+
+	if DeepCopy(nil) != nil {
+		t.Errorf("nil should be copied as nil")
+	}
+	if c := DeepCopy((*record)(nil)).(*record); c != nil {
+		t.Errorf("A nil pointer should be copied as nil, got %+v", c)
+	}
+}
+
+// lazyString mimics the generated Lazy types, which hold either raw JSON or the decoded value
+type lazyString struct {
+	raw   []byte
+	value *string
+}
+
+func (l *lazyString) decoded() string {
+	if l.value != nil {
+		return *l.value
+	}
+	var s string
+	_ = json.Unmarshal(l.raw, &s)
+	return s
+}
+
+func (l *lazyString) DeepCopy() interface{} {
+	c := &lazyString{raw: append([]byte(nil), l.raw...)}
+	if l.value != nil {
+		v := *l.value
+		c.value = &v
+	}
+	return c
+}
+
+func (l *lazyString) DeepEqual(other interface{}) bool {
+	return l.decoded() == other.(*lazyString).decoded()
+}
+
+func TestDeepCopy_DeepCopier(t *testing.T) {
+	s := "a"
+	original := &struct{ Lazy *lazyString }{Lazy: &lazyString{value: &s}}
+
+	c := DeepCopy(original).(*struct{ Lazy *lazyString })
+	if c.Lazy == original.Lazy || c.Lazy.value == original.Lazy.value {
+		t.Errorf("Copy shares memory with the original")
+	}
+	*original.Lazy.value = "b"
+	if *c.Lazy.value != "a" {
+		t.Errorf("Changes to the original leaked into the copy: %s", *c.Lazy.value)
+	}
+}
+
+func TestDeepEqual(t *testing.T) {
+	type record struct {
+		Ints   []int64
+		Map    map[string]*string
+		Lazy   *lazyString
+		hidden int
+	}
+
+	a, b := "a", "a"
+	tests := []struct {
+		name     string
+		a, b     interface{}
+		expected bool
+	}{
+		{"nil", nil, nil, true},
+		{"nil and value", nil, &record{}, false},
+		{"different types", int32(1), int64(1), false},
+		{"equal", &record{Ints: []int64{1}, Map: map[string]*string{"k": &a}}, &record{Ints: []int64{1}, Map: map[string]*string{"k": &b}}, true},
+		{"nil and empty slice", &record{Ints: []int64{}}, &record{}, false},
+		{"different map values", &record{Map: map[string]*string{"k": &a}}, &record{Map: map[string]*string{"k": nil}}, false},
+		{"unexported fields", &record{hidden: 1}, &record{hidden: 2}, false},
+		{"raw and decoded lazy", &record{Lazy: &lazyString{raw: []byte(`"a"`)}}, &record{Lazy: &lazyString{value: &a}}, true},
+		{"different lazy", &record{Lazy: &lazyString{raw: []byte(`"b"`)}}, &record{Lazy: &lazyString{value: &a}}, false},
+		{"nil lazy", &record{}, &record{Lazy: &lazyString{value: &a}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := DeepEqual(test.a, test.b); actual != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, actual)
+			}
+			if actual := DeepEqual(test.b, test.a); actual != test.expected {
+				t.Errorf("Expected %v when swapped, got %v", test.expected, actual)
+			}
+		})
+	}
+}
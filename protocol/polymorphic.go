@@ -0,0 +1,146 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Polymorphic records are records whose concrete type is only known at runtime, i.e. a base record or any of the
+// records that include it. The concrete type is given by a discriminator field that holds the fully-qualified name of
+// the type, which is looked up in the registry populated by RegisterObject. The code generator uses the functions below
+// to (de)serialize the fields whose type is a base record configured as polymorphic.
+
+// MarshalPolymorphic marshals the given model to JSON, setting its discriminator field to the model's fully-qualified
+// name. A nil model is marshaled to null.
+func MarshalPolymorphic(discriminator string, obj RestLiObject) ([]byte, error) {
+	if isNilObject(obj) {
+		return []byte("null"), nil
+	}
+
+	fqn, ok := ObjectName(obj)
+	if !ok {
+		return nil, fmt.Errorf("go-restli: Unregistered polymorphic type %T", obj)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	fields[discriminator], err = json.Marshal(fqn)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalPolymorphic unmarshals the given JSON object into a new instance of the model named by its discriminator
+// field
+func UnmarshalPolymorphic(discriminator string, data []byte) (RestLiObject, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := fields[discriminator]
+	if !ok {
+		return nil, fmt.Errorf("go-restli: Missing discriminator field %q", discriminator)
+	}
+	var fqn string
+	err = json.Unmarshal(raw, &fqn)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnmarshalObject(fqn, data)
+}
+
+// EncodePolymorphic is the rest.li equivalent of MarshalPolymorphic
+func EncodePolymorphic(codec RestLiCodec, discriminator string, obj RestLiObject) (string, error) {
+	if isNilObject(obj) {
+		return "", fmt.Errorf("go-restli: Cannot encode a nil polymorphic %T since rest.li has no null", obj)
+	}
+
+	fqn, ok := ObjectName(obj)
+	if !ok {
+		return "", fmt.Errorf("go-restli: Unregistered polymorphic type %T", obj)
+	}
+
+	data, err := obj.RestLiEncode(codec)
+	if err != nil {
+		return "", err
+	}
+	fields, err := codec.DecodeObject(data)
+	if err != nil {
+		return "", err
+	}
+	fields[discriminator] = codec.EncodeString(fqn)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	buf.WriteByte('(')
+	for i, k := range keys {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(k + ":" + fields[k])
+	}
+	buf.WriteByte(')')
+	return buf.String(), nil
+}
+
+// isNilObject returns true for both nil interfaces and interfaces that hold a nil pointer, since the latter marshal to
+// null rather than to an object
+func isNilObject(obj RestLiObject) bool {
+	if obj == nil {
+		return true
+	}
+	v := reflect.ValueOf(obj)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// DecodePolymorphic is the rest.li equivalent of UnmarshalPolymorphic
+func DecodePolymorphic(codec RestLiCodec, discriminator string, data string) (RestLiObject, error) {
+	fields, err := codec.DecodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := fields[discriminator]
+	if !ok {
+		return nil, fmt.Errorf("go-restli: Missing discriminator field %q", discriminator)
+	}
+	var fqn string
+	err = codec.DecodeString(raw, &fqn)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := NewObject(fqn)
+	if !ok {
+		return nil, fmt.Errorf("go-restli: Unknown type %s", fqn)
+	}
+	decodable, ok := obj.(RestLiEncodable)
+	if !ok {
+		return nil, fmt.Errorf("go-restli: %s cannot be decoded from the rest.li format", fqn)
+	}
+	err = decodable.RestLiDecode(codec, data)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"testing"
+)
+
+type testSubtype struct {
+	Kind *string `json:"kind,omitempty"`
+	Name string  `json:"name"`
+}
+
+func (t *testSubtype) RestLiEncode(codec RestLiCodec) (string, error) {
+	data := "(name:" + codec.EncodeString(t.Name)
+	if t.Kind != nil {
+		data += ",kind:" + codec.EncodeString(*t.Kind)
+	}
+	return data + ")", nil
+}
+
+func (t *testSubtype) RestLiDecode(codec RestLiCodec, data string) error {
+	fields, err := codec.DecodeObject(data)
+	if err != nil {
+		return err
+	}
+	if kind, ok := fields["kind"]; ok {
+		t.Kind = new(string)
+		if err = codec.DecodeString(kind, t.Kind); err != nil {
+			return err
+		}
+	}
+	return codec.DecodeString(fields["name"], &t.Name)
+}
+
+func TestPolymorphic(t *testing.T) {
+	RegisterObject("protocol.testSubtype", func() RestLiObject { return new(testSubtype) })
+
+	data, err := MarshalPolymorphic("kind", &testSubtype{Name: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"kind":"protocol.testSubtype","name":"foo"}`; string(data) != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, data)
+	}
+
+	obj, err := UnmarshalPolymorphic("kind", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := obj.(*testSubtype); !ok || v.Name != "foo" {
+		t.Errorf("Expected a testSubtype named foo, got %+v", obj)
+	}
+
+	encoded, err := EncodePolymorphic(RestLiUrlEncoder, "kind", &testSubtype{Name: "foo bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `(kind:protocol.testSubtype,name:foo%20bar)`; encoded != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, encoded)
+	}
+
+	obj, err = DecodePolymorphic(RestLiUrlEncoder, "kind", encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := obj.(*testSubtype); !ok || v.Name != "foo bar" {
+		t.Errorf("Expected a testSubtype named foo bar, got %+v", obj)
+	}
+
+	for _, nilObj := range []RestLiObject{nil, (*testSubtype)(nil)} {
+		data, err = MarshalPolymorphic("kind", nilObj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "null" {
+			t.Errorf("Expected a nil %T to marshal to null, got: %s", nilObj, data)
+		}
+		if _, err = EncodePolymorphic(RestLiUrlEncoder, "kind", nilObj); err == nil {
+			t.Errorf("Expected an error when encoding a nil %T", nilObj)
+		}
+	}
+
+	if _, err = UnmarshalPolymorphic("kind", []byte(`{"name":"foo"}`)); err == nil {
+		t.Errorf("Expected an error for a missing discriminator")
+	}
+	if _, err = UnmarshalPolymorphic("kind", []byte(`{"kind":"protocol.unknown"}`)); err == nil {
+		t.Errorf("Expected an error for an unknown type")
+	}
+}
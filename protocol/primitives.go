@@ -13,11 +13,19 @@ func (r *RestLiCodec) EncodeInt64(v int64) string {
 	return fmt.Sprintf("%d", v)
 }
 
+// EncodeFloat32 uses the shortest representation of the float, unless the codec was created with WithPlainFloats
 func (r *RestLiCodec) EncodeFloat32(v float32) string {
+	if r.plainFloats {
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
 	return fmt.Sprintf("%g", v)
 }
 
+// EncodeFloat64 uses the shortest representation of the float, unless the codec was created with WithPlainFloats
 func (r *RestLiCodec) EncodeFloat64(v float64) string {
+	if r.plainFloats {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
 	return fmt.Sprintf("%g", v)
 }
 
@@ -25,7 +33,19 @@ func (r *RestLiCodec) EncodeBool(v bool) string {
 	return fmt.Sprintf("%t", v)
 }
 
+// EmptyString is how the rest.li protocol encodes empty strings, which would otherwise be indistinguishable from absent
+// values and, in paths, would produce empty segments
+const EmptyString = "''"
+
+// EncodeString escapes the given string. Empty strings are encoded as EmptyString, unless the codec follows rest.li 1.0
+// which has no such literal and leaves them empty
 func (r *RestLiCodec) EncodeString(v string) string {
+	if v == "" {
+		if r.protocolVersion1 {
+			return ""
+		}
+		return EmptyString
+	}
 	return r.encoder(v)
 }
 
@@ -69,16 +89,36 @@ func (r *RestLiCodec) DecodeFloat64(data string, v *float64) error {
 	return nil
 }
 
+// DecodeBool only accepts true and false, unless the codec was created with WithLenientBool
 func (r *RestLiCodec) DecodeBool(data string, v *bool) error {
-	b, err := strconv.ParseBool(data)
-	if err != nil {
-		return err
+	if r.lenientBool {
+		if unescaped, err := r.decoder(data); err == nil {
+			data = unescaped
+		}
+		switch data {
+		case `"true"`, "1":
+			data = "true"
+		case `"false"`, "0":
+			data = "false"
+		}
+	}
+
+	switch data {
+	case "true":
+		*v = true
+	case "false":
+		*v = false
+	default:
+		return &strconv.NumError{Func: "DecodeBool", Num: data, Err: strconv.ErrSyntax}
 	}
-	*v = bool(b)
 	return nil
 }
 
 func (r *RestLiCodec) DecodeString(data string, v *string) error {
+	if data == EmptyString {
+		*v = ""
+		return nil
+	}
 	s, err := r.decoder(data)
 	if err != nil {
 		return err
@@ -0,0 +1,151 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+const FieldsParam = "fields"
+
+// PathSpec identifies a field of a model to include in a projection. Nested fields are identified by their full path,
+// e.g. PathSpec{"message", "id"} selects the id field of the message field
+type PathSpec []string
+
+func NewPathSpec(segments ...string) PathSpec {
+	return segments
+}
+
+type projectionNode struct {
+	name     string
+	selected bool
+	children []*projectionNode
+}
+
+func (n *projectionNode) child(name string) *projectionNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &projectionNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *projectionNode) encode(buf *strings.Builder) {
+	for i, c := range n.children {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(c.name)
+		if len(c.children) > 0 {
+			buf.WriteString(":(")
+			c.encode(buf)
+			buf.WriteByte(')')
+		}
+	}
+}
+
+// EncodeProjection encodes the given fields using the rest.li 2.0 projection syntax, e.g. a,b:(c,d). Fields are
+// encoded in the order in which they are first given. Selecting a field also selects all its children, regardless of
+// whether some of its children are selected explicitly
+func EncodeProjection(fields []PathSpec) string {
+	root := new(projectionNode)
+	for _, f := range fields {
+		n := root
+		for _, segment := range f {
+			n = n.child(segment)
+		}
+		n.selected = true
+	}
+
+	root.prune()
+
+	var buf strings.Builder
+	root.encode(&buf)
+	return buf.String()
+}
+
+// prune removes the children of any node that was itself selected, since a selected node includes all its children
+func (n *projectionNode) prune() {
+	for _, c := range n.children {
+		if c.selected {
+			c.children = nil
+		} else {
+			c.prune()
+		}
+	}
+}
+
+// DecodeProjection decodes a projection encoded with the rest.li 2.0 projection syntax, e.g. a,b:(c,d), into the
+// PathSpec of each selected field, in the order in which they appear. It is the inverse of EncodeProjection, and
+// returns no fields for an empty projection
+func DecodeProjection(projection string) (fields []PathSpec, err error) {
+	if projection == "" {
+		return nil, nil
+	}
+	rest, err := decodeProjectionFields(projection, nil, &fields)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("go-restli: Unexpected %q in projection %q", rest, projection)
+	}
+	return fields, nil
+}
+
+// decodeProjectionFields decodes the comma-separated fields of the given projection up to the end of the string or an
+// unmatched closing parenthesis, which is returned as part of the rest of the projection
+func decodeProjectionFields(projection string, parent PathSpec, fields *[]PathSpec) (rest string, err error) {
+	for {
+		end := strings.IndexAny(projection, ",:()")
+		if end == -1 {
+			end = len(projection)
+		}
+		name := projection[:end]
+		if name == "" {
+			return "", fmt.Errorf("go-restli: Empty field name in projection")
+		}
+		spec := parent.Append(name)
+		projection = projection[end:]
+
+		if strings.HasPrefix(projection, ":(") {
+			projection, err = decodeProjectionFields(projection[2:], spec, fields)
+			if err != nil {
+				return "", err
+			}
+			if !strings.HasPrefix(projection, ")") {
+				return "", fmt.Errorf("go-restli: Unterminated sub-projection of %q", name)
+			}
+			projection = projection[1:]
+		} else {
+			*fields = append(*fields, spec)
+		}
+
+		if !strings.HasPrefix(projection, ",") {
+			return projection, nil
+		}
+		projection = projection[1:]
+	}
+}
+
+// AddProjection appends the fields query parameter to the given path, if any fields are given
+func AddProjection(path string, fields []PathSpec) string {
+	if len(fields) == 0 {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		path += "&"
+	} else {
+		path += "?"
+	}
+	return path + FieldsParam + "=" + EncodeProjection(fields)
+}
+
+// Append returns a new PathSpec made of this PathSpec followed by the given segments. Unlike append, it never modifies
+// the underlying array of this PathSpec, so that PathSpecs with a common prefix can safely be derived from one another
+func (p PathSpec) Append(segments ...string) PathSpec {
+	spec := make(PathSpec, 0, len(p)+len(segments))
+	spec = append(spec, p...)
+	return append(spec, segments...)
+}
@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeProjection(t *testing.T) {
+	tests := []struct {
+		fields   []PathSpec
+		expected string
+	}{
+		{
+			fields:   []PathSpec{NewPathSpec("id")},
+			expected: "id",
+		},
+		{
+			fields:   []PathSpec{NewPathSpec("id"), NewPathSpec("message", "id"), NewPathSpec("message", "body")},
+			expected: "id,message:(id,body)",
+		},
+		{
+			fields:   []PathSpec{NewPathSpec("message", "id"), NewPathSpec("message")},
+			expected: "message",
+		},
+	}
+
+	for _, test := range tests {
+		if actual := EncodeProjection(test.fields); actual != test.expected {
+			t.Errorf("Expected: %s, Got: %s", test.expected, actual)
+		}
+	}
+}
+
+func TestDecodeProjection(t *testing.T) {
+	tests := []struct {
+		projection string
+		expected   []PathSpec
+	}{
+		{
+			projection: "",
+			expected:   nil,
+		},
+		{
+			projection: "id",
+			expected:   []PathSpec{NewPathSpec("id")},
+		},
+		{
+			projection: "id,message:(id,body)",
+			expected:   []PathSpec{NewPathSpec("id"), NewPathSpec("message", "id"), NewPathSpec("message", "body")},
+		},
+		{
+			projection: "a:(b:(c),d),e",
+			expected:   []PathSpec{NewPathSpec("a", "b", "c"), NewPathSpec("a", "d"), NewPathSpec("e")},
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := DecodeProjection(test.projection)
+		if err != nil {
+			t.Errorf("Could not decode %q: %v", test.projection, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("Expected: %v, Got: %v", test.expected, actual)
+		}
+		if encoded := EncodeProjection(actual); encoded != test.projection {
+			t.Errorf("Expected %q to be re-encoded as is, got %q", test.projection, encoded)
+		}
+	}
+
+	for _, projection := range []string{",", "a,", "a:()", "a:(b", "a)", "a:(b))", "a:b"} {
+		if _, err := DecodeProjection(projection); err == nil {
+			t.Errorf("Expected %q to be rejected", projection)
+		}
+	}
+}
+
+func TestAddProjection(t *testing.T) {
+	fields := []PathSpec{NewPathSpec("id")}
+
+	if actual := AddProjection("/collection/1", nil); actual != "/collection/1" {
+		t.Errorf("Expected no fields parameter, Got: %s", actual)
+	}
+	if actual := AddProjection("/collection/1", fields); actual != "/collection/1?fields=id" {
+		t.Errorf("Expected: /collection/1?fields=id, Got: %s", actual)
+	}
+	if actual := AddProjection("/collection/1?altkey=handle", fields); actual != "/collection/1?altkey=handle&fields=id" {
+		t.Errorf("Expected: /collection/1?altkey=handle&fields=id, Got: %s", actual)
+	}
+}
+
+func TestPathSpec_Append(t *testing.T) {
+	parent := make(PathSpec, 1, 10)
+	parent[0] = "a"
+	b := parent.Append("b")
+	c := parent.Append("c")
+	if b[1] != "b" || c[1] != "c" {
+		t.Errorf("PathSpecs derived from the same parent should not share their storage: %v, %v", b, c)
+	}
+	if len(parent) != 1 {
+		t.Errorf("Parent should not be modified: %v", parent)
+	}
+}
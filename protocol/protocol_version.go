@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// RestLiProtocolVersion1 is the legacy version of the rest.li protocol, which RestLiClient.ProtocolVersion can be set to
+// in order to target servers that do not understand rest.li 2.0 URLs.
+//
+// Only the parts of the URL format that differ for the types supported by both versions are translated: batch keys are
+// sent as one ids query parameter per key (ids=1&ids=2) instead of a single List(1,2), and empty strings are sent as is
+// rather than as EmptyString. Primitive keys and query parameters are otherwise encoded identically by both versions.
+// Rest.li 1.0 flattens records, unions, arrays and maps into multiple query parameters, which is not supported:
+// encoding them fails with ErrProtocolVersion1ComplexValue, so complex keys and complex finder parameters cannot be
+// sent to such servers.
+// https://linkedin.github.io/rest.li/spec/protocol#protocol-versions
+const RestLiProtocolVersion1 = "1.0.0"
+
+// ErrProtocolVersion1ComplexValue is returned when a record, union, array or map is encoded with the codec of a client
+// that uses RestLiProtocolVersion1, see RestLiCodec.CheckComplexValue
+var ErrProtocolVersion1ComplexValue = errors.New("go-restli: Records, unions, arrays and maps cannot be encoded with " +
+	"rest.li protocol version " + RestLiProtocolVersion1)
+
+// CheckComplexValue returns ErrProtocolVersion1ComplexValue if the codec follows rest.li 1.0, which does not encode
+// records, unions, arrays and maps with the rest.li 2.0 syntax. It is called by the generated code before encoding
+// such values
+func (r *RestLiCodec) CheckComplexValue() error {
+	if r.protocolVersion1 {
+		return ErrProtocolVersion1ComplexValue
+	}
+	return nil
+}
+
+// BatchIdsParam is the query parameter that holds the keys of batch requests
+const BatchIdsParam = "ids"
+
+func (c *RestLiClient) protocolVersion() string {
+	if c.ProtocolVersion != "" {
+		return c.ProtocolVersion
+	}
+	return RestLiProtocolVersion
+}
+
+func (c *RestLiClient) isProtocolVersion1() bool {
+	return c.ProtocolVersion == RestLiProtocolVersion1
+}
+
+// setRestLiHeaders is like SetRestLiHeaders, but sends the client's ProtocolVersion
+func (c *RestLiClient) setRestLiHeaders(req *http.Request, method RestLiMethod) {
+	SetRestLiHeaders(req, method)
+	req.Header.Set(RestLiHeader_ProtocolVersion, c.protocolVersion())
+}
+
+// EncodeBatchIds returns the query that holds the given keys of a batch request, which must already be encoded. With
+// rest.li 2.0 the keys are sent as ids=List(k1,k2), and as ids=k1&ids=k2 with rest.li 1.0.
+func (c *RestLiClient) EncodeBatchIds(encodedKeys []string) string {
+	var ids strings.Builder
+	if c.isProtocolVersion1() {
+		for i, k := range encodedKeys {
+			if i != 0 {
+				ids.WriteByte('&')
+			}
+			ids.WriteString(BatchIdsParam + "=")
+			ids.WriteString(k)
+		}
+		return ids.String()
+	}
+
+	ids.WriteString(BatchIdsParam + "=List(")
+	for i, k := range encodedKeys {
+		if i != 0 {
+			ids.WriteByte(',')
+		}
+		ids.WriteString(k)
+	}
+	ids.WriteByte(')')
+	return ids.String()
+}
+
+// EncodedBatchIdsLength returns the length of the query returned by EncodeBatchIds for keyCount keys whose encoded
+// lengths add up to encodedKeysLength, without building it. It is used by the generated EncodedIdsLength functions,
+// which help split batches whose URL would exceed the limit of the server.
+func (c *RestLiClient) EncodedBatchIdsLength(keyCount, encodedKeysLength int) int {
+	separators := 0
+	if keyCount > 1 {
+		separators = keyCount - 1
+	}
+	if c.isProtocolVersion1() {
+		return keyCount*len(BatchIdsParam+"=") + separators + encodedKeysLength
+	}
+	return len(BatchIdsParam+"=List()") + separators + encodedKeysLength
+}
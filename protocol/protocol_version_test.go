@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestLiClient_EncodeBatchIds(t *testing.T) {
+	keys := []string{"1", "a%2Cb"}
+
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{version: "", expected: "ids=List(1,a%2Cb)"},
+		{version: RestLiProtocolVersion, expected: "ids=List(1,a%2Cb)"},
+		{version: RestLiProtocolVersion1, expected: "ids=1&ids=a%2Cb"},
+	}
+	for _, test := range tests {
+		c := &RestLiClient{ProtocolVersion: test.version}
+		if actual := c.EncodeBatchIds(keys); actual != test.expected {
+			t.Errorf("Expected %q for version %q, got %q", test.expected, test.version, actual)
+		}
+	}
+
+	if actual := new(RestLiClient).EncodeBatchIds(nil); actual != "ids=List()" {
+		t.Errorf("Unexpected empty batch: %q", actual)
+	}
+}
+
+func TestRestLiClient_EncodedBatchIdsLength(t *testing.T) {
+	for _, version := range []string{RestLiProtocolVersion, RestLiProtocolVersion1} {
+		c := &RestLiClient{ProtocolVersion: version}
+		for _, keys := range [][]string{nil, {"1"}, {"1", "a%2Cb", "foo"}} {
+			keysLength := 0
+			for _, k := range keys {
+				keysLength += len(k)
+			}
+			expected := len(c.EncodeBatchIds(keys))
+			if actual := c.EncodedBatchIdsLength(len(keys), keysLength); actual != expected {
+				t.Errorf("Expected %d for %q with version %q, got %d", expected, keys, version, actual)
+			}
+		}
+	}
+}
+
+func TestRestLiClient_ProtocolVersion1Codec(t *testing.T) {
+	tests := []struct {
+		version     string
+		emptyString string
+		err         error
+	}{
+		{version: "", emptyString: EmptyString},
+		{version: RestLiProtocolVersion, emptyString: EmptyString},
+		{version: RestLiProtocolVersion1, emptyString: "", err: ErrProtocolVersion1ComplexValue},
+	}
+	for _, test := range tests {
+		c := &RestLiClient{ProtocolVersion: test.version, Codec: RestLiUrlEncoder.WithPlainFloats()}
+		urlCodec, reducedCodec := c.UrlCodec(), c.ReducedCodec()
+		for _, codec := range []*RestLiCodec{&urlCodec, &reducedCodec} {
+			if actual := codec.EncodeString(""); actual != test.emptyString {
+				t.Errorf("Expected %q for version %q, got %q", test.emptyString, test.version, actual)
+			}
+			if err := codec.CheckComplexValue(); !errors.Is(err, test.err) {
+				t.Errorf("Expected %v for version %q, got %v", test.err, test.version, err)
+			}
+			if actual := codec.EncodeFloat64(1e6); actual != "1000000" {
+				t.Errorf("Expected the client's options to be kept for version %q, got %q", test.version, actual)
+			}
+		}
+	}
+
+	// Codecs that are not the client's always follow rest.li 2.0
+	if err := RestLiUrlEncoder.CheckComplexValue(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestLiClient_ProtocolVersion1(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, r.Header.Get(RestLiHeader_ProtocolVersion))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	c := &RestLiClient{ProtocolVersion: RestLiProtocolVersion1}
+
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL+"/foo"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Get(RestLiHeader_ProtocolVersion); v != RestLiProtocolVersion1 {
+		t.Errorf("Expected version %q, got %q", RestLiProtocolVersion1, v)
+	}
+	// The response of a legacy server must be accepted as is
+	if _, err = c.DoAndIgnore(req); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = new(RestLiClient).GetRequest(context.Background(), mustParse(server.URL+"/foo"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion1)
+	if _, err = new(RestLiClient).DoAndIgnore(req); err == nil {
+		t.Error("A rest.li 1.0 response should be rejected by a rest.li 2.0 client")
+	}
+}
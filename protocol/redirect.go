@@ -0,0 +1,36 @@
+package protocol
+
+import (
+	"errors"
+	"net/http"
+)
+
+// maxRedirects is the number of redirects followed by CheckRedirect, which is the same as the default policy of
+// http.Client
+const maxRedirects = 10
+
+// redirectHeaders are the headers of the original request that CheckRedirect re-attaches to redirected requests
+var redirectHeaders = []string{RestLiHeader_Method, RestLiHeader_ProtocolVersion, "Accept"}
+
+// CheckRedirect is an http.Client.CheckRedirect policy for rest.li requests. Like the default policy, it follows up to 10
+// redirects, but it also re-attaches the rest.li headers of the original request to the redirected request, without
+// which the server cannot process it. This policy is used by the clients returned by NewHTTPClient, and can be set on
+// any http.Client given to a RestLiClient.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("go-restli: Stopped after 10 redirects")
+	}
+
+	original := via[0]
+	for _, h := range redirectHeaders {
+		if v := original.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	return nil
+}
+
+// noRedirects returns the redirect responses as is, see TransportOptions.DisableRedirects
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
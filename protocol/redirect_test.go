@@ -0,0 +1,57 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	var method string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Header.Get(RestLiHeader_Method)
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
+	u, _ := url.Parse(redirect.URL + "/foo")
+
+	c := &RestLiClient{Client: NewHTTPClient(DefaultTransportOptions)}
+	// Simulate the header being dropped from the redirected request
+	c.Client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		req.Header.Del(RestLiHeader_Method)
+		return CheckRedirect(req, via)
+	}
+	req, err := c.GetRequest(context.Background(), u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoAndIgnore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != Method_get.String() {
+		t.Errorf("The rest.li method header was not re-attached, got %q", method)
+	}
+
+	options := DefaultTransportOptions
+	options.DisableRedirects = true
+	c = &RestLiClient{Client: NewHTTPClient(options)}
+	req, err = c.GetRequest(context.Background(), u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Errorf("Expected the redirect to be returned, got %d", res.StatusCode)
+	}
+}
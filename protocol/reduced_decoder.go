@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// RestLiContentTypes maps the content types of response bodies that are encoded using the rest.li URL format, rather
+// than JSON, to the codec that decodes them. Responses with any other content type are decoded as JSON
+var RestLiContentTypes = map[string]RestLiCodec{
+	"application/x-www-form-urlencoded": RestLiUrlEncoder,
+	"text/plain":                        RestLiReducedEncoder,
+}
+
+// CodecForContentType returns the codec registered in RestLiContentTypes for the given Content-Type header, ignoring
+// any parameters such as the charset
+func CodecForContentType(contentType string) (codec RestLiCodec, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return codec, false
+	}
+	codec, ok = RestLiContentTypes[mediaType]
+	return codec, ok
+}
+
+// DecodeObject splits data in the rest.li object format, i.e. (k1:v1,k2:v2), into its keys and values. Neither are
+// decoded, since values can themselves be objects or lists, they must be decoded individually according to their type.
+// An empty string is treated as an empty object, since that is how records encode unions that have no member set.
+func (r *RestLiCodec) DecodeObject(data string) (map[string]string, error) {
+	if data == "" {
+		return map[string]string{}, nil
+	}
+	if len(data) < 2 || data[0] != '(' || data[len(data)-1] != ')' {
+		return nil, fmt.Errorf("go-restli: Invalid rest.li object: %q", data)
+	}
+
+	entries, err := splitRestLiEntries(data[1 : len(data)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]string, len(entries))
+	for _, e := range entries {
+		idx := strings.IndexByte(e, ':')
+		if idx <= 0 {
+			return nil, fmt.Errorf("go-restli: Invalid rest.li object entry %q in %q", e, data)
+		}
+		object[e[:idx]] = e[idx+1:]
+	}
+	return object, nil
+}
+
+// DecodeList splits data in the rest.li list format, i.e. List(v1,v2), into its elements. Like DecodeObject, the
+// elements are not decoded.
+func (r *RestLiCodec) DecodeList(data string) ([]string, error) {
+	if !strings.HasPrefix(data, "List(") || !strings.HasSuffix(data, ")") {
+		return nil, fmt.Errorf("go-restli: Invalid rest.li list: %q", data)
+	}
+	return splitRestLiEntries(data[len("List(") : len(data)-1])
+}
+
+// splitRestLiEntries splits the contents of an object or a list on the commas that are not part of a nested object or
+// list. Since the codecs always escape the syntax characters of strings, there is no need to look for quotes.
+func splitRestLiEntries(data string) (entries []string, err error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	depth, start := 0, 0
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("go-restli: Unbalanced parentheses in %q", data)
+			}
+		case ',':
+			if depth == 0 {
+				entries = append(entries, data[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("go-restli: Unbalanced parentheses in %q", data)
+	}
+
+	return append(entries, data[start:]), nil
+}
@@ -0,0 +1,54 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestLiCodec_DecodeObject(t *testing.T) {
+	object, err := RestLiReducedEncoder.DecodeObject("(a:1,b:List(1,(c:2)),d:(e:f,g:h),i%3Aj:)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"a":     "1",
+		"b":     "List(1,(c:2))",
+		"d":     "(e:f,g:h)",
+		"i%3Aj": "",
+	}
+	if !reflect.DeepEqual(expected, object) {
+		t.Errorf("Expected: %v, Got: %v", expected, object)
+	}
+
+	for _, data := range []string{"a:1", "(a:1", "(a:(b:1)", "(a)", "(a:1))"} {
+		if _, err = RestLiReducedEncoder.DecodeObject(data); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
+
+func TestRestLiCodec_DecodeList(t *testing.T) {
+	list, err := RestLiReducedEncoder.DecodeList("List(1,(a:2,b:3),List())")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"1", "(a:2,b:3)", "List()"}
+	if !reflect.DeepEqual(expected, list) {
+		t.Errorf("Expected: %v, Got: %v", expected, list)
+	}
+
+	list, err = RestLiReducedEncoder.DecodeList("List()")
+	if err != nil || len(list) != 0 {
+		t.Errorf("Expected an empty list, got %v (%v)", list, err)
+	}
+}
+
+func TestCodecForContentType(t *testing.T) {
+	if _, ok := CodecForContentType("application/json"); ok {
+		t.Errorf("JSON should not have a codec")
+	}
+	codec, ok := CodecForContentType("text/plain; charset=utf-8")
+	if !ok || !reflect.DeepEqual(codec.EncodeString("a,b"), RestLiReducedEncoder.EncodeString("a,b")) {
+		t.Errorf("text/plain should be decoded with RestLiReducedEncoder")
+	}
+}
@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// RestLiObject is implemented by all generated models
+type RestLiObject interface {
+	RestLiEncode(codec RestLiCodec) (data string, err error)
+}
+
+var (
+	objectRegistry     = make(map[string]func() RestLiObject)
+	objectNames        = make(map[reflect.Type]string)
+	objectRegistryLock sync.RWMutex
+)
+
+// RegisterObject registers the constructor for the model with the given fully-qualified rest.li name. It is called by
+// the init functions of the generated code. The same schema may be generated in more than one package, in which case
+// the last registration wins.
+func RegisterObject(fqn string, constructor func() RestLiObject) {
+	objectRegistryLock.Lock()
+	defer objectRegistryLock.Unlock()
+
+	objectRegistry[fqn] = constructor
+	objectNames[reflect.TypeOf(constructor())] = fqn
+}
+
+// NewObject returns a new, empty instance of the model with the given fully-qualified rest.li name
+func NewObject(fqn string) (RestLiObject, bool) {
+	objectRegistryLock.RLock()
+	constructor, ok := objectRegistry[fqn]
+	objectRegistryLock.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return constructor(), true
+}
+
+// ObjectName returns the fully-qualified rest.li name the given model's type was registered with
+func ObjectName(obj RestLiObject) (string, bool) {
+	objectRegistryLock.RLock()
+	defer objectRegistryLock.RUnlock()
+
+	fqn, ok := objectNames[reflect.TypeOf(obj)]
+	return fqn, ok
+}
+
+// UnmarshalObject unmarshals the given JSON into a new instance of the model with the given fully-qualified rest.li
+// name
+func UnmarshalObject(fqn string, data []byte) (RestLiObject, error) {
+	obj, ok := NewObject(fqn)
+	if !ok {
+		return nil, fmt.Errorf("go-restli: Unknown type %s", fqn)
+	}
+
+	err := json.Unmarshal(data, obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,30 @@
+package protocol
+
+import (
+	"testing"
+)
+
+type testObject struct {
+	Name string `json:"name"`
+}
+
+func (t *testObject) RestLiEncode(RestLiCodec) (string, error) {
+	return "(name:" + t.Name + ")", nil
+}
+
+func TestUnmarshalObject(t *testing.T) {
+	RegisterObject("protocol.testObject", func() RestLiObject { return new(testObject) })
+
+	obj, err := UnmarshalObject("protocol.testObject", []byte(`{"name":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.(*testObject).Name != "foo" {
+		t.Errorf("Expected: foo, Got: %s", obj.(*testObject).Name)
+	}
+
+	_, err = UnmarshalObject("protocol.unknown", []byte(`{}`))
+	if err == nil {
+		t.Errorf("Expected an error for an unknown type")
+	}
+}
@@ -0,0 +1,37 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header used to propagate request IDs when RestLiClient.RequestIDHeader is empty
+const DefaultRequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the given request ID to the context. All the requests created by a RestLiClient with this
+// context will carry the ID in the client's RequestIDHeader
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID attached to the context by WithRequestID, if any
+func RequestIDFromContext(ctx context.Context) (requestID string, ok bool) {
+	requestID, ok = ctx.Value(requestIDKey{}).(string)
+	return requestID, ok
+}
+
+func (c *RestLiClient) requestIDHeader() string {
+	if c.RequestIDHeader != "" {
+		return c.RequestIDHeader
+	}
+	return DefaultRequestIDHeader
+}
+
+// setRequestID sets the request ID header if the request's context carries one
+func (c *RestLiClient) setRequestID(req *http.Request) {
+	if requestID, ok := RequestIDFromContext(req.Context()); ok && requestID != "" {
+		req.Header.Set(c.requestIDHeader(), requestID)
+	}
+}
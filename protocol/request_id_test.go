@@ -0,0 +1,38 @@
+package protocol
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestRestLiClient_RequestID(t *testing.T) {
+	u, _ := url.Parse("http://localhost/foo")
+	ctx := WithRequestID(context.Background(), "abc")
+
+	c := new(RestLiClient)
+	req, err := c.GetRequest(ctx, u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := req.Header.Get(DefaultRequestIDHeader); id != "abc" {
+		t.Errorf("Expected: abc, Got: %q", id)
+	}
+
+	c.RequestIDHeader = "X-Trace"
+	req, err = c.JsonPostRequest(ctx, u, Method_create, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := req.Header.Get("X-Trace"); id != "abc" {
+		t.Errorf("Expected: abc, Got: %q", id)
+	}
+
+	req, err = c.GetRequest(context.Background(), u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := req.Header["X-Trace"]; ok {
+		t.Errorf("No request ID should be set without one on the context")
+	}
+}
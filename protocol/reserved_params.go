@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FinderParam is the query parameter that holds the name of the finder being called
+const FinderParam = "q"
+
+// ReservedParamValue is the value of a ReservedParams entry. All the generated types implement it, which lets records
+// be used as structured values, e.g. filter expressions. StringParam and ListParam cover the other common cases.
+type ReservedParamValue interface {
+	RestLiEncode(codec RestLiCodec) (data string, err error)
+}
+
+// ReservedParams are query parameters that finders do not declare in their restspec, but that the server understands
+// anyway, such as the $type, sort or filter parameters of some rest.li deployments. They can be set on the
+// ReservedParams field of every generated FindByXxxParams struct, and are keyed by the name of the parameter.
+type ReservedParams map[string]ReservedParamValue
+
+// StringParam is a ReservedParamValue that is encoded as a rest.li string
+type StringParam string
+
+func (s StringParam) RestLiEncode(codec RestLiCodec) (data string, err error) {
+	return codec.EncodeString(string(s)), nil
+}
+
+// ListParam is a ReservedParamValue that is encoded as a rest.li array, e.g. List(name,(field:age,order:DESC))
+type ListParam []ReservedParamValue
+
+func (l ListParam) RestLiEncode(codec RestLiCodec) (data string, err error) {
+	var buf strings.Builder
+	buf.WriteString("List(")
+	for i, v := range l {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		encoded, err := v.RestLiEncode(codec)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(encoded)
+	}
+	buf.WriteByte(')')
+	return buf.String(), nil
+}
+
+// EncodeFinderParams adds these params to the given query of a finder, whose values are escaped by query.Encode, so
+// the given codec should be RestLiReducedEncoder or one derived from it (see RestLiClient.ReducedCodec). It is called
+// by the generated EncodeFinderParams functions, and returns an error if one of the params is the q parameter or one of
+// the given params declared by the finder, which cannot be overridden.
+func (p ReservedParams) EncodeFinderParams(codec RestLiCodec, query url.Values, declaredParams ...string) error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == FinderParam {
+			return fmt.Errorf("go-restli: The %s query parameter is not a reserved param", name)
+		}
+		for _, declared := range declaredParams {
+			if name == declared {
+				return fmt.Errorf("go-restli: %s is a parameter of the finder, it is not a reserved param", name)
+			}
+		}
+		if p[name] == nil {
+			return fmt.Errorf("go-restli: Reserved param %s has no value", name)
+		}
+
+		encoded, err := p[name].RestLiEncode(codec)
+		if err != nil {
+			return err
+		}
+		query.Set(name, encoded)
+	}
+	return nil
+}
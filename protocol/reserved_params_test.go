@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestReservedParams_EncodeFinderParams(t *testing.T) {
+	query := url.Values{FinderParam: {"search"}}
+	err := ReservedParams{
+		"$type": StringParam("com.example.Fruit"),
+		"sort":  ListParam{StringParam("name"), StringParam("a,b")},
+	}.EncodeFinderParams(RestLiReducedEncoder, query, "keyword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "%24type=com.example.Fruit&q=search&sort=List%28name%2Ca%252Cb%29"; query.Encode() != expected {
+		t.Errorf("Expected %s, got %s", expected, query.Encode())
+	}
+
+	for _, name := range []string{FinderParam, "keyword"} {
+		err = ReservedParams{name: StringParam("foo")}.EncodeFinderParams(RestLiReducedEncoder, url.Values{}, "keyword")
+		if err == nil {
+			t.Errorf("%s should not be accepted as a reserved param", name)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ActionParam is the query parameter that holds the name of the action a POST request invokes
+const ActionParam = "action"
+
+// ServerRequestMethod returns the rest.li method a request was sent for, as read by the generated servers. The
+// RestLiHeader_Method header is used when it is set, which is always the case for requests sent by RestLiClient. It is
+// otherwise derived from the HTTP method, accounting for MethodOverrideHeader, and from the ActionParam, FinderParam
+// and BatchIdsParam.
+func ServerRequestMethod(req *http.Request) RestLiMethod {
+	if m, ok := RestLiMethodNameMapping[req.Header.Get(RestLiHeader_Method)]; ok {
+		return m
+	}
+
+	httpMethod := req.Method
+	if override := req.Header.Get(MethodOverrideHeader); override != "" {
+		httpMethod = override
+	}
+	switch httpMethod {
+	case http.MethodGet:
+		query := req.URL.Query()
+		if query.Get(FinderParam) != "" {
+			return Method_finder
+		}
+		if _, ok := query[BatchIdsParam]; ok {
+			return Method_batch_get
+		}
+		return Method_get
+	case http.MethodPost:
+		if req.URL.Query().Get(ActionParam) != "" {
+			return Method_action
+		}
+		return Method_create
+	case http.MethodPut:
+		return Method_update
+	case http.MethodDelete:
+		return Method_delete
+	default:
+		return Method_Unknown
+	}
+}
+
+// MatchPathTemplate matches the given escaped path (see url.URL.EscapedPath) against a path template such as
+// /a/{aId}/b/{bId}, and returns the value of each key as it appears in the path, i.e. still encoded. The path must be
+// made of exactly the given prefix, which is the path a server is mounted under (if any), followed by the template.
+func MatchPathTemplate(prefix, template, escapedPath string) (keys map[string]string, ok bool) {
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix != "" {
+		if !strings.HasPrefix(escapedPath, prefix+"/") {
+			return nil, false
+		}
+		escapedPath = escapedPath[len(prefix):]
+	}
+
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(escapedPath, "/"), "/")
+	if len(pathSegments) != len(templateSegments) {
+		return nil, false
+	}
+
+	keys = make(map[string]string)
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			keys[segment[1:len(segment)-1]] = pathSegments[i]
+		} else if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return keys, true
+}
+
+// ServerUrlCodec returns the codec the generated servers decode the keys of the given request with. It mirrors
+// RestLiClient.UrlCodec: codec is the server's equivalent of RestLiClient.Codec, and the codec follows rest.li 1.0 if
+// the request was sent with RestLiProtocolVersion1. A server therefore decodes the keys of any client that is configured
+// with the same codec.
+func ServerUrlCodec(codec RestLiCodec, req *http.Request) RestLiCodec {
+	if codec.encoder == nil {
+		codec = RestLiUrlEncoder.withOptionsOf(codec)
+	}
+	codec.protocolVersion1 = req.Header.Get(RestLiHeader_ProtocolVersion) == RestLiProtocolVersion1
+	return codec
+}
+
+// ServerReducedCodec is like ServerUrlCodec, but mirrors RestLiClient.ReducedCodec. The generated servers decode the
+// query parameters of finders with it.
+func ServerReducedCodec(codec RestLiCodec, req *http.Request) RestLiCodec {
+	return RestLiReducedEncoder.withOptionsOf(ServerUrlCodec(codec, req))
+}
+
+// SetServerProtocolVersion sets the RestLiHeader_ProtocolVersion header of the response to the protocol version the
+// given request was sent with, such that clients configured with RestLiProtocolVersion1 accept the response. It is
+// called by the generated servers before routing the request, WriteResponse and WriteErrorResponse otherwise default to
+// RestLiProtocolVersion.
+func SetServerProtocolVersion(w http.ResponseWriter, req *http.Request) {
+	version := RestLiProtocolVersion
+	if req.Header.Get(RestLiHeader_ProtocolVersion) == RestLiProtocolVersion1 {
+		version = RestLiProtocolVersion1
+	}
+	w.Header().Set(RestLiHeader_ProtocolVersion, version)
+}
+
+// DecodeBatchIds returns the keys of the given batch request, still encoded, i.e. it is the inverse of
+// RestLiClient.EncodeBatchIds. The codec is expected to come from ServerUrlCodec, such that the keys are read as
+// ids=List(k1,k2) with rest.li 2.0 and as ids=k1&ids=k2 with rest.li 1.0.
+func DecodeBatchIds(codec RestLiCodec, req *http.Request) ([]string, error) {
+	// The keys are read from the raw query, since unescaping it would also unescape the syntax characters of the keys
+	var ids []string
+	for _, param := range strings.Split(req.URL.RawQuery, "&") {
+		if strings.HasPrefix(param, BatchIdsParam+"=") {
+			ids = append(ids, param[len(BatchIdsParam+"="):])
+		}
+	}
+
+	if codec.protocolVersion1 {
+		return ids, nil
+	}
+	if len(ids) != 1 {
+		return nil, fmt.Errorf("go-restli: Expected a single %s parameter, got %d", BatchIdsParam, len(ids))
+	}
+	return codec.DecodeList(ids[0])
+}
+
+// DecodeRequest decodes the JSON body of the given request into v. Empty bodies leave v untouched.
+func DecodeRequest(req *http.Request, v interface{}) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+// WriteResponse writes a response with the given status and the JSON encoding of v as its body, unless v is nil. The
+// response is sent with RestLiProtocolVersion, unless SetServerProtocolVersion was called.
+func WriteResponse(w http.ResponseWriter, status int, v interface{}) {
+	setDefaultProtocolVersion(w)
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		WriteErrorResponse(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", JsonContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// WriteErrorResponse writes the given error as a rest.li error response. A *RestLiError is written as is, any other
+// error is written as a 500 Internal Server Error holding its message. Like WriteResponse, the response is sent with
+// RestLiProtocolVersion unless SetServerProtocolVersion was called.
+func WriteErrorResponse(w http.ResponseWriter, err error) {
+	var restLiError *RestLiError
+	if !errors.As(err, &restLiError) {
+		restLiError = &RestLiError{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	if restLiError.Status == 0 {
+		restLiError.Status = http.StatusInternalServerError
+	}
+
+	body, _ := json.Marshal(restLiError)
+	setDefaultProtocolVersion(w)
+	w.Header().Set(RestLiHeader_ErrorResponse, "true")
+	w.Header().Set("Content-Type", JsonContentType)
+	w.WriteHeader(restLiError.Status)
+	_, _ = w.Write(body)
+}
+
+func setDefaultProtocolVersion(w http.ResponseWriter) {
+	if w.Header().Get(RestLiHeader_ProtocolVersion) == "" {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+	}
+}
+
+// BadRequestError wraps an error caused by a malformed request, such as a key or body that cannot be decoded, into a
+// 400 Bad Request RestLiError
+func BadRequestError(err error) *RestLiError {
+	return &RestLiError{Status: http.StatusBadRequest, Message: err.Error()}
+}
+
+// NotImplementedError is returned by the generated servers for the methods that have no handler
+func NotImplementedError(resourceName, methodName string) *RestLiError {
+	return &RestLiError{
+		Status:  http.StatusNotImplemented,
+		Message: fmt.Sprintf("go-restli: %s is not implemented by %s", methodName, resourceName),
+	}
+}
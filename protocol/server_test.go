@@ -0,0 +1,291 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMatchPathTemplate(t *testing.T) {
+	tests := []struct {
+		Prefix   string
+		Template string
+		Path     string
+		Keys     map[string]string
+		Ok       bool
+	}{
+		{Template: "/a", Path: "/a", Keys: map[string]string{}, Ok: true},
+		{Template: "/a/{aId}", Path: "/a/1", Keys: map[string]string{"aId": "1"}, Ok: true},
+		{Prefix: "/prefix", Template: "/a/{aId}/b/{bId}", Path: "/prefix/a/1/b/%2F", Keys: map[string]string{"aId": "1", "bId": "%2F"}, Ok: true},
+		{Prefix: "/prefix/", Template: "/a/{aId}", Path: "/prefix/a/1", Keys: map[string]string{"aId": "1"}, Ok: true},
+		{Template: "/a/{aId}", Path: "/a", Ok: false},
+		{Template: "/a/{aId}", Path: "/a/", Ok: false},
+		{Template: "/a/{aId}", Path: "/b/1", Ok: false},
+		{Template: "/a/{aId}/b", Path: "/a/1/c", Ok: false},
+		// Paths are matched in full, so unknown prefixes are rejected
+		{Template: "/a/{aId}", Path: "/anything/else/a/1", Ok: false},
+		{Template: "/a/{aId}/b/{bId}", Path: "/prefix/a/1/b/%2F", Ok: false},
+		{Prefix: "/prefix", Template: "/a/{aId}", Path: "/a/1", Ok: false},
+		{Prefix: "/prefix", Template: "/a/{aId}", Path: "/prefixed/a/1", Ok: false},
+		{Prefix: "/prefix", Template: "/a/{aId}", Path: "/other/prefix/a/1", Ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Prefix+" "+test.Template+" "+test.Path, func(t *testing.T) {
+			keys, ok := MatchPathTemplate(test.Prefix, test.Template, test.Path)
+			if ok != test.Ok {
+				t.Fatalf("Expected %v, got %v", test.Ok, ok)
+			}
+			if len(keys) != len(test.Keys) {
+				t.Fatalf("Expected %v, got %v", test.Keys, keys)
+			}
+			for k, v := range test.Keys {
+				if keys[k] != v {
+					t.Errorf("Expected %s for %s, got %s", v, k, keys[k])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPathTemplate_EscapedKeys(t *testing.T) {
+	var keys map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ok bool
+		keys, ok = MatchPathTemplate("/context", "/a/{aId}/b/{bId}", req.URL.EscapedPath())
+		if !ok {
+			t.Errorf("%s did not match", req.URL.EscapedPath())
+		}
+	}))
+	defer server.Close()
+
+	hostname, _ := url.Parse(server.URL + "/context")
+	c := &RestLiClient{
+		Client:           server.Client(),
+		HostnameResolver: &SimpleHostnameSupplier{Hostname: hostname},
+	}
+
+	for _, key := range []string{"a/b", "/", "//", ".", "..", "../..", "a?b#c", "%2F", "a b+c"} {
+		t.Run(key, func(t *testing.T) {
+			aId := RestLiUrlEncoder.EncodeString(key)
+			bId := "(id:" + RestLiUrlEncoder.EncodeString(key) + ")"
+			u, err := c.FormatQueryUrl("a", "a/"+aId+"/b/"+bId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.Get(u.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+
+			var decoded string
+			if err = RestLiUrlEncoder.DecodeString(keys["aId"], &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded != key {
+				t.Errorf("Expected %q, got %q", key, decoded)
+			}
+
+			fields, err := RestLiUrlEncoder.DecodeObject(keys["bId"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = RestLiUrlEncoder.DecodeString(fields["id"], &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded != key {
+				t.Errorf("Expected %q, got %q", key, decoded)
+			}
+		})
+	}
+}
+
+func TestServerRequestMethod(t *testing.T) {
+	newRequest := func(method, url string, header ...string) *http.Request {
+		req := httptest.NewRequest(method, url, nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		return req
+	}
+
+	tests := []struct {
+		Request  *http.Request
+		Expected RestLiMethod
+	}{
+		{Request: newRequest(http.MethodGet, "/a/1"), Expected: Method_get},
+		{Request: newRequest(http.MethodPost, "/a"), Expected: Method_create},
+		{Request: newRequest(http.MethodPost, "/a?action=ping"), Expected: Method_action},
+		{Request: newRequest(http.MethodGet, "/a?q=search&start=0"), Expected: Method_finder},
+		{Request: newRequest(http.MethodGet, "/a?ids=List(1,2)"), Expected: Method_batch_get},
+		{Request: newRequest(http.MethodPut, "/a/1"), Expected: Method_update},
+		{Request: newRequest(http.MethodDelete, "/a/1"), Expected: Method_delete},
+		{Request: newRequest(http.MethodPost, "/a/1", MethodOverrideHeader, http.MethodGet), Expected: Method_get},
+		{Request: newRequest(http.MethodPost, "/a/1", RestLiHeader_Method, Method_partial_update.String()), Expected: Method_partial_update},
+		{Request: newRequest(http.MethodPatch, "/a/1"), Expected: Method_Unknown},
+	}
+
+	for _, test := range tests {
+		if m := ServerRequestMethod(test.Request); m != test.Expected {
+			t.Errorf("%s %s: expected %s, got %s", test.Request.Method, test.Request.URL, test.Expected, m)
+		}
+	}
+}
+
+func TestDecodeBatchIds(t *testing.T) {
+	keys := []string{"1", "(a:1,b:List(2,3))", "%2C"}
+	for _, version := range []string{RestLiProtocolVersion, RestLiProtocolVersion1} {
+		t.Run(version, func(t *testing.T) {
+			if version == RestLiProtocolVersion1 {
+				keys = keys[:1]
+			}
+			c := &RestLiClient{ProtocolVersion: version}
+			req := httptest.NewRequest(http.MethodGet, "/a?"+c.EncodeBatchIds(keys)+"&fields=a", nil)
+			req.Header.Set(RestLiHeader_ProtocolVersion, version)
+
+			ids, err := DecodeBatchIds(ServerUrlCodec(RestLiCodec{}, req), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(ids, " ") != strings.Join(keys, " ") {
+				t.Errorf("Expected %q, got %q", keys, ids)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/a?ids=1&ids=2", nil)
+	if _, err := DecodeBatchIds(ServerUrlCodec(RestLiCodec{}, req), req); err == nil {
+		t.Errorf("Expected rest.li 1.0 ids to be rejected from a rest.li 2.0 request")
+	}
+}
+
+func TestServerUrlCodec(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	codec := ServerUrlCodec(RestLiCodec{}, req)
+	if err := codec.CheckComplexValue(); err != nil {
+		t.Errorf("Unexpected error for a rest.li 2.0 request: %+v", err)
+	}
+	var b bool
+	lenient := ServerUrlCodec(RestLiUrlEncoder.WithLenientBool(), req)
+	if err := lenient.DecodeBool("1", &b); err != nil || !b {
+		t.Errorf("The options of the codec were not kept: %v %+v", b, err)
+	}
+
+	req.Header.Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion1)
+	codec = ServerReducedCodec(RestLiCodec{}, req)
+	if err := codec.CheckComplexValue(); err != ErrProtocolVersion1ComplexValue {
+		t.Errorf("Expected %+v for a rest.li 1.0 request, got %+v", ErrProtocolVersion1ComplexValue, err)
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	var v map[string]int
+	err := DecodeRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(" {\"a\":1}\n")), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != 1 {
+		t.Errorf("Expected 1, got %v", v)
+	}
+
+	v = nil
+	err = DecodeRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("\n")), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Errorf("Empty bodies should not be decoded, got %v", v)
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteResponse(w, http.StatusOK, map[string]int{"a": 1})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != JsonContentType {
+		t.Errorf("Expected %s, got %s", JsonContentType, contentType)
+	}
+	if version := w.Header().Get(RestLiHeader_ProtocolVersion); version != RestLiProtocolVersion {
+		t.Errorf("Expected %s, got %s", RestLiProtocolVersion, version)
+	}
+	if body := w.Body.String(); body != `{"a":1}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	WriteResponse(w, http.StatusNoContent, nil)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty %d, got %d: %s", http.StatusNoContent, w.Code, w.Body)
+	}
+}
+
+func TestSetServerProtocolVersion(t *testing.T) {
+	for _, version := range []string{"", RestLiProtocolVersion, RestLiProtocolVersion1} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RestLiHeader_ProtocolVersion, version)
+		expected := version
+		if expected == "" {
+			expected = RestLiProtocolVersion
+		}
+
+		w := httptest.NewRecorder()
+		SetServerProtocolVersion(w, req)
+		WriteResponse(w, http.StatusOK, nil)
+		if actual := w.Header().Get(RestLiHeader_ProtocolVersion); actual != expected {
+			t.Errorf("Expected %q, got %q", expected, actual)
+		}
+
+		w = httptest.NewRecorder()
+		SetServerProtocolVersion(w, req)
+		WriteErrorResponse(w, NotImplementedError("a", "Get"))
+		if actual := w.Header().Get(RestLiHeader_ProtocolVersion); actual != expected {
+			t.Errorf("Expected %q, got %q", expected, actual)
+		}
+	}
+}
+
+func TestWriteErrorResponse(t *testing.T) {
+	tests := []struct {
+		Err      error
+		Expected RestLiError
+	}{
+		{
+			Err:      NotImplementedError("a", "Get"),
+			Expected: RestLiError{Status: http.StatusNotImplemented, Message: "go-restli: Get is not implemented by a"},
+		},
+		{
+			Err:      BadRequestError(errors.New("bad key")),
+			Expected: RestLiError{Status: http.StatusBadRequest, Message: "bad key"},
+		},
+		{
+			Err:      errors.New("boom"),
+			Expected: RestLiError{Status: http.StatusInternalServerError, Message: "boom"},
+		},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		WriteErrorResponse(w, test.Err)
+		if w.Code != test.Expected.Status {
+			t.Errorf("Expected %d, got %d", test.Expected.Status, w.Code)
+		}
+		if w.Header().Get(RestLiHeader_ErrorResponse) != "true" {
+			t.Errorf("%s was not set", RestLiHeader_ErrorResponse)
+		}
+
+		var actual RestLiError
+		if err := json.Unmarshal(w.Body.Bytes(), &actual); err != nil {
+			t.Fatal(err)
+		}
+		if actual.Status != test.Expected.Status || actual.Message != test.Expected.Message {
+			t.Errorf("Expected %+v, got %+v", test.Expected, actual)
+		}
+	}
+}
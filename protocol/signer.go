@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"io/ioutil"
+	"net/http"
+)
+
+// RequestSigner is called by RestLiClient.Do on every request, right before it is sent, and can be used to add
+// authentication headers (e.g. an HMAC signature) to the request. At that point all the headers set by the client,
+// including the rest.li protocol headers, the request ID and RestLiClient.Headers, are already on the request, so the
+// signature can cover them
+type RequestSigner interface {
+	// SignRequest is given the request, whose method, URL and headers are final, and the bytes of its body, which are
+	// empty for GET and DELETE requests. The body can be read without consuming the request's own body. Returning an
+	// error aborts the request.
+	SignRequest(req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc is an adapter to use ordinary functions as a RequestSigner
+type RequestSignerFunc func(req *http.Request, body []byte) error
+
+func (f RequestSignerFunc) SignRequest(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+func (c *RestLiClient) signRequest(req *http.Request) error {
+	if c.RequestSigner == nil {
+		return nil
+	}
+
+	var body []byte
+	// GetBody is set on all the requests created by RestLiClient, short of the ones that stream their body (see
+	// UnstructuredDataPostRequest), and returns a fresh reader over the same bytes
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		body, err = ioutil.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.RequestSigner.SignRequest(req, body)
+}
@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestLiClient_RequestSigner(t *testing.T) {
+	sign := func(method, uri, restLiMethod string, body []byte) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(method + "\n" + uri + "\n" + restLiMethod + "\n"))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Signature") != sign(r.Method, r.URL.RequestURI(), r.Header.Get(RestLiHeader_Method), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	c := &RestLiClient{
+		RequestSigner: RequestSignerFunc(func(req *http.Request, body []byte) error {
+			req.Header.Set("X-Signature", sign(req.Method, req.URL.RequestURI(), req.Header.Get(RestLiHeader_Method), body))
+			return nil
+		}),
+	}
+
+	req, err := c.JsonPostRequest(context.Background(), mustParse(server.URL+"/foo?bar=baz"), Method_create, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.DoAndIgnore(req); err != nil {
+		t.Fatalf("Signed request should be accepted: %+v", err)
+	}
+
+	req, err = c.GetRequest(context.Background(), mustParse(server.URL+"/foo/1"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.DoAndIgnore(req); err != nil {
+		t.Fatalf("Signed request should be accepted: %+v", err)
+	}
+
+	signingError := errors.New("no credentials")
+	c.RequestSigner = RequestSignerFunc(func(*http.Request, []byte) error { return signingError })
+	if _, err = c.DoAndIgnore(req); err != signingError {
+		t.Errorf("Expected the signing error, got %+v", err)
+	}
+}
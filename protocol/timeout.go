@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RecommendedTimeout      = 10 * time.Second
+	RecommendedBatchTimeout = 30 * time.Second
+)
+
+// RecommendedMethodTimeouts can be given to a RestLiClient's MethodTimeouts to bound the requests whose caller did not
+// set a deadline. Batch methods, get_all, finders and actions can return (or do) a lot more work than the simple methods
+// so they are given more time. No timeouts are applied unless configured.
+var RecommendedMethodTimeouts = map[RestLiMethod]time.Duration{
+	Method_get:            RecommendedTimeout,
+	Method_create:         RecommendedTimeout,
+	Method_delete:         RecommendedTimeout,
+	Method_update:         RecommendedTimeout,
+	Method_partial_update: RecommendedTimeout,
+
+	Method_batch_get:            RecommendedBatchTimeout,
+	Method_batch_create:         RecommendedBatchTimeout,
+	Method_batch_delete:         RecommendedBatchTimeout,
+	Method_batch_update:         RecommendedBatchTimeout,
+	Method_batch_partial_update: RecommendedBatchTimeout,
+
+	Method_get_all: RecommendedBatchTimeout,
+
+	Method_action: RecommendedBatchTimeout,
+	Method_finder: RecommendedBatchTimeout,
+}
+
+// MethodTimeout returns the timeout to apply to the given method, if any
+func (c *RestLiClient) MethodTimeout(method RestLiMethod) (timeout time.Duration, ok bool) {
+	timeout, ok = c.MethodTimeouts[method]
+	return timeout, ok && timeout > 0
+}
+
+// WithMethodTimeout applies the method's timeout to the given context. If the context already has a deadline, it is
+// returned as-is since deadlines set by the caller always take precedence. The returned CancelFunc must always be
+// called once the request completes.
+func (c *RestLiClient) WithMethodTimeout(ctx context.Context, method RestLiMethod) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return ctx, func() {}
+	}
+
+	timeout, ok := c.MethodTimeout(method)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRestLiClient_WithMethodTimeout(t *testing.T) {
+	c := &RestLiClient{
+		MethodTimeouts: map[RestLiMethod]time.Duration{
+			Method_get:    time.Minute,
+			Method_finder: 0,
+		},
+	}
+
+	ctx, cancel := c.WithMethodTimeout(context.Background(), Method_get)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) <= RecommendedBatchTimeout {
+		t.Errorf("Expected the configured timeout to be applied, got: %v", deadline)
+	}
+
+	for _, method := range []RestLiMethod{Method_create, Method_finder} {
+		ctx, cancel = c.WithMethodTimeout(context.Background(), method)
+		defer cancel()
+		if deadline, ok := ctx.Deadline(); ok {
+			t.Errorf("Expected no timeout to be applied to %s, got: %v", method, deadline)
+		}
+	}
+
+	c = &RestLiClient{MethodTimeouts: RecommendedMethodTimeouts}
+	ctx, cancel = c.WithMethodTimeout(context.Background(), Method_create)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > RecommendedTimeout {
+		t.Errorf("Expected the recommended timeout to be applied, got: %v", deadline)
+	}
+
+	expected := time.Now().Add(time.Hour)
+	callerCtx, callerCancel := context.WithDeadline(context.Background(), expected)
+	defer callerCancel()
+	ctx, cancel = c.WithMethodTimeout(callerCtx, Method_get)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(expected) {
+		t.Errorf("Expected the caller's deadline to win, Expected: %v, Got: %v", expected, deadline)
+	}
+}
@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestInfo describes the generated method that issued a request
+type RequestInfo struct {
+	// ResourceName is the fully-qualified name of the resource, e.g. com.linkedin.foo.bar
+	ResourceName string
+	// MethodName is the name of the generated method, e.g. Get or FindBySearch
+	MethodName string
+	// Method is the rest.li method being called
+	Method RestLiMethod
+	// PathTemplate is the path of the method with a {placeholder} for each key, e.g. /groups/{groupId}/members/{memberId}.
+	// Unlike the request's URL, it does not vary with the keys, which makes it suitable to label metrics
+	PathTemplate string
+	// FeatureFlag is the name of the feature flag the method is behind, if any. See RestLiClient.FeatureFlags
+	FeatureFlag string
+}
+
+// String returns the default span name for the request, e.g. com.linkedin.foo.bar.Get
+func (i RequestInfo) String() string {
+	return i.ResourceName + "." + i.MethodName
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo attaches the given RequestInfo to the context. It is called by all the generated methods before the
+// request is created, meaning the info is available to the Tracer as well as to any http.RoundTripper that has access
+// to the request's context
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the RequestInfo attached to the context by WithRequestInfo, if any
+func RequestInfoFromContext(ctx context.Context) (info RequestInfo, ok bool) {
+	info, ok = ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
+
+// Tracer is called around every request sent by a RestLiClient, and can be used to create a span per call
+type Tracer interface {
+	// StartRequest is called right before the request is sent. The returned context replaces the request's context,
+	// which lets the span be propagated by the underlying transport (e.g. otelhttp). The returned function is always
+	// called once the request completes, with the response if there is one (its body may not have been read yet) and the
+	// error if the request failed, including rest.li error responses.
+	StartRequest(ctx context.Context, info RequestInfo, req *http.Request) (context.Context, func(res *http.Response, err error))
+}
+
+// startTrace calls the Tracer, if any, and returns the request with the context it returned along with the function
+// that must be called once the request completes
+func (c *RestLiClient) startTrace(req *http.Request) (*http.Request, func(res *http.Response, err error)) {
+	if c.Tracer == nil {
+		return req, func(*http.Response, error) {}
+	}
+	info, _ := RequestInfoFromContext(req.Context())
+	ctx, finish := c.Tracer.StartRequest(req.Context(), info, req)
+	return req.WithContext(ctx), finish
+}
+
+// TracerFunc is an adapter to use ordinary functions as a Tracer
+type TracerFunc func(ctx context.Context, info RequestInfo, req *http.Request) (context.Context, func(res *http.Response, err error))
+
+func (f TracerFunc) StartRequest(ctx context.Context, info RequestInfo, req *http.Request) (context.Context, func(res *http.Response, err error)) {
+	return f(ctx, info, req)
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRestLiClient_Tracer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var startedInfo RequestInfo
+	var finishedStatus int
+	c := &RestLiClient{
+		Tracer: TracerFunc(func(ctx context.Context, info RequestInfo, req *http.Request) (context.Context, func(*http.Response, error)) {
+			startedInfo = info
+			return ctx, func(res *http.Response, err error) {
+				if err != nil {
+					t.Error(err)
+				}
+				finishedStatus = res.StatusCode
+			}
+		}),
+	}
+
+	expectedInfo := RequestInfo{ResourceName: "testsuite.simple", MethodName: "Get", Method: Method_get}
+	u, _ := url.Parse(server.URL)
+	req, err := c.GetRequest(WithRequestInfo(context.Background(), expectedInfo), u, Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.DoAndIgnore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if startedInfo != expectedInfo {
+		t.Errorf("Expected: %+v, Got: %+v", expectedInfo, startedInfo)
+	}
+	if finishedStatus != http.StatusOK {
+		t.Errorf("Expected: %d, Got: %d", http.StatusOK, finishedStatus)
+	}
+	if expected := "testsuite.simple.Get"; startedInfo.String() != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, startedInfo.String())
+	}
+}
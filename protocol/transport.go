@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions configures the http.Transport returned by NewTransport
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle (keep-alive) connections across all hosts. Zero means no limit
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle (keep-alive) connections to keep per host. The default of 2 used
+	// by net/http is usually far too low for services that make a lot of concurrent calls to the same host
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the total number of connections per host, including those in use. Zero means no limit
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept before being closed. Zero means no limit
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval between TCP keep-alive probes. A negative value disables TCP keep-alives
+	KeepAlive time.Duration
+	// DisableKeepAlives disables HTTP keep-alives, meaning a new connection will be opened for every request. This should
+	// only be used for debugging
+	DisableKeepAlives bool
+	// DisableHTTP2 prevents the transport from negotiating HTTP/2 with servers that support it
+	DisableHTTP2 bool
+	// DisableRedirects prevents the clients returned by NewHTTPClient from following redirects, the 3xx responses are
+	// returned instead. Otherwise, redirects are followed according to CheckRedirect
+	DisableRedirects bool
+}
+
+// DefaultTransportOptions are the options used by the transport of clients that do not provide their own http.Client
+var DefaultTransportOptions = TransportOptions{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+	IdleConnTimeout:     90 * time.Second,
+	KeepAlive:           30 * time.Second,
+}
+
+// NewTransport returns a new http.Transport configured with the given options. Transports hold the pool of idle
+// connections, therefore a single transport should be shared by all the clients that talk to the same hosts rather than
+// creating one per request
+func NewTransport(options TransportOptions) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: options.KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     !options.DisableHTTP2,
+		MaxIdleConns:          options.MaxIdleConns,
+		MaxIdleConnsPerHost:   options.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       options.MaxConnsPerHost,
+		IdleConnTimeout:       options.IdleConnTimeout,
+		DisableKeepAlives:     options.DisableKeepAlives,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if options.DisableHTTP2 {
+		// A non-nil, empty map is the documented way of disabling HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
+
+// NewHTTPClient returns a new http.Client that uses a transport configured with the given options, and follows
+// redirects according to CheckRedirect unless they are disabled
+func NewHTTPClient(options TransportOptions) *http.Client {
+	client := &http.Client{
+		Transport:     NewTransport(options),
+		CheckRedirect: CheckRedirect,
+	}
+	if options.DisableRedirects {
+		client.CheckRedirect = noRedirects
+	}
+	return client
+}
+
+// defaultHTTPClient is shared by all the RestLiClients that have no http.Client of their own, so that they all reuse
+// the same connections
+var defaultHTTPClient = NewHTTPClient(DefaultTransportOptions)
+
+func (c *RestLiClient) httpClient() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return defaultHTTPClient
+}
+
+// Close closes the idle connections of the client's http.Client, so that services that create and discard clients
+// (e.g. one per tenant) can release them on shutdown. Connections that are in use are not interrupted. Note that all
+// the RestLiClients that have no http.Client of their own share the same connections, which are all closed by calling
+// Close on any of them. It always returns nil, and only returns an error to implement io.Closer.
+func (c *RestLiClient) Close() error {
+	c.httpClient().CloseIdleConnections()
+	return nil
+}
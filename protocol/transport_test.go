@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	transport := NewTransport(DefaultTransportOptions)
+	if transport.MaxIdleConnsPerHost != DefaultTransportOptions.MaxIdleConnsPerHost {
+		t.Errorf("Expected: %d, Got: %d", DefaultTransportOptions.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+	if !transport.ForceAttemptHTTP2 || transport.TLSNextProto != nil {
+		t.Errorf("HTTP/2 should be enabled by default")
+	}
+
+	options := DefaultTransportOptions
+	options.DisableHTTP2 = true
+	transport = NewTransport(options)
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("HTTP/2 should be disabled")
+	}
+}
+
+func TestRestLiClient_DefaultHTTPClient(t *testing.T) {
+	c1, c2 := new(RestLiClient), new(RestLiClient)
+	if c1.httpClient() != c2.httpClient() {
+		t.Errorf("Clients without an http.Client should share the same default client")
+	}
+}
+
+func TestRestLiClient_Close(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	c := &RestLiClient{Client: NewHTTPClient(DefaultTransportOptions)}
+	req, err := c.GetRequest(context.Background(), mustParse(server.URL), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.DoAndIgnore(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("Idle connection was not closed")
+	}
+}
@@ -0,0 +1,101 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UnionMembersError is returned by ValidateUnionMembers when more than one member of a union is set, or when none of the
+// members of a required union are set
+type UnionMembersError struct {
+	// Union describes the union, e.g. the record and field that hold it
+	Union string
+	// SetMembers are the aliases of the members that are set
+	SetMembers []string
+}
+
+func (e *UnionMembersError) Error() string {
+	if len(e.SetMembers) == 0 {
+		return fmt.Sprintf("go-restli: Exactly one member of %s must be set, but none are", e.Union)
+	}
+	return fmt.Sprintf("go-restli: Only one member of %s can be set, but %d are: %s", e.Union, len(e.SetMembers),
+		strings.Join(e.SetMembers, ", "))
+}
+
+// ValidateUnionMembers is called by the generated code with the aliases of the members of the given union that are set.
+// It returns a UnionMembersError naming them if there is more than one, or if there are none and required is true,
+// i.e. the union is required and has no null member.
+func ValidateUnionMembers(union string, setMembers []string, required bool) error {
+	if len(setMembers) > 1 || (required && len(setMembers) == 0) {
+		return &UnionMembersError{Union: union, SetMembers: setMembers}
+	}
+	return nil
+}
+
+// UnknownUnionMember holds a member of a union that is not part of the schema the code was generated from, e.g. because
+// it was added to the schema after the fact. The member is kept as-is so that it can be re-emitted when the union is
+// marshaled back to JSON, which allows clients to pass values they do not understand through to the server.
+type UnknownUnionMember struct {
+	Alias string
+	Value json.RawMessage
+}
+
+// MarshalJSON writes the union as a JSON object whose only key is the member's alias
+func (u *UnknownUnionMember) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]json.RawMessage{u.Alias: u.Value})
+}
+
+// FindUnknownUnionMember returns the member of the given JSON union that is not one of the known aliases, or nil if the
+// union is null or its member is known
+func FindUnknownUnionMember(data []byte, knownAliases ...string) (*UnknownUnionMember, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	for _, alias := range knownAliases {
+		delete(members, alias)
+	}
+
+	switch len(members) {
+	case 0:
+		return nil, nil
+	case 1:
+		for alias, value := range members {
+			return &UnknownUnionMember{Alias: alias, Value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("go-restli: Union has %d unknown members, expected at most one", len(members))
+}
+
+// FindUnknownUnionMemberInField is like FindUnknownUnionMember, but for the union found in the given field of the JSON
+// object data. It returns nil if the field is absent.
+func FindUnknownUnionMemberInField(data []byte, field string, knownAliases ...string) (*UnknownUnionMember, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	union, ok := fields[field]
+	if !ok {
+		return nil, nil
+	}
+	return FindUnknownUnionMember(union, knownAliases...)
+}
+
+// SetUnknownUnionMember replaces the given field of the JSON object data with the unknown member. Note that the keys
+// of the returned object are sorted.
+func SetUnknownUnionMember(data []byte, field string, member *UnknownUnionMember) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	union, err := member.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	fields[field] = union
+	return json.Marshal(fields)
+}
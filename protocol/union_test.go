@@ -0,0 +1,95 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFindUnknownUnionMember(t *testing.T) {
+	member, err := FindUnknownUnionMember([]byte(`{"known":1}`), "known")
+	if err != nil || member != nil {
+		t.Errorf("Expected no unknown member, got %+v (%+v)", member, err)
+	}
+
+	member, err = FindUnknownUnionMember([]byte(`{"com.example.New":{"a":1}}`), "known")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.Alias != "com.example.New" || string(member.Value) != `{"a":1}` {
+		t.Errorf("Unexpected unknown member: %+v", member)
+	}
+
+	_, err = FindUnknownUnionMember([]byte(`{"a":1,"b":2}`), "known")
+	if err == nil {
+		t.Error("Expected an error for multiple unknown members")
+	}
+
+	member, err = FindUnknownUnionMemberInField([]byte(`{"other":1}`), "union", "known")
+	if err != nil || member != nil {
+		t.Errorf("Expected no unknown member for an absent field, got %+v (%+v)", member, err)
+	}
+}
+
+func TestSetUnknownUnionMember(t *testing.T) {
+	member := &UnknownUnionMember{Alias: "new", Value: json.RawMessage(`"foo"`)}
+	data, err := SetUnknownUnionMember([]byte(`{"union":{},"other":1}`), "union", member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"other":1,"union":{"new":"foo"}}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	found, err := FindUnknownUnionMemberInField(data, "union", "known")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Alias != member.Alias || string(found.Value) != string(member.Value) {
+		t.Errorf("Did not round-trip %+v, got %+v", member, found)
+	}
+}
+
+func TestValidateUnionMembers(t *testing.T) {
+	tests := []struct {
+		Name       string
+		SetMembers []string
+		Required   bool
+		Expected   string
+	}{
+		{Name: "none", Expected: ""},
+		{Name: "none required", Required: true, Expected: "go-restli: Exactly one member of Foo.bar must be set, but none are"},
+		{Name: "one", SetMembers: []string{"int"}, Expected: ""},
+		{Name: "one required", SetMembers: []string{"int"}, Required: true, Expected: ""},
+		{
+			Name:       "two",
+			SetMembers: []string{"int", "com.example.Baz"},
+			Expected:   "go-restli: Only one member of Foo.bar can be set, but 2 are: int, com.example.Baz",
+		},
+		{
+			Name:       "three required",
+			SetMembers: []string{"int", "string", "com.example.Baz"},
+			Required:   true,
+			Expected:   "go-restli: Only one member of Foo.bar can be set, but 3 are: int, string, com.example.Baz",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := ValidateUnionMembers("Foo.bar", test.SetMembers, test.Required)
+			if test.Expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %+v", err)
+				}
+				return
+			}
+
+			var membersError *UnionMembersError
+			if !errors.As(err, &membersError) {
+				t.Fatalf("Expected a UnionMembersError, got %+v", err)
+			}
+			if err.Error() != test.Expected {
+				t.Errorf("Expected %q, got %q", test.Expected, err.Error())
+			}
+		})
+	}
+}
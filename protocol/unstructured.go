@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UnstructuredDataGetRequest creates a GET request for a resource that serves unstructured data, i.e. raw bytes such as
+// images or documents rather than records, which accepts any Content-Type in response
+func (c *RestLiClient) UnstructuredDataGetRequest(ctx context.Context, url *url.URL, method RestLiMethod) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), emptyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setRestLiHeaders(req, method)
+	req.Header.Set("Accept", "*/*")
+	c.setRequestID(req)
+	c.addHeaders(req)
+
+	return req, nil
+}
+
+// UnstructuredDataPostRequest creates a POST request that streams the given body to a resource that serves unstructured
+// data. Since the body is not buffered, it is given as nil to RestLiClient.RequestSigner, unless it is a *bytes.Buffer,
+// *bytes.Reader or *strings.Reader (see http.NewRequest)
+func (c *RestLiClient) UnstructuredDataPostRequest(ctx context.Context, url *url.URL, method RestLiMethod, contentType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setRestLiHeaders(req, method)
+	req.Header.Set("Content-Type", contentType)
+	c.setRequestID(req)
+	c.addHeaders(req)
+
+	return req, nil
+}
+
+// DoAndStream calls Do and returns the response's body as is, without reading it, along with its Content-Type. It is
+// meant for resources that serve unstructured data, which can be arbitrarily large. The caller must close the body once
+// done with it, so that the connection can be reused. RestLiClient.MaxResponseBodySize does not apply to the body.
+func (c *RestLiClient) DoAndStream(req *http.Request) (body io.ReadCloser, contentType string, err error) {
+	start := time.Now()
+	res, err := c.Do(req)
+	c.observeRequest(req, res, err, time.Since(start))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.StatusCode/100 != 2 {
+		_ = res.Body.Close()
+		return nil, "", fmt.Errorf("go-restli: Invalid response code from %s: %d", req.URL, res.StatusCode)
+	}
+
+	return res.Body, res.Header.Get("Content-Type"), nil
+}
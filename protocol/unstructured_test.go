@@ -0,0 +1,76 @@
+package protocol
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRestLiClient_UnstructuredData(t *testing.T) {
+	blobs := make(map[string][]byte)
+	contentTypes := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RestLiHeader_ProtocolVersion, RestLiProtocolVersion)
+		switch r.Method {
+		case http.MethodPost:
+			blobs["1"], _ = ioutil.ReadAll(r.Body)
+			contentTypes["1"] = r.Header.Get("Content-Type")
+			w.Header().Set(RestLiHeader_ID, "1")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			id := strings.TrimPrefix(r.URL.Path, "/blobs/")
+			blob, ok := blobs[id]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", contentTypes[id])
+			_, _ = w.Write(blob)
+		}
+	}))
+	defer server.Close()
+
+	c := &RestLiClient{}
+	data := "\x89PNG\r\n\x1a\n\x00"
+
+	req, err := c.UnstructuredDataPostRequest(context.Background(), mustParse(server.URL+"/blobs"), Method_create,
+		"image/png", io.MultiReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.DoAndIgnore(req); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = c.UnstructuredDataGetRequest(context.Background(), mustParse(server.URL+"/blobs/1"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := c.DoAndStream(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ioutil.ReadAll(body)
+	_ = body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(read) != data {
+		t.Errorf("Expected %q, got %q", data, read)
+	}
+	if contentType != "image/png" {
+		t.Errorf("Expected image/png, got %q", contentType)
+	}
+
+	req, err = c.UnstructuredDataGetRequest(context.Background(), mustParse(server.URL+"/blobs/2"), Method_get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = c.DoAndStream(req); err == nil {
+		t.Error("Expected an error for a missing blob")
+	}
+}
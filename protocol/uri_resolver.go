@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URIResolver translates base URIs that use a custom scheme, such as the d2://serviceName URIs of D2 service discovery,
+// into the concrete URL of a host. It is called by FormatQueryUrl before every request, on the URL returned by the
+// client's HostnameResolver, which lets implementations load balance across hosts.
+type URIResolver interface {
+	ResolveURI(uri *url.URL) (*url.URL, error)
+}
+
+// URIResolverFunc is an adapter to use ordinary functions as a URIResolver
+type URIResolverFunc func(uri *url.URL) (*url.URL, error)
+
+func (f URIResolverFunc) ResolveURI(uri *url.URL) (*url.URL, error) {
+	return f(uri)
+}
+
+// resolveURI resolves the given URI with the URIResolver registered for its scheme, if any. The path of the URI, if
+// any, is appended to the path of the resolved URL so that d2://serviceName/context resolves to http://host/context
+func (c *RestLiClient) resolveURI(uri *url.URL) (*url.URL, error) {
+	resolver, ok := c.URIResolvers[uri.Scheme]
+	if !ok {
+		return uri, nil
+	}
+
+	resolved, err := resolver.ResolveURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("go-restli: Could not resolve %s: %w", uri, err)
+	}
+
+	if p := strings.Trim(uri.Path, "/"); p != "" {
+		withPath := *resolved
+		withPath.Path = strings.TrimSuffix(withPath.Path, "/") + "/" + p
+		withPath.RawPath = ""
+		resolved = &withPath
+	}
+	return resolved, nil
+}
@@ -0,0 +1,44 @@
+package protocol
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestRestLiClient_URIResolvers(t *testing.T) {
+	supplier := &SimpleHostnameSupplier{Hostname: mustParse("d2://searchService")}
+	c := &RestLiClient{
+		HostnameResolver: supplier,
+		URIResolvers: map[string]URIResolver{
+			"d2": URIResolverFunc(func(uri *url.URL) (*url.URL, error) {
+				if uri.Host != "searchService" {
+					return nil, errors.New("unknown service")
+				}
+				return mustParse("http://10.0.0.1:8080"), nil
+			}),
+		},
+	}
+
+	expected := "http://10.0.0.1:8080/search?action=search"
+	if actual := c.formatQuery(t, query); actual != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, actual)
+	}
+
+	supplier.Hostname = mustParse("d2://searchService/seas-broker")
+	expected = "http://10.0.0.1:8080/seas-broker/search?action=search"
+	if actual := c.formatQuery(t, query); actual != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, actual)
+	}
+
+	supplier.Hostname = mustParse("http://localhost")
+	expected = "http://localhost/search?action=search"
+	if actual := c.formatQuery(t, query); actual != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, actual)
+	}
+
+	supplier.Hostname = mustParse("d2://unknownService")
+	if _, err := c.FormatQueryUrl("search", query); err == nil {
+		t.Errorf("Expected an error for an unknown service")
+	}
+}
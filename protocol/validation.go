@@ -0,0 +1,79 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The validators reported by the generated Validate functions, on top of the ones declared in the schema
+const (
+	// RequiredValidator rejects required fields that are not set
+	RequiredValidator = "required"
+	// EnumValidator rejects enums that do not hold one of their symbols
+	EnumValidator = "enum"
+	// UnionValidator rejects unions that have more than one member set, or none if they are required
+	UnionValidator = "union"
+)
+
+// ValidationError is returned by the generated Validate functions when the value of a field does not satisfy one of
+// the validators declared on it in the schema
+type ValidationError struct {
+	// Field is the path of the field from the record being validated, e.g. foo.bar[0].baz, see FieldPath and IndexPath
+	Field string
+	// Validator is the name of the validator that rejected the value, e.g. "strlen"
+	Validator string
+	Message   string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("go-restli: Invalid value for field %q (%s): %s", e.Field, e.Validator, e.Message)
+}
+
+// ValidationErrors lists every violation found by the generated Validate functions
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Add appends a ValidationError for the given field
+func (e *ValidationErrors) Add(field, validator, message string) {
+	*e = append(*e, &ValidationError{Field: field, Validator: validator, Message: message})
+}
+
+// AddUnionMembers appends a ValidationError for the given union field if more than one of its members is set, or if
+// none are and the union is required. setMembers are the aliases of the members that are set
+func (e *ValidationErrors) AddUnionMembers(field string, setMembers []string, required bool) {
+	switch {
+	case len(setMembers) > 1:
+		e.Add(field, UnionValidator, "only one member can be set, got "+strings.Join(setMembers, ", "))
+	case required && len(setMembers) == 0:
+		e.Add(field, UnionValidator, "exactly one member must be set")
+	}
+}
+
+// Err returns these errors, or nil if there are none
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// FieldPath returns the path of the given field of the record at the given path, which is empty for the record being
+// validated
+func FieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// IndexPath returns the path of the element at the given index (or key) of the array (or map) at the given path
+func IndexPath(path string, index interface{}) string {
+	return fmt.Sprintf("%s[%v]", path, index)
+}
@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	var errs ValidationErrors
+	if errs.Err() != nil {
+		t.Fatalf("Expected no error, got %+v", errs.Err())
+	}
+
+	errs.Add(FieldPath("", "name"), RequiredValidator, "is required")
+	errs.Add(FieldPath(IndexPath(FieldPath("", "friends"), 1), "name"), "strlen", "must be at least 1 characters long")
+	errs.AddUnionMembers(FieldPath("", "contact"), []string{"email", "phone"}, false)
+	errs.AddUnionMembers(FieldPath("", "address"), nil, true)
+	errs.AddUnionMembers(FieldPath("", "nickname"), nil, false)
+	errs.AddUnionMembers(FieldPath("", "title"), []string{"string"}, true)
+
+	err := errs.Err()
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Expected ValidationErrors, got %+v", err)
+	}
+
+	expected := `go-restli: Invalid value for field "name" (required): is required
+go-restli: Invalid value for field "friends[1].name" (strlen): must be at least 1 characters long
+go-restli: Invalid value for field "contact" (union): only one member can be set, got email, phone
+go-restli: Invalid value for field "address" (union): exactly one member must be set`
+	if err.Error() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, err.Error())
+	}
+}
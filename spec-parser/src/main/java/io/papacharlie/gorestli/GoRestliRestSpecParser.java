@@ -48,11 +48,15 @@ public class GoRestliRestSpecParser {
 
   public static void main(String[] args) {
     if (args.length < 2) {
-      System.err.println("Usage: PEGASUS_DIR REST_SPEC,[REST_SPEC...]");
+      System.err.println("Usage: PEGASUS_DIR[" + File.pathSeparator + "PEGASUS_DIR...] REST_SPEC,[REST_SPEC...]");
       System.exit(1);
     }
 
-    String resolverPath = Paths.get(args[0]).toAbsolutePath().normalize().toString();
+    // The resolver path is a search path: referenced schemas are looked up in each of the given roots, in order
+    String resolverPath = Arrays.stream(args[0].split(File.pathSeparator))
+        .filter(p -> !p.isEmpty())
+        .map(p -> Paths.get(p).toAbsolutePath().normalize().toString())
+        .collect(Collectors.joining(File.pathSeparator));
     Set<String> restSpecs = Arrays.stream(Arrays.copyOfRange(args, 1, args.length))
         .map(p -> Paths.get(p).toAbsolutePath().normalize().toString())
         .peek(System.err::println)
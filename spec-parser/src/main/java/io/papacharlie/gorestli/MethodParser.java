@@ -1,41 +1,57 @@
 package io.papacharlie.gorestli;
 
 import com.google.common.collect.ImmutableSet;
+import com.linkedin.data.DataMap;
 import com.linkedin.restli.common.ResourceMethod;
 import com.linkedin.restli.restspec.ActionSchema;
+import com.linkedin.restli.restspec.AssociationSchema;
 import com.linkedin.restli.restspec.CollectionSchema;
+import com.linkedin.restli.restspec.CustomAnnotationContentSchemaMap;
 import com.linkedin.restli.restspec.FinderSchema;
 import com.linkedin.restli.restspec.ParameterSchema;
 import com.linkedin.restli.restspec.ParameterSchemaArray;
 import com.linkedin.restli.restspec.ResourceSchema;
+import com.linkedin.restli.restspec.RestMethodSchema;
 import io.papacharlie.gorestli.json.Method;
+import io.papacharlie.gorestli.json.Method.MaxBatchSize;
 import io.papacharlie.gorestli.json.Method.MethodType;
 import io.papacharlie.gorestli.json.Method.PathKey;
 import io.papacharlie.gorestli.json.Record.Field;
 import io.papacharlie.gorestli.json.RestliType;
 import java.util.ArrayList;
 import java.util.Collections;
+import java.util.LinkedHashSet;
 import java.util.List;
+import java.util.Map;
 import java.util.Set;
 
 import static io.papacharlie.gorestli.json.Method.MethodType.*;
 
 
 public class MethodParser {
-  private static final Set<ResourceMethod> NO_KEY_METHODS =
-      ImmutableSet.of(ResourceMethod.CREATE, ResourceMethod.GET_ALL);
+  private static final Set<ResourceMethod> NO_KEY_METHODS = ImmutableSet.of(
+      ResourceMethod.CREATE,
+      ResourceMethod.GET_ALL,
+      ResourceMethod.BATCH_GET,
+      ResourceMethod.BATCH_CREATE,
+      ResourceMethod.BATCH_DELETE,
+      ResourceMethod.BATCH_UPDATE,
+      ResourceMethod.BATCH_PARTIAL_UPDATE);
 
   private final TypeParser _typeParser;
   private final ResourceSchema _resource;
+  // The namespace of the resource's generated package, which is where the records of association keys are declared
+  private final String _namespace;
   private final RestliType _resourceSchema;
   private final String _path;
   private final List<PathKey> _pathKeys;
   private final String _entityPath;
   private final List<PathKey> _entityPathKeys;
 
-  public MethodParser(TypeParser typeParser, ResourceSchema resource, List<PathKey> pathKeys) {
+  public MethodParser(TypeParser typeParser, ResourceSchema resource, String namespace, List<PathKey> pathKeys) {
     _typeParser = typeParser;
     _resource = resource;
+    _namespace = namespace;
     if (_resource.getSchema() != null) {
       _resourceSchema = _typeParser.parseFromRestSpec(_resource.getSchema());
     } else {
@@ -47,6 +63,11 @@ public class MethodParser {
       CollectionSchema collectionSchema = resource.getCollection();
       _entityPath = collectionSchema.getEntity().getPath();
       _entityPathKeys = Utils.append(_pathKeys, PathKey.forCollection(collectionSchema, _typeParser));
+    } else if (resource.getAssociation() != null) {
+      AssociationSchema associationSchema = resource.getAssociation();
+      _entityPath = associationSchema.getEntity().getPath();
+      _entityPathKeys = Utils.append(_pathKeys,
+          PathKey.forAssociation(associationSchema, _namespace, resource.getName(), _typeParser));
     } else {
       _entityPath = null;
       _entityPathKeys = null;
@@ -56,6 +77,8 @@ public class MethodParser {
   public Method newActionMethod(ActionSchema action, boolean isActionOnEntity) {
     Method method = newMethod(action.getName(), ACTION, isActionOnEntity);
     method._doc = action.getDoc();
+    method._deprecated = deprecation(action.getAnnotations());
+    method._annotations = annotations(action.getAnnotations());
     method._params = toFieldList(action.getParameters());
 
     if (action.getReturns() != null) {
@@ -68,12 +91,29 @@ public class MethodParser {
   public Method newFinderMethod(FinderSchema finder) {
     Method method = newMethod(finder.getName(), FINDER, false);
     method._doc = finder.getDoc();
+    method._deprecated = deprecation(finder.getAnnotations());
+    method._annotations = annotations(finder.getAnnotations());
     method._params = toFieldList(finder.getParameters());
     method._return = _resourceSchema;
+    if (finder.hasMetadata()) {
+      method._metadata = _typeParser.parseFromRestSpec(finder.getMetadata().getType());
+    }
+
+    // Finders of associations can bind to some parts of the association's key, which are then given in the path as a
+    // partial compound key. Only those parts are passed to the finder
+    Set<String> assocKeys = new LinkedHashSet<>(Utils.emptyIfNull(finder.getAssocKeys()));
+    if (finder.hasAssocKey()) {
+      assocKeys.add(finder.getAssocKey());
+    }
+    if (_resource.getAssociation() != null && !assocKeys.isEmpty()) {
+      method._path = _entityPath;
+      method._pathKeys = Utils.append(_pathKeys, PathKey.forAssociationFinder(_resource.getAssociation(), _namespace,
+          _resource.getName(), finder.getName(), assocKeys, _typeParser));
+    }
     return method;
   }
 
-  public Method newRestMethod(String restMethod) {
+  public Method newRestMethod(String restMethod, RestMethodSchema methodSchema) {
     boolean onEntity;
     if (_resource.getSimple() != null) {
       // simple resources don't have entities
@@ -84,9 +124,41 @@ public class MethodParser {
 
     Method method = newMethod(restMethod, REST_METHOD, onEntity);
     method._return = _resourceSchema;
+    if (methodSchema != null) {
+      method._doc = methodSchema.getDoc();
+      method._deprecated = deprecation(methodSchema.getAnnotations());
+      method._annotations = annotations(methodSchema.getAnnotations());
+      // Read from the raw data since maxBatchSize is not present in the restspec schemas of older rest.li versions
+      Object maxBatchSize = methodSchema.data().get("maxBatchSize");
+      if (maxBatchSize instanceof DataMap && ((DataMap) maxBatchSize).getInteger("value") != null) {
+        DataMap maxBatchSizeMap = (DataMap) maxBatchSize;
+        method._maxBatchSize = new MaxBatchSize(
+            maxBatchSizeMap.getInteger("value"),
+            Boolean.TRUE.equals(maxBatchSizeMap.getBoolean("validate")));
+      }
+      // Same as maxBatchSize, the metadata of GET_ALL methods is missing from the schemas of older rest.li versions
+      Object metadata = methodSchema.data().get("metadata");
+      if (metadata instanceof DataMap && ((DataMap) metadata).getString("type") != null) {
+        method._metadata = _typeParser.parseFromRestSpec(((DataMap) metadata).getString("type"));
+      }
+    }
     return method;
   }
 
+  private static String deprecation(CustomAnnotationContentSchemaMap annotations) {
+    if (annotations == null || !annotations.containsKey("deprecated")) {
+      return null;
+    }
+    return Utils.deprecation(annotations.get("deprecated").data());
+  }
+
+  private static Map<String, Object> annotations(CustomAnnotationContentSchemaMap annotations) {
+    if (annotations == null || annotations.isEmpty()) {
+      return null;
+    }
+    return annotations.data();
+  }
+
   private List<Field> toFieldList(ParameterSchemaArray parameters) {
     if (parameters == null || parameters.isEmpty()) {
       return Collections.emptyList();
@@ -2,17 +2,24 @@ package io.papacharlie.gorestli;
 
 import com.linkedin.restli.restspec.ActionSchema;
 import com.linkedin.restli.restspec.ActionSchemaArray;
+import com.linkedin.restli.restspec.AlternativeKeySchema;
+import com.linkedin.restli.restspec.AssociationSchema;
 import com.linkedin.restli.restspec.CollectionSchema;
 import com.linkedin.restli.restspec.FinderSchema;
 import com.linkedin.restli.restspec.ResourceSchema;
+import com.linkedin.restli.restspec.RestMethodSchema;
+import com.linkedin.restli.restspec.RestMethodSchemaArray;
 import com.linkedin.restli.restspec.SimpleSchema;
 import io.papacharlie.gorestli.json.Method.PathKey;
 import io.papacharlie.gorestli.json.Resource;
+import io.papacharlie.gorestli.json.Resource.AlternativeKey;
 import io.papacharlie.gorestli.json.RestliType;
 import java.io.File;
 import java.util.Collections;
+import java.util.HashMap;
 import java.util.HashSet;
 import java.util.List;
+import java.util.Map;
 import java.util.Set;
 
 
@@ -33,7 +40,7 @@ public class ResourceParser {
     _typeParser = typeParser;
     _namespaceChain = Utils.append(namespaceChain, schema.getName());
     _pathKeys = pathKeys;
-    _methodParser = new MethodParser(_typeParser, _schema, _pathKeys);
+    _methodParser = new MethodParser(_typeParser, _schema, String.join(".", _namespaceChain), _pathKeys);
   }
 
   private ResourceParser(ResourceParser parent, ResourceSchema subResource, PathKey pathKey) {
@@ -47,14 +54,7 @@ public class ResourceParser {
   }
 
   public Set<Resource> parse() {
-    if (_schema.hasCollection() && _schema.getCollection().getIdentifier().hasParams()) {
-      Utils.log("Complex Key resources are not supported. Skipping %s and its children.%n", _namespaceChain);
-      return Collections.emptySet();
-    }
-
     Resource resource = newResource();
-    MethodParser methodParser = new MethodParser(_typeParser, _schema, _pathKeys);
-
     Set<Resource> resourcesAndSubResources = new HashSet<>();
     resourcesAndSubResources.add(resource);
 
@@ -65,7 +65,7 @@ public class ResourceParser {
     if (_schema.getSimple() != null) {
       SimpleSchema simple = _schema.getSimple();
       addActions(resource, simple.getActions(), false);
-      addRestMethods(resource, simple.getSupports());
+      addRestMethods(resource, simple.getSupports(), simple.getMethods());
 
       for (ResourceSchema subResource : Utils.emptyIfNull(simple.getEntity().getSubresources())) {
         resourcesAndSubResources.addAll(new ResourceParser(this, subResource, null).parse());
@@ -76,10 +76,17 @@ public class ResourceParser {
       CollectionSchema collection = _schema.getCollection();
       addActions(resource, collection.getActions(), false);
       addActions(resource, collection.getEntity().getActions(), true);
-      addRestMethods(resource, collection.getSupports());
+      addRestMethods(resource, collection.getSupports(), collection.getMethods());
 
       for (FinderSchema finder : Utils.emptyIfNull(collection.getFinders())) {
-        resource.addMethod(methodParser.newFinderMethod(finder));
+        resource.addMethod(_methodParser.newFinderMethod(finder));
+      }
+
+      for (AlternativeKeySchema alternativeKey : Utils.emptyIfNull(collection.getAlternativeKeys())) {
+        resource.addAlternativeKey(new AlternativeKey(
+            alternativeKey.getName(),
+            alternativeKey.getDoc(),
+            _typeParser.parseFromRestSpec(alternativeKey.getType())));
       }
 
       PathKey pathKey = PathKey.forCollection(collection, _typeParser);
@@ -88,6 +95,31 @@ public class ResourceParser {
       }
     }
 
+    if (_schema.getAssociation() != null) {
+      AssociationSchema association = _schema.getAssociation();
+      addActions(resource, association.getActions(), false);
+      addActions(resource, association.getEntity().getActions(), true);
+      addRestMethods(resource, association.getSupports(), association.getMethods());
+
+      for (FinderSchema finder : Utils.emptyIfNull(association.getFinders())) {
+        resource.addMethod(_methodParser.newFinderMethod(finder));
+      }
+
+      for (AlternativeKeySchema alternativeKey : Utils.emptyIfNull(association.getAlternativeKeys())) {
+        resource.addAlternativeKey(new AlternativeKey(
+            alternativeKey.getName(),
+            alternativeKey.getDoc(),
+            _typeParser.parseFromRestSpec(alternativeKey.getType())));
+      }
+
+      // Sub-resources are always given the association's whole compound key, which rest.li sends as a single segment
+      PathKey pathKey = PathKey.forAssociation(association, String.join(".", _namespaceChain), _schema.getName(),
+          _typeParser);
+      for (ResourceSchema subResource : Utils.emptyIfNull(association.getEntity().getSubresources())) {
+        resourcesAndSubResources.addAll(new ResourceParser(this, subResource, pathKey).parse());
+      }
+    }
+
     return resourcesAndSubResources;
   }
 
@@ -95,17 +127,25 @@ public class ResourceParser {
     RestliType resourceType = _schema.hasSchema()
         ? _typeParser.parseFromRestSpec(_schema.getSchema())
         : null;
-    return new Resource(
+    Resource resource = new Resource(
         String.join(".", _namespaceChain),
         _schema.getDoc(),
         _resourceFilename,
         _rootResourceName,
         resourceType);
+    // Read from the raw data since entityType is not present in the restspec schemas of older rest.li versions
+    resource._isUnstructuredData = "UNSTRUCTURED_DATA".equals(_schema.data().getString("entityType"));
+    return resource;
   }
 
-  private void addRestMethods(Resource resource, List<String> restMethods) {
+  private void addRestMethods(Resource resource, List<String> restMethods, RestMethodSchemaArray methodSchemas) {
+    Map<String, RestMethodSchema> methodSchemasByName = new HashMap<>();
+    for (RestMethodSchema methodSchema : Utils.emptyIfNull(methodSchemas)) {
+      methodSchemasByName.put(methodSchema.getMethod(), methodSchema);
+    }
+
     for (String restMethod : Utils.emptyIfNull(restMethods)) {
-      resource.addMethod(_methodParser.newRestMethod(restMethod));
+      resource.addMethod(_methodParser.newRestMethod(restMethod, methodSchemasByName.get(restMethod)));
     }
   }
 
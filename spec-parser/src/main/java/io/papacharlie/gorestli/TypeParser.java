@@ -1,6 +1,7 @@
 package io.papacharlie.gorestli;
 
 import com.google.common.base.Preconditions;
+import com.linkedin.data.DataMap;
 import com.linkedin.data.schema.ArrayDataSchema;
 import com.linkedin.data.schema.DataSchema;
 import com.linkedin.data.schema.DataSchemaLocation;
@@ -25,8 +26,10 @@ import io.papacharlie.gorestli.json.RestliType;
 import io.papacharlie.gorestli.json.Typeref;
 import java.io.File;
 import java.util.ArrayList;
+import java.util.HashMap;
 import java.util.HashSet;
 import java.util.List;
+import java.util.Map;
 import java.util.Set;
 import java.util.stream.Collectors;
 
@@ -35,6 +38,8 @@ import static io.papacharlie.gorestli.json.RestliType.*;
 
 
 public class TypeParser {
+  private static final String VALIDATE_PROPERTY = "validate";
+
   private final DataSchemaResolver _dataSchemaResolver;
 
   public TypeParser(DataSchemaResolver dataSchemaResolver) {
@@ -88,14 +93,38 @@ public class TypeParser {
       fields.add(new Field(
           field.getName(),
           field.getDoc(),
+          Utils.deprecation(field.getProperties().get("deprecated")),
           fromDataSchema(fieldType),
           optional,
-          field.getDefault()));
+          field.getDefault(),
+          validators(field),
+          field.getProperties()));
     }
 
     return new DataType(new Record(schema, sourceFile, fields));
   }
 
+  /**
+   * Collects the "validate" properties of the given field and of the typerefs its type goes through. Validators
+   * declared closer to the field take precedence.
+   */
+  private static Map<String, Object> validators(RecordDataSchema.Field field) {
+    Map<String, Object> validators = new HashMap<>();
+    addValidators(validators, field.getProperties().get(VALIDATE_PROPERTY));
+    DataSchema schema = field.getType();
+    while (schema instanceof TyperefDataSchema) {
+      addValidators(validators, schema.getProperties().get(VALIDATE_PROPERTY));
+      schema = ((TyperefDataSchema) schema).getRef();
+    }
+    return validators;
+  }
+
+  private static void addValidators(Map<String, Object> validators, Object validate) {
+    if (validate instanceof DataMap) {
+      ((DataMap) validate).forEach(validators::putIfAbsent);
+    }
+  }
+
   private DataType parseDataType(EnumDataSchema schema, File sourceFile) {
     return new DataType(new Enum(schema, sourceFile, schema.getSymbols(), schema.getSymbolDocs()));
   }
@@ -127,7 +156,14 @@ public class TypeParser {
       case ARRAY:
         return new RestliType(null, null, fromDataSchema(((ArrayDataSchema) schema).getItems()), null, null);
       case MAP:
-        return new RestliType(null, null, null, fromDataSchema(((MapDataSchema) schema).getValues()), null);
+        MapDataSchema map = (MapDataSchema) schema;
+        // Keys are always strings, but a typeref to string can give them a more specific type. Keys that are typeref'd
+        // to anything else (e.g. a long holding a timestamp) are left as plain strings, since that is how they are sent
+        RestliType mapKey = (map.getKey() != null && map.getKey().getType() == TYPEREF
+            && map.getKey().getDereferencedType() == STRING)
+            ? fromDataSchema(map.getKey())
+            : null;
+        return new RestliType(null, null, null, fromDataSchema(map.getValues()), mapKey, null);
       case UNION:
         UnionDataSchema union = (UnionDataSchema) schema;
         List<UnionMember> unionMembers = union.getMembers().stream()
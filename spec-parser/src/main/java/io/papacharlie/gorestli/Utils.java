@@ -1,6 +1,7 @@
 package io.papacharlie.gorestli;
 
 import com.google.gson.Gson;
+import com.linkedin.data.DataMap;
 import com.google.gson.GsonBuilder;
 import java.time.LocalDateTime;
 import java.time.format.DateTimeFormatter;
@@ -49,6 +50,24 @@ public class Utils {
         : list;
   }
 
+  /**
+   * Returns the message of the given "deprecated" property or annotation. Deprecations without a message return the
+   * empty string, and null is returned if there is no deprecation at all.
+   */
+  public static String deprecation(Object deprecated) {
+    if (deprecated instanceof String) {
+      return (String) deprecated;
+    }
+    if (deprecated instanceof DataMap) {
+      Object doc = ((DataMap) deprecated).get("doc");
+      return (doc instanceof String) ? (String) doc : "";
+    }
+    if (Boolean.TRUE.equals(deprecated)) {
+      return "";
+    }
+    return null;
+  }
+
   public static void log(String format, Object... args) {
     System.err.printf("[go-restli] " + LOG_TIME_FORMAT.format(LocalDateTime.now()) + " " + format, args);
   }
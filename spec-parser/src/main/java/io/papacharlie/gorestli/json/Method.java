@@ -1,9 +1,17 @@
 package io.papacharlie.gorestli.json;
 
+import com.linkedin.restli.restspec.AssocKeySchema;
+import com.linkedin.restli.restspec.AssociationSchema;
 import com.linkedin.restli.restspec.CollectionSchema;
+import com.linkedin.restli.restspec.IdentifierSchema;
 import io.papacharlie.gorestli.TypeParser;
 import io.papacharlie.gorestli.json.Record.Field;
+import io.papacharlie.gorestli.json.RestliType.Identifier;
+import java.util.ArrayList;
+import java.util.Collection;
 import java.util.List;
+import java.util.Map;
+import org.apache.commons.lang3.StringUtils;
 
 
 public class Method {
@@ -16,25 +24,94 @@ public class Method {
   public MethodType _methodType;
   public String _name;
   public String _doc;
+  public String _deprecated;
   public String _path;
   public boolean _onEntity;
   public List<PathKey> _pathKeys;
   public List<Field> _params;
   public RestliType _return;
+  public MaxBatchSize _maxBatchSize;
+  // The type of the metadata of the CollectionResponse returned by finders and GET_ALL methods, if they declare one
+  public RestliType _metadata;
+  // The raw contents of the custom annotations declared on the method, keyed by annotation name
+  public Map<String, Object> _annotations;
+
+  public static class MaxBatchSize {
+    public final int _value;
+    public final boolean _validate;
+
+    public MaxBatchSize(int value, boolean validate) {
+      _value = value;
+      _validate = validate;
+    }
+  }
 
   public static class PathKey {
     public final String _name;
     public final RestliType _type;
+    public final RestliType _params;
+    // The parts of the compound key of an association, only set for association keys. The generator declares a record
+    // with these fields under the name _type references
+    public final List<Field> _assocKeys;
 
     public PathKey(String name, RestliType type) {
+      this(name, type, null);
+    }
+
+    public PathKey(String name, RestliType type, RestliType params) {
+      this(name, type, params, null);
+    }
+
+    private PathKey(String name, RestliType type, RestliType params, List<Field> assocKeys) {
       _name = name;
       _type = type;
+      _params = params;
+      _assocKeys = assocKeys;
     }
 
     public static PathKey forCollection(CollectionSchema collection, TypeParser typeParser) {
+      IdentifierSchema identifier = collection.getIdentifier();
       return new PathKey(
-          collection.getIdentifier().getName(),
-          typeParser.parseFromRestSpec(collection.getIdentifier().getType()));
+          identifier.getName(),
+          typeParser.parseFromRestSpec(identifier.getType()),
+          identifier.hasParams() ? typeParser.parseFromRestSpec(identifier.getParams()) : null);
+    }
+
+    /**
+     * Returns the compound key of the given association resource, whose record is declared in the resource's namespace
+     * under the capitalized name of the association's identifier.
+     */
+    public static PathKey forAssociation(AssociationSchema association, String namespace, String resourceName,
+        TypeParser typeParser) {
+      String identifier = associationIdentifier(association, resourceName);
+      return forAssociation(association, namespace, StringUtils.capitalize(identifier), identifier, typeParser, null);
+    }
+
+    /**
+     * Returns the partial compound key made of the given parts of the association's key, which finders declare with
+     * assocKeys. Its record is named after the finder.
+     */
+    public static PathKey forAssociationFinder(AssociationSchema association, String namespace, String resourceName,
+        String finderName, Collection<String> assocKeyNames, TypeParser typeParser) {
+      return forAssociation(association, namespace, StringUtils.capitalize(finderName) + "AssocKeys",
+          associationIdentifier(association, resourceName), typeParser, assocKeyNames);
+    }
+
+    private static PathKey forAssociation(AssociationSchema association, String namespace, String typeName,
+        String identifier, TypeParser typeParser, Collection<String> assocKeyNames) {
+      List<Field> assocKeys = new ArrayList<>();
+      for (AssocKeySchema assocKey : association.getAssocKeys()) {
+        if (assocKeyNames == null || assocKeyNames.contains(assocKey.getName())) {
+          assocKeys.add(new Field(assocKey.getName(), null, typeParser.parseFromRestSpec(assocKey.getType()), false));
+        }
+      }
+      RestliType type = new RestliType(null, new Identifier(namespace, typeName), null, null, null);
+      return new PathKey(identifier, type, null, assocKeys);
+    }
+
+    // The identifier is missing from the restspecs of older rest.li versions, which default it to <resource>Id
+    private static String associationIdentifier(AssociationSchema association, String resourceName) {
+      return association.hasIdentifier() ? association.getIdentifier() : resourceName + "Id";
     }
   }
 }
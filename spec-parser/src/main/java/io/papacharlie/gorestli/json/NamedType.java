@@ -1,6 +1,7 @@
 package io.papacharlie.gorestli.json;
 
 import com.linkedin.data.schema.NamedDataSchema;
+import io.papacharlie.gorestli.Utils;
 import java.io.File;
 import java.util.Objects;
 
@@ -9,16 +10,23 @@ public abstract class NamedType {
   public final String _name;
   public final String _namespace;
   public final String _doc;
+  public final String _deprecated;
   public final String _sourceFile;
 
   protected NamedType(NamedDataSchema namedDataSchema, File sourceFile) {
-    this(namedDataSchema.getName(), namedDataSchema.getNamespace(), namedDataSchema.getDoc(), sourceFile);
+    this(namedDataSchema.getName(), namedDataSchema.getNamespace(), namedDataSchema.getDoc(),
+        Utils.deprecation(namedDataSchema.getProperties().get("deprecated")), sourceFile);
   }
 
   protected NamedType(String name, String namespace, String doc, File sourceFile) {
+    this(name, namespace, doc, null, sourceFile);
+  }
+
+  protected NamedType(String name, String namespace, String doc, String deprecated, File sourceFile) {
     _name = name;
     _namespace = namespace;
     _doc = doc;
+    _deprecated = deprecated;
     _sourceFile = sourceFile.getAbsolutePath();
   }
 
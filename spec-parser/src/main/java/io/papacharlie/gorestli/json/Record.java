@@ -4,6 +4,7 @@ import com.linkedin.data.schema.NamedDataSchema;
 import io.papacharlie.gorestli.Utils;
 import java.io.File;
 import java.util.List;
+import java.util.Map;
 
 
 public class Record extends NamedType {
@@ -17,20 +18,27 @@ public class Record extends NamedType {
   public static class Field {
     public final String _name;
     public final String _doc;
+    public final String _deprecated;
     public final RestliType _type;
     public final boolean _isOptional;
     public final String _defaultValue;
+    public final Map<String, Object> _validators;
+    public final Map<String, Object> _properties;
 
-    public Field(String name, String doc, RestliType type, Boolean isOptional, Object defaultValue) {
+    public Field(String name, String doc, String deprecated, RestliType type, Boolean isOptional,
+        Object defaultValue, Map<String, Object> validators, Map<String, Object> properties) {
       _name = name;
       _doc = doc;
+      _deprecated = deprecated;
       _type = type;
       _isOptional = (isOptional == null) ? false : isOptional;
       _defaultValue = (defaultValue == null) ? null : Utils.toJson(defaultValue);
+      _validators = (validators == null || validators.isEmpty()) ? null : validators;
+      _properties = (properties == null || properties.isEmpty()) ? null : properties;
     }
 
     public Field(String name, String doc, RestliType type, Boolean isOptional) {
-      this(name, doc, type, isOptional, null);
+      this(name, doc, null, type, isOptional, null, null, null);
     }
   }
 }
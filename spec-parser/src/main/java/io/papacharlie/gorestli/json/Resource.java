@@ -11,7 +11,9 @@ public class Resource {
   public final String _sourceFile;
   public final String _rootResourceName;
   public final RestliType _resourceSchema;
+  public boolean _isUnstructuredData;
   public List<Method> _methods;
+  public List<AlternativeKey> _alternativeKeys;
 
   public Resource(String namespace, String doc, String sourceFile, String rootResourceName, RestliType resourceSchema) {
     _namespace = namespace;
@@ -26,6 +28,23 @@ public class Resource {
     return this;
   }
 
+  public Resource addAlternativeKey(AlternativeKey key) {
+    _alternativeKeys = Utils.append(_alternativeKeys, key);
+    return this;
+  }
+
+  public static class AlternativeKey {
+    public final String _name;
+    public final String _doc;
+    public final RestliType _type;
+
+    public AlternativeKey(String name, String doc, RestliType type) {
+      _name = name;
+      _doc = doc;
+      _type = type;
+    }
+  }
+
 
   @Override
   public int hashCode() {
@@ -23,13 +23,20 @@ public final class RestliType {
   public final Identifier _reference;
   public final RestliType _array;
   public final RestliType _map;
+  public final RestliType _mapKey;
   public final List<UnionMember> _union;
 
   public RestliType(String primitive, Identifier reference, RestliType array, RestliType map, List<UnionMember> union) {
+    this(primitive, reference, array, map, null, union);
+  }
+
+  public RestliType(String primitive, Identifier reference, RestliType array, RestliType map, RestliType mapKey,
+      List<UnionMember> union) {
     _primitive = primitive;
     _reference = reference;
     _array = array;
     _map = map;
+    _mapKey = mapKey;
     _union = (union == null) ? null : Collections.unmodifiableList(union);
   }
 